@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Base temperature of growing degree days in °C, that of maize and most warm
+// season crops, and the cap above which heat no longer speeds growth
+const (
+	DEFAULT_GDD_BASE_C = 10.0
+	GDD_CAP_C          = 30.0
+)
+
+// Base of the growing degree days in the temperature unit shown, from -base,
+// WEATHER_GDD_BASE or gdd_base; NaN for DEFAULT_GDD_BASE_C
+var gddBase = math.NaN()
+
+// Stored observations this close to the location count as its own, in km
+const AGRI_HISTORY_KM = 25
+
+// Observations a stored day needs before its low and high are trusted over
+// the archive's
+const AGRI_DAY_OBSERVATIONS = 6
+
+// Days of the past shown before the forecast in the table
+const AGRI_PAST_DAYS_SHOWN = 7
+
+// Where the temperatures of a day come from
+const (
+	HISTORY_SOURCE  = "history"
+	ARCHIVE_SOURCE  = "archive"
+	FORECAST_SOURCE = "forecast"
+)
+
+// Low and high of one day in °C
+type dayTemperatures struct {
+	Date   time.Time // Local midnight
+	Min    float64
+	Max    float64
+	Source string
+}
+
+// Growing degree days of a day by the modified method: the high is capped
+// at GDD_CAP_C and the low raised to the base, both in °C
+func growingDegreeDays(low float64, high float64, base float64) float64 {
+	high = max(min(high, GDD_CAP_C), base)
+	low = min(max(low, base), high)
+
+	return (high+low)/2 - base
+}
+
+// Days Open-Meteo recorded in °C from one date to another, both included.
+// Spans older than the forecast endpoint keeps are taken from the archive.
+func fetchPastDays(ctx context.Context, at coordinate, from time.Time, to time.Time) ([]dailyForecast, error) {
+	var days []dailyForecast
+
+	// The archive trails behind, so the last days come from the forecast
+	cut := time.Now().AddDate(0, 0, -ARCHIVE_DELAY_DAYS-1)
+	if from.Before(cut) && to.After(cut) {
+		archived, err := fetchOpenMeteoDays(ctx, at, METRIC, from, cut)
+		if err != nil {
+			return nil, err
+		}
+		days = archived.Daily
+		from = cut.AddDate(0, 0, 1)
+	}
+
+	recent, err := fetchOpenMeteoDays(ctx, at, METRIC, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(days, recent.Daily...), nil
+}
+
+// Lows and highs of the stored observations near a coordinate by local date,
+// for the days observed often enough
+func historyTemperatures(at coordinate) (map[string]dayTemperatures, error) {
+	entries, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	days := map[string]dayTemperatures{}
+	for _, entry := range entries {
+		if distanceKm(at, coordinate{Lat: entry.Lat, Lon: entry.Lon}) > AGRI_HISTORY_KM {
+			continue
+		}
+
+		moment := time.Unix(entry.Time, 0).In(time.FixedZone("", entry.TzOffset))
+		key := moment.Format("2006-01-02")
+		day, seen := days[key]
+		if !seen {
+			day = dayTemperatures{Date: time.Date(moment.Year(), moment.Month(), moment.Day(), 0, 0, 0, 0, moment.Location()), Min: entry.Temp, Max: entry.Temp, Source: HISTORY_SOURCE}
+		}
+		day.Min, day.Max = min(day.Min, entry.Temp), max(day.Max, entry.Temp)
+		days[key] = day
+		counts[key]++
+	}
+
+	for key, count := range counts {
+		if count < AGRI_DAY_OBSERVATIONS {
+			delete(days, key)
+		}
+	}
+
+	return days, nil
+}
+
+// The lows and highs from a date through the forecast: observed days from
+// the stored history where it has enough of them, the others from the
+// Open-Meteo archive, then the forecast from today on
+func seasonTemperatures(ctx context.Context, result report, since time.Time) ([]dayTemperatures, error) {
+	weather := result.Weather
+	units := result.Options.Units
+	zone := time.FixedZone(weather.Timezone, weather.Offset)
+	now := weather.Current.Time.In(zone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, zone)
+
+	var season []dayTemperatures
+	if since.Before(today) {
+		stored, err := historyTemperatures(weather.Coord)
+		if err != nil {
+			logger.Debug("history lookup failed", "error", err)
+		}
+
+		past, err := fetchPastDays(ctx, weather.Coord, since, today.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, day := range past {
+			date := time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, zone)
+			if observed, found := stored[date.Format("2006-01-02")]; found {
+				season = append(season, observed)
+				continue
+			}
+			season = append(season, dayTemperatures{Date: date, Min: day.TempMin, Max: day.TempMax, Source: ARCHIVE_SOURCE})
+		}
+	}
+
+	for _, day := range weather.Daily {
+		date := time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, zone)
+		if date.Before(today) || date.Before(since) {
+			continue
+		}
+		season = append(season, dayTemperatures{Date: date, Min: units.toCelsius(day.TempMin), Max: units.toCelsius(day.TempMax), Source: FORECAST_SOURCE})
+	}
+
+	return season, nil
+}
+
+// The start of the growing season a date falls in: January 1, or July 1 in
+// the southern hemisphere
+func seasonStart(today time.Time, latitude float64) time.Time {
+	if latitude >= 0 {
+		return time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+	}
+
+	start := time.Date(today.Year(), time.July, 1, 0, 0, 0, 0, today.Location())
+	if today.Before(start) {
+		start = start.AddDate(-1, 0, 0)
+	}
+
+	return start
+}
+
+// Implements `weather agri`, the growing degree days accumulated since the
+// start of the season from the stored history and the archive, and where the
+// forecast takes them, then the soil temperature and moisture by depth that
+// tell when to plant
+func runAgri(ctx context.Context, target location, since string, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	now := weather.Current.Time.In(time.FixedZone(weather.Timezone, weather.Offset))
+
+	start := seasonStart(now, weather.Coord.Lat)
+	if since != "" {
+		if start, err = parseDay(since, now); err != nil {
+			return err
+		}
+		if start.After(now) {
+			return fmt.Errorf("-since %s hasn't happened yet", since)
+		}
+	}
+
+	// The base is given in the unit shown, degree days are counted in it too
+	base := DEFAULT_GDD_BASE_C
+	if !math.IsNaN(gddBase) {
+		base = options.Units.toCelsius(gddBase)
+	}
+	scale := options.Units.fromCelsius(1) - options.Units.fromCelsius(0)
+	degrees := func(celsius float64) string {
+		return fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+	}
+
+	season, err := seasonTemperatures(ctx, result, start)
+	if err != nil {
+		return err
+	}
+
+	observed, forecast, counts := 0.0, 0.0, map[string]int{}
+	for _, day := range season {
+		if day.Source == FORECAST_SOURCE {
+			forecast += growingDegreeDays(day.Min, day.Max, base) * scale
+		} else {
+			observed += growingDegreeDays(day.Min, day.Max, base) * scale
+		}
+		counts[day.Source]++
+	}
+
+	fmt.Printf(tr("Growing degree days above %s since %s: %.0f")+"\n", degrees(base), start.Format("Jan 2 2006"), observed)
+	if counts[HISTORY_SOURCE]+counts[ARCHIVE_SOURCE] > 0 {
+		fmt.Printf(tr("From %d days of your history and %d from the Open-Meteo archive")+"\n", counts[HISTORY_SOURCE], counts[ARCHIVE_SOURCE])
+	}
+	if counts[FORECAST_SOURCE] > 0 {
+		last := season[len(season)-1].Date
+		fmt.Printf(tr("Forecast: %+.0f from today to %s, %.0f in all")+"\n", forecast, last.Format("Mon Jan 2"), observed+forecast)
+	}
+
+	rows := [][]string{translated("Day", "Low", "High", "GDD", "Total", "Source")}
+	total := 0.0
+	for index, day := range season {
+		gdd := growingDegreeDays(day.Min, day.Max, base) * scale
+		total += gdd
+		if index < len(season)-counts[FORECAST_SOURCE]-AGRI_PAST_DAYS_SHOWN {
+			continue
+		}
+
+		rows = append(rows, []string{
+			day.Date.Format("Mon Jan 2"),
+			degrees(day.Min),
+			degrees(day.Max),
+			fmt.Sprintf("%.1f", gdd),
+			fmt.Sprintf("%.0f", total),
+			tr(day.Source),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	// The soil is a bonus, degree days stand without it
+	soil, err := fetchSoil(ctx, weather.Coord)
+	if err != nil {
+		logger.Debug("soil lookup failed", "error", err)
+		return nil
+	}
+	printSoil(soil, weather.Current.Time, options)
+
+	return nil
+}
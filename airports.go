@@ -0,0 +1,87 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Major airports with their IATA and ICAO codes, compiled into the binary so
+// codes resolve without a network lookup
+//
+//go:embed airports.csv
+var airportsCSV string
+
+// One airport of the embedded list
+type airport struct {
+	IATA    string
+	ICAO    string
+	Name    string
+	City    string
+	Country string
+	Coord   coordinate
+}
+
+// Airports keyed by both of their codes, parsed on first use
+var airports = sync.OnceValue(func() map[string]airport {
+	records, err := csv.NewReader(strings.NewReader(airportsCSV)).ReadAll()
+	if err != nil {
+		panic("parsing the embedded airport list: " + err.Error())
+	}
+
+	byCode := map[string]airport{}
+	for _, record := range records[1:] {
+		lat, latErr := strconv.ParseFloat(record[5], 64)
+		lon, lonErr := strconv.ParseFloat(record[6], 64)
+		if latErr != nil || lonErr != nil {
+			panic("bad coordinates for airport " + record[0] + " in the embedded list")
+		}
+
+		entry := airport{
+			IATA:    record[0],
+			ICAO:    record[1],
+			Name:    record[2],
+			City:    record[3],
+			Country: record[4],
+			Coord:   coordinate{Lat: lat, Lon: lon},
+		}
+
+		byCode[entry.IATA] = entry
+		byCode[entry.ICAO] = entry
+	}
+
+	return byCode
+})
+
+// Looks up an airport by its three letter IATA or four letter ICAO code.
+// Codes must be typed in capitals, so a town like "Ely" stays a place name.
+func findAirport(query string) (airport, bool) {
+	code := strings.TrimSpace(query)
+	if len(code) != 3 && len(code) != 4 {
+		return airport{}, false
+	}
+
+	for _, char := range code {
+		if char < 'A' || char > 'Z' {
+			return airport{}, false
+		}
+	}
+
+	found, ok := airports()[code]
+
+	return found, ok
+}
+
+// The airport as a location
+func (a airport) location() location {
+	return location{
+		Coord:       a.Coord,
+		Name:        a.Name,
+		FullName:    fmt.Sprintf("%s (%s/%s), %s", a.Name, a.IATA, a.ICAO, a.City),
+		CompactName: fmt.Sprintf("%s %s", a.IATA, a.City),
+		Country:     a.Country,
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+)
+
+const OPEN_METEO_AIR_QUALITY_URL = "https://air-quality-api.open-meteo.com/v1/air-quality"
+
+// Hourly US AQI from Open-Meteo; hours without a value are null
+type airQualityResponse struct {
+	Hourly struct {
+		Time  []int64    `json:"time"`
+		USAQI []*float64 `json:"us_aqi"`
+	} `json:"hourly"`
+}
+
+// Forecast US air quality index by the Unix time of each hour. Open-Meteo
+// serves it without a key, like its weather.
+func fetchAirQuality(ctx context.Context, at coordinate) (map[int64]float64, error) {
+	status("[@] Fetching air quality from Open-Meteo")
+
+	query := url.Values{}
+	query.Set("latitude", fmt.Sprintf("%f", at.Lat))
+	query.Set("longitude", fmt.Sprintf("%f", at.Lon))
+	query.Set("hourly", "us_aqi")
+	query.Set("timeformat", "unixtime")
+	query.Set("forecast_days", "2")
+
+	body, err := fetch(ctx, OPEN_METEO_AIR_QUALITY_URL+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("fetching air quality from Open-Meteo: %w", err)
+	}
+
+	var parsed airQualityResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing Open-Meteo air quality: %w", err)
+	}
+
+	indexes := map[int64]float64{}
+	for index, dt := range parsed.Hourly.Time {
+		if value := valueAt(parsed.Hourly.USAQI, index); value != nil {
+			indexes[dt] = *value
+		}
+	}
+
+	return indexes, nil
+}
+
+// EPA categories of the US AQI with their lower bounds
+var aqiCategories = []struct {
+	From float64
+	Name string
+}{
+	{301, "hazardous"},
+	{201, "very unhealthy"},
+	{151, "unhealthy"},
+	{101, "unhealthy for sensitive groups"},
+	{51, "moderate"},
+	{0, "good"},
+}
+
+// EPA category of a US AQI
+func aqiCategory(aqi float64) string {
+	for _, category := range aqiCategories {
+		if math.Round(aqi) >= category.From {
+			return category.Name
+		}
+	}
+
+	return "good"
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Implements `weather alerts`, listing the warnings in force for a place
+func runAlerts(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	alerts := result.Weather.Alerts
+	official := false
+
+	// The NWS itself tells where in the US a warning is drawn, which the
+	// providers leave out
+	if strings.EqualFold(target.Country, "US") {
+		if found, err := fetchNWSAlerts(ctx, target.Coord); err != nil {
+			logger.Debug("NWS alert lookup failed", "error", err)
+		} else {
+			alerts, official = found, true
+			for index := range alerts {
+				alerts[index].Start = result.Options.in(alerts[index].Start)
+				alerts[index].End = result.Options.in(alerts[index].End)
+			}
+		}
+	}
+
+	if len(alerts) == 0 {
+		if !official && !alertProviders[result.Provider] {
+			var capable []string
+			for _, name := range providerNames {
+				if alertProviders[name] {
+					capable = append(capable, name)
+				}
+			}
+
+			fmt.Printf(tr("%s doesn't publish weather alerts; try -providers %s")+"\n", result.Provider, strings.Join(capable, ","))
+			return nil
+		}
+
+		fmt.Println(tr("No active weather alerts."))
+		return nil
+	}
+
+	for _, alert := range alerts {
+		fmt.Printf("\n⚠️  %s\n", alert.Event)
+		if alert.Sender != "" {
+			fmt.Printf("%s: %s\n", tr("Issued by"), alert.Sender)
+		}
+		if span := formatSpan(alert.Start, alert.End, result.Options); span != "" {
+			fmt.Printf("%s: %s\n", tr("In effect"), span)
+		}
+		if alert.AreaName != "" {
+			fmt.Printf("%s: %s\n", tr("Area"), alert.AreaName)
+		}
+		if len(alert.Area) > 0 {
+			if inside, km := alert.covers(target.Coord); inside {
+				fmt.Printf("📍 %s\n", tr("This location is inside the warned area"))
+			} else {
+				fmt.Printf("📍 %s\n", fmt.Sprintf(tr("This location is outside the warned area, about %s from it"), formatDistance(km, result.Options.Units)))
+			}
+		}
+		if alert.Description != "" {
+			fmt.Printf("\n%s\n", strings.TrimSpace(alert.Description))
+		}
+	}
+
+	return nil
+}
+
+// Formats the span an alert covers, leaving out ends that aren't known
+func formatSpan(start, end time.Time, options displayOptions) string {
+	layout := "Mon Jan 2 " + options.clockFormat()
+
+	switch {
+	case !start.IsZero() && !end.IsZero():
+		return fmt.Sprintf(tr("%s until %s"), start.Format(layout), end.Format(layout))
+	case !start.IsZero():
+		return fmt.Sprintf(tr("from %s"), start.Format(layout))
+	case !end.IsZero():
+		return fmt.Sprintf(tr("until %s"), end.Format(layout))
+	}
+
+	return ""
+}
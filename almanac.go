@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Principal moon phases by where they fall in the cycle
+var moonQuarters = []struct {
+	Phase float64
+	Name  string
+}{
+	{0, "new moon"},
+	{0.25, "first quarter"},
+	{0.5, "full moon"},
+	{0.75, "last quarter"},
+}
+
+// The principal phase the moon passes on the day from start to end, empty
+// on the days between them
+func moonQuarter(start time.Time, end time.Time) string {
+	from, to := moonPhase(start), moonPhase(end)
+	if to < from {
+		// The cycle starts over at new moon during the day
+		return moonQuarters[0].Name
+	}
+
+	for _, quarter := range moonQuarters[1:] {
+		if from < quarter.Phase && to >= quarter.Phase {
+			return quarter.Name
+		}
+	}
+
+	return ""
+}
+
+// The zone of the place for dates away from today. Providers report the
+// offset of today, which is off by an hour across a change to or from
+// daylight saving time, so the named zone is preferred when it is known.
+func (w weatherData) zoneFor(options displayOptions) *time.Location {
+	if options.Zone == nil && w.Timezone != "" {
+		if zone, err := time.LoadLocation(w.Timezone); err == nil {
+			return zone
+		}
+	}
+
+	return w.Current.Time.Location()
+}
+
+// Implements `weather almanac`, a calendar of the month with sunrise,
+// sunset, daylight and the moon of every day at the place
+func runAlmanac(ctx context.Context, target location, month string, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	clock := options.clockFormat()
+	zone := weather.zoneFor(options)
+
+	first := time.Date(weather.Current.Time.Year(), weather.Current.Time.Month(), 1, 0, 0, 0, 0, zone)
+	if month != "" {
+		if first, err = time.ParseInLocation("2006-01", month, zone); err != nil {
+			return fmt.Errorf("invalid month %q, expected a year and month such as 2025-07", month)
+		}
+	}
+
+	place := firstNonEmpty(target.CompactName, target.Name, fmt.Sprintf("%.4f, %.4f", weather.Coord.Lat, weather.Coord.Lon))
+	fmt.Printf("\n%s, %s\n\n", place, first.Format("January 2006"))
+
+	rows := [][]string{translated("Day", "Sunrise", "Sunset", "Daylight", "Moon", "Phase")}
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		sunrise, sunset := "-", "-"
+		if rise, ok := sunPasses(day, weather.Coord, SUNRISE_ALTITUDE, true); ok {
+			sunrise = rise.Format(clock)
+		}
+		if set, ok := sunPasses(day, weather.Coord, SUNRISE_ALTITUDE, false); ok {
+			sunset = set.Format(clock)
+		}
+
+		noon := day.Add(12 * time.Hour)
+		phase := moonQuarter(day, day.AddDate(0, 0, 1))
+		if phase != "" {
+			phase = tr(phase)
+		}
+
+		rows = append(rows, []string{
+			day.Format("Mon 2"),
+			sunrise,
+			sunset,
+			formatLength(daylight(day, weather.Coord)),
+			fmt.Sprintf("%s %.0f%%", moonPhaseEmoji(noon, weather.Coord.Lat), math.Round(moonIllumination(noon)*100)),
+			phase,
+		})
+	}
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// Pressure levels of the winds aloft in hPa, with the altitude in feet of
+// each in the standard atmosphere, lowest first
+var aloftLevels = []struct {
+	Pressure int
+	Feet     int
+}{
+	{925, 2500},
+	{850, 5000},
+	{700, 10000},
+	{600, 14000},
+	{500, 18000},
+	{400, 24000},
+	{300, 30000},
+	{250, 34000},
+	{200, 39000},
+}
+
+// Hours ahead `weather aloft -in` reaches
+const MAX_ALOFT_HOURS = 72
+
+// Levels closer than this above the ground in feet are left out, as in the
+// winds aloft forecasts of the NWS
+const ALOFT_GROUND_CLEARANCE_FT = 1500
+
+// From this altitude in feet temperatures aloft are always below zero and
+// printed without a sign
+const ALOFT_UNSIGNED_FROM_FT = 24000
+
+// Light winds encoded as 9900, in knots
+const ALOFT_LIGHT_KNOTS = 5
+
+// Hourly winds and temperatures at the pressure levels from Open-Meteo, in
+// knots, degrees, °C and geopotential meters, keyed by variable and level
+type openMeteoAloft struct {
+	Elevation        float64               `json:"elevation"`
+	Timezone         string                `json:"timezone"`
+	UTCOffsetSeconds int                   `json:"utc_offset_seconds"`
+	Hourly           map[string][]*float64 `json:"hourly"`
+}
+
+// Fetches the winds aloft of the coming days
+func fetchAloft(ctx context.Context, at coordinate) (openMeteoAloft, error) {
+	status("[@] Fetching the winds aloft from Open-Meteo")
+
+	var variables []string
+	for _, level := range aloftLevels {
+		for _, name := range []string{"wind_speed", "wind_direction", "temperature", "geopotential_height"} {
+			variables = append(variables, fmt.Sprintf("%s_%dhPa", name, level.Pressure))
+		}
+	}
+
+	query := openMeteoQuery(at, METRIC)
+	query.Set("hourly", strings.Join(variables, ","))
+	query.Set("wind_speed_unit", "kn")
+	query.Set("forecast_days", fmt.Sprint(MAX_ALOFT_HOURS/24+1))
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return openMeteoAloft{}, fmt.Errorf("fetching the winds aloft from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoAloft
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return openMeteoAloft{}, fmt.Errorf("parsing the Open-Meteo winds aloft: %w", err)
+	}
+	if len(parsed.Hourly["time"]) == 0 {
+		return openMeteoAloft{}, errors.New("Open-Meteo has no winds aloft for this place")
+	}
+
+	return parsed, nil
+}
+
+// A variable of a pressure level at an hour, NaN when missing
+func (a openMeteoAloft) value(name string, pressure int, index int) float64 {
+	if value := valueAt(a.Hourly[fmt.Sprintf("%s_%dhPa", name, pressure)], index); value != nil {
+		return *value
+	}
+
+	return math.NaN()
+}
+
+// Encodes wind and temperature the way winds aloft forecasts do, as in
+// 2714-05: direction in tens of degrees, speed in knots and temperature in
+// °C. Speeds from 100 knots add 50 to the direction, light winds are 9900.
+func aloftCode(direction float64, knots float64, celsius float64, feet int) string {
+	var wind string
+	switch tens, speed := int(math.Round(direction/10)), int(math.Round(knots)); {
+	case speed < ALOFT_LIGHT_KNOTS:
+		wind = "9900"
+	case speed >= 100:
+		wind = fmt.Sprintf("%02d%02d", (tens+35)%36+51, min(speed, 199)-100)
+	default:
+		wind = fmt.Sprintf("%02d%02d", (tens+35)%36+1, speed)
+	}
+
+	if math.IsNaN(celsius) {
+		return wind
+	}
+
+	temperature := int(math.Round(celsius))
+	if feet >= ALOFT_UNSIGNED_FROM_FT {
+		return fmt.Sprintf("%s%02d", wind, -temperature)
+	}
+	if temperature > 0 {
+		return fmt.Sprintf("%s+%02d", wind, temperature)
+	}
+
+	return fmt.Sprintf("%s-%02d", wind, -temperature)
+}
+
+// Implements `weather aloft`, the wind and temperature at the standard
+// pressure levels above the location some hours ahead, as a winds aloft
+// briefing
+func runAloft(ctx context.Context, target location, hours int, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	aloft, err := fetchAloft(ctx, target.Coord)
+	if err != nil {
+		return err
+	}
+
+	// The forecast hour closest to the one asked for
+	zone := time.FixedZone(aloft.Timezone, aloft.UTCOffsetSeconds)
+	wanted := time.Now().Add(time.Duration(hours) * time.Hour)
+	index, best := -1, time.Duration(math.MaxInt64)
+	for position, unix := range aloft.Hourly["time"] {
+		if unix == nil {
+			continue
+		}
+		if gap := time.Unix(int64(*unix), 0).Sub(wanted).Abs(); gap < best {
+			index, best = position, gap
+		}
+	}
+	if index < 0 {
+		return errors.New("the winds aloft forecast has no hours")
+	}
+
+	valid := unixIn(int64(*aloft.Hourly["time"][index]), zone)
+	fmt.Printf("%s %s, %s %s\n", tr("Valid"), valid.Format("Mon Jan 2 "+options.clockFormat()), tr("ground at"), formatHeight(aloft.Elevation, options.Units))
+
+	rows := [][]string{translated("Level", "Altitude", "Direction", "Wind", "Temp", "Code")}
+	for _, level := range aloftLevels {
+		height := aloft.value("geopotential_height", level.Pressure, index)
+		speed := aloft.value("wind_speed", level.Pressure, index)
+		direction := aloft.value("wind_direction", level.Pressure, index)
+		celsius := aloft.value("temperature", level.Pressure, index)
+		if math.IsNaN(height) || math.IsNaN(speed) || math.IsNaN(direction) {
+			continue
+		}
+
+		// Levels under or just above the ground blow through the terrain
+		if (height-aloft.Elevation)*3.28084 < ALOFT_GROUND_CLEARANCE_FT {
+			continue
+		}
+
+		temperature := "-"
+		if !math.IsNaN(celsius) {
+			temperature = fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+		}
+
+		rows = append(rows, []string{
+			fmt.Sprintf("%d hPa", level.Pressure),
+			formatHeight(height, options.Units),
+			fmt.Sprintf("%03.0f° %s", direction, compassDirection(int64(direction))),
+			options.Wind.format(speed*METERS_PER_SECOND_PER_KNOT, 0),
+			temperature,
+			aloftCode(direction, speed, celsius, level.Feet),
+		})
+	}
+
+	if len(rows) == 1 {
+		return errors.New("no pressure level lies above the ground here")
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const OPEN_METEO_ARCHIVE_URL = "https://archive-api.open-meteo.com/v1/archive"
+
+// Days the archive trails behind today; more recent days come from the
+// forecast endpoint, which keeps its past months
+const ARCHIVE_DELAY_DAYS = 5
+
+// Variables requested for past days, the ones the archive keeps
+const OPEN_METEO_PAST_HOURLY = "temperature_2m,apparent_temperature,relative_humidity_2m,dew_point_2m,is_day,weather_code,cloud_cover,pressure_msl,wind_speed_10m,wind_direction_10m,wind_gusts_10m,precipitation"
+const OPEN_METEO_PAST_DAILY = "weather_code,temperature_2m_max,temperature_2m_min,sunrise,sunset,precipitation_sum,wind_speed_10m_max,wind_gusts_10m_max,wind_direction_10m_dominant"
+
+// Fetches the hours and days Open-Meteo recorded from one date to another,
+// both included, without a key. There are no current conditions, so only
+// Hourly and Daily are filled in.
+func fetchOpenMeteoDays(ctx context.Context, at coordinate, units unitSystem, from time.Time, to time.Time) (weatherData, error) {
+	status("[@] Fetching past weather from Open-Meteo")
+
+	query := openMeteoQuery(at, units)
+	query.Set("hourly", OPEN_METEO_PAST_HOURLY)
+	query.Set("daily", OPEN_METEO_PAST_DAILY)
+	query.Set("start_date", from.Format("2006-01-02"))
+	query.Set("end_date", to.Format("2006-01-02"))
+
+	endpoint := OPEN_METEO_ARCHIVE_URL
+	if to.After(time.Now().AddDate(0, 0, -ARCHIVE_DELAY_DAYS)) {
+		endpoint = OPEN_METEO_URL
+	}
+
+	body, err := fetch(ctx, endpoint+"?"+query.Encode())
+	if err != nil {
+		return weatherData{}, fmt.Errorf("fetching past weather from Open-Meteo: %w", err)
+	}
+
+	var parsedResponse openMeteoResponse
+	if err := json.Unmarshal(body, &parsedResponse); err != nil {
+		return weatherData{}, fmt.Errorf("parsing Open-Meteo past weather: %w", err)
+	}
+
+	if len(parsedResponse.Daily.Time) == 0 {
+		return weatherData{}, fmt.Errorf("Open-Meteo has no weather from %s to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	weather := parsedResponse.toWeatherData()
+	weather.Current = conditions{}
+
+	return weather, nil
+}
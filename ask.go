@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// What a question can be about and the words that give it away, checked in
+// order so "hot" wins over the general "temperature"
+var askTopics = []struct {
+	Topic string
+	Words []string
+}{
+	{"snow", []string{"snow", "snowing", "snowy", "snowfall"}},
+	{"rain", []string{"rain", "raining", "rainy", "umbrella", "wet", "shower", "showers", "drizzle", "pour", "pouring", "storm", "stormy"}},
+	{"hot", []string{"hot", "warm", "heat", "sweltering"}},
+	{"cold", []string{"cold", "freezing", "chilly", "frost", "frosty", "jacket", "coat"}},
+	{"wind", []string{"wind", "windy", "gust", "gusts", "gusty", "breezy"}},
+	{"sun", []string{"sun", "sunny", "clear", "cloud", "cloudy", "clouds", "overcast", "sunshine"}},
+	{"temperature", []string{"temperature", "temp", "degrees"}},
+}
+
+// Words that end a place name in a question
+var askStopWords = map[string]bool{
+	"in": true, "at": true, "for": true, "near": true, "on": true, "this": true, "next": true,
+	"be": true, "is": true, "will": true, "going": true, "to": true, "it": true, "and": true,
+	"or": true, "the": true, "during": true, "by": true, "around": true,
+}
+
+// The topic a word gives away, empty when it is none
+func askTopic(word string) string {
+	for _, topic := range askTopics {
+		for _, keyword := range topic.Words {
+			if word == keyword {
+				return topic.Topic
+			}
+		}
+	}
+
+	return ""
+}
+
+// A question taken apart into what it asks about, where and when
+type question struct {
+	Topic string // One of askTopics, empty for the weather in general
+	Place string // Empty for your own location
+	Day   string // Phrase understood by parseDay, empty for today
+	Span  string // Phrase naming several days, such as "next week"
+	Part  string // Name of one of dayParts or "Night", empty for the whole day
+	Now   bool
+
+	Weekend bool // The coming weekend, asked about as its first day
+}
+
+// Takes a question such as "will it rain in Kathmandu tomorrow evening?"
+// apart with a few heuristics rather than any grammar
+func parseQuestion(text string) question {
+	var parsed question
+
+	original := strings.FieldsFunc(text, func(char rune) bool {
+		return unicode.IsSpace(char) || strings.ContainsRune("?!", char)
+	})
+	words := make([]string, len(original))
+	for index, word := range original {
+		words[index] = strings.ToLower(strings.Trim(word, ".,;:'\""))
+	}
+
+	used := make([]bool, len(words))
+
+	// The longest run of words that names a day, such as "in 3 days"
+	for index := 0; index < len(words) && parsed.Day == ""; index++ {
+		for length := min(4, len(words)-index); length > 0; length-- {
+			phrase := strings.Join(words[index:index+length], " ")
+
+			// "sun" is about sunshine far more often than about Sunday
+			if length == 1 && askTopic(phrase) != "" {
+				continue
+			}
+
+			if _, err := parseDay(phrase, time.Now()); err == nil {
+				parsed.Day = phrase
+				for offset := index; offset < index+length; offset++ {
+					used[offset] = true
+				}
+				break
+			}
+		}
+	}
+
+	for index, word := range words {
+		if used[index] {
+			continue
+		}
+
+		switch word {
+		case "weekend", "week":
+			// "this weekend" asks about its first day, "next week" no day at all
+			phrase := []string{word}
+			used[index] = true
+			if index > 0 && !used[index-1] && (words[index-1] == "this" || words[index-1] == "next" || words[index-1] == "the" || words[index-1] == "coming") {
+				phrase = append([]string{words[index-1]}, phrase...)
+				used[index-1] = true
+			}
+
+			if word == "weekend" {
+				parsed.Weekend = true
+			} else if parsed.Span == "" {
+				parsed.Span = strings.Join(phrase, " ")
+			}
+			continue
+		case "now":
+			parsed.Now = true
+			used[index] = true
+			if index > 0 && words[index-1] == "right" {
+				used[index-1] = true
+			}
+			continue
+		case "tonight", "night", "overnight":
+			parsed.Part = "Night"
+			used[index] = true
+			continue
+		}
+
+		for _, part := range dayParts {
+			if word == strings.ToLower(part.Name) {
+				parsed.Part = part.Name
+				used[index] = true
+			}
+		}
+
+		if topic := askTopic(word); topic != "" {
+			if parsed.Topic == "" {
+				parsed.Topic = topic
+			}
+			used[index] = true
+		}
+	}
+
+	// "tonight" names both a day and a part of it
+	if parsed.Day == "tonight" {
+		parsed.Part = "Night"
+	}
+
+	// The place follows the last preposition that isn't part of the time
+	for index := len(words) - 1; index >= 0; index-- {
+		if used[index] || (words[index] != "in" && words[index] != "at" && words[index] != "for" && words[index] != "near") {
+			continue
+		}
+
+		var place []string
+		for next := index + 1; next < len(words) && !used[next] && !askStopWords[words[next]]; next++ {
+			place = append(place, strings.Trim(original[next], "'\""))
+		}
+
+		// Commas stay, as in "Paris, France", unless they end the place
+		if len(place) > 0 {
+			parsed.Place = strings.TrimRight(strings.Join(place, " "), ".,;:")
+			break
+		}
+	}
+
+	return parsed
+}
+
+// What the forecast says over the time a question asks about
+type askFacts struct {
+	Pop       float64
+	Amount    float64
+	Low       float64
+	High      float64
+	FeelsLow  float64
+	FeelsHigh float64
+	Wind      float64
+	Gust      float64
+	Clouds    int64 // Average
+	Snow      bool
+}
+
+// Facts over a span of hours
+func factsOf(moments []conditions) askFacts {
+	facts := askFacts{Low: moments[0].Temp, High: moments[0].Temp, FeelsLow: moments[0].FeelsLike, FeelsHigh: moments[0].FeelsLike}
+
+	var clouds int64
+	for _, moment := range moments {
+		facts.Pop = max(facts.Pop, moment.Pop)
+		facts.Amount += moment.Precipitation
+		facts.Low, facts.High = min(facts.Low, moment.Temp), max(facts.High, moment.Temp)
+		facts.FeelsLow, facts.FeelsHigh = min(facts.FeelsLow, moment.FeelsLike), max(facts.FeelsHigh, moment.FeelsLike)
+		facts.Wind, facts.Gust = max(facts.Wind, moment.WindSpeed), max(facts.Gust, moment.WindGust)
+		facts.Snow = facts.Snow || moment.Condition.Kind == SNOW || moment.Condition.Kind == SLEET
+		clouds += moment.Clouds
+	}
+	facts.Clouds = clouds / int64(len(moments))
+
+	// The current conditions carry no chance, what falls now is certain
+	if len(moments) == 1 && moments[0].Condition.Kind.precipitating() {
+		facts.Pop = 1
+	}
+
+	return facts
+}
+
+// Facts of a whole day when the hourly forecast doesn't reach it
+func dailyFacts(day dailyForecast) askFacts {
+	return askFacts{
+		Pop: day.Pop, Amount: day.Precipitation, Low: day.TempMin, High: day.TempMax,
+		FeelsLow: day.TempMin, FeelsHigh: day.TempMax, Wind: day.WindSpeed, Gust: day.WindGust,
+		Clouds: day.Clouds, Snow: day.Condition.Kind == SNOW || day.Condition.Kind == SLEET,
+	}
+}
+
+// The forecast hours of the day within the part a question names, from
+// now on. Night runs to midnight, as the hours after belong to the next day.
+func askedHours(weather weatherData, day time.Time, part string) []conditions {
+	from, to := 0, 24
+	for _, candidate := range dayParts {
+		if candidate.Name == part {
+			from, to = candidate.From, candidate.To
+		}
+	}
+	if part == "Night" {
+		from = 18
+	}
+
+	now := weather.Current.Time.Truncate(time.Hour)
+
+	var hours []conditions
+	for _, hour := range weather.Hourly {
+		if sameDate(hour.Time, day) && !hour.Time.Before(now) && hour.Time.Hour() >= from && hour.Time.Hour() < to {
+			hours = append(hours, hour)
+		}
+	}
+
+	return hours
+}
+
+// Answers a question in a sentence from the facts
+func (f askFacts) answer(topic string, place string, when string, options displayOptions) string {
+	temperature := func(value float64) string { return fmt.Sprintf("%.0f%s", value, options.Units.temperature()) }
+	windy := KILOMETERS_PER_HOUR.fromMetersPerSecond(options.Units.toMetersPerSecond(f.Wind)) >= WINDY_KMH ||
+		KILOMETERS_PER_HOUR.fromMetersPerSecond(options.Units.toMetersPerSecond(f.Gust)) >= STRONG_GUST_KMH
+
+	switch topic {
+	case "rain":
+		switch {
+		case f.Pop >= 0.6:
+			return fmt.Sprintf(tr("Yes, rain is likely in %s %s: %.0f%% chance"), place, when, f.Pop*100)
+		case f.Pop >= 0.3:
+			return fmt.Sprintf(tr("Maybe, there is a %.0f%% chance of rain in %s %s"), f.Pop*100, place, when)
+		default:
+			return fmt.Sprintf(tr("Probably not, only a %.0f%% chance of rain in %s %s"), f.Pop*100, place, when)
+		}
+	case "snow":
+		if f.Snow || (f.Pop >= RAIN_LIKELY && options.Units.toCelsius(f.Low) <= 1) {
+			return fmt.Sprintf(tr("Yes, snow is likely in %s %s"), place, when)
+		}
+		return fmt.Sprintf(tr("No snow expected in %s %s"), place, when)
+	case "hot":
+		if options.Units.toCelsius(f.FeelsHigh) >= 28 {
+			return fmt.Sprintf(tr("Yes, it gets hot in %s %s, feeling like up to %s"), place, when, temperature(f.FeelsHigh))
+		}
+		return fmt.Sprintf(tr("No, it stays at or below %s in %s %s"), temperature(f.High), place, when)
+	case "cold":
+		if options.Units.toCelsius(f.FeelsLow) <= 5 {
+			return fmt.Sprintf(tr("Yes, it gets cold in %s %s, feeling like down to %s"), place, when, temperature(f.FeelsLow))
+		}
+		return fmt.Sprintf(tr("No, it doesn't get colder than %s in %s %s"), temperature(f.Low), place, when)
+	case "wind":
+		if windy {
+			return fmt.Sprintf(tr("Yes, it gets windy in %s %s, up to %s"), place, when, options.wind(max(f.Wind, f.Gust), 1))
+		}
+		return fmt.Sprintf(tr("No, the wind stays below %s in %s %s"), options.wind(max(f.Wind, f.Gust), 1), place, when)
+	case "sun":
+		switch {
+		case f.Clouds <= 30 && f.Pop < 0.3:
+			return fmt.Sprintf(tr("Yes, mostly sunny in %s %s"), place, when)
+		case f.Clouds <= 70:
+			return fmt.Sprintf(tr("Partly, sun and clouds in %s %s"), place, when)
+		default:
+			return fmt.Sprintf(tr("No, mostly cloudy in %s %s"), place, when)
+		}
+	}
+
+	return fmt.Sprintf(tr("Between %s and %s in %s %s"), temperature(f.Low), temperature(f.High), place, when)
+}
+
+// Implements `weather ask`, answering a question about the weather in a
+// sentence with the numbers behind it
+func runAsk(ctx context.Context, text string, units string) error {
+	asked := parseQuestion(text)
+	if asked.Span != "" && asked.Day == "" && !asked.Weekend {
+		return fmt.Errorf("%q covers several days, name one such as tomorrow, friday or \"this weekend\"", asked.Span)
+	}
+
+	var args []string
+	if asked.Place != "" {
+		args = []string{asked.Place}
+	}
+
+	target, err := targetLocation(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+
+	day := weather.Current.Time
+	if asked.Weekend && asked.Day == "" {
+		day = weekendStart(weather.Current.Time)
+	}
+	if asked.Day != "" {
+		if day, err = parseDay(asked.Day, weather.Current.Time); err != nil {
+			return err
+		}
+	}
+
+	var facts askFacts
+	when := fmt.Sprintf(tr("on %s"), day.Format("Mon Jan 2"))
+	if asked.Part != "" {
+		when += " (" + tr(asked.Part) + ")"
+	}
+
+	if asked.Now {
+		facts = factsOf([]conditions{weather.Current})
+		when = tr("right now")
+	} else if hours := askedHours(weather, day, asked.Part); len(hours) > 0 {
+		facts = factsOf(hours)
+	} else {
+		found := false
+		for _, forecast := range weather.Daily {
+			if sameDate(forecast.Date, day) {
+				facts, found = dailyFacts(forecast), true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("the forecast of %s doesn't cover %s", result.Provider, day.Format("Mon Jan 2"))
+		}
+	}
+
+	place := firstNonEmpty(target.CompactName, target.Name, fmt.Sprintf("%.4f, %.4f", weather.Coord.Lat, weather.Coord.Lon))
+	fmt.Println(facts.answer(asked.Topic, place, when, options) + ".")
+
+	temperature := options.Units.temperature()
+	fmt.Printf("%s %.0f–%.0f%s · %s %.0f%% · %s · %s %s · %s %d%%\n",
+		tr("Temp"), facts.Low, facts.High, temperature, tr("rain chance"), facts.Pop*100,
+		options.Units.formatPrecipitation(facts.Amount), tr("wind"), options.wind(facts.Wind, 1),
+		tr("clouds"), facts.Clouds)
+
+	return nil
+}
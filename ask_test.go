@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Weekends are understood and spans of several days kept apart, so neither
+// is answered as today
+func TestParseQuestionSpans(t *testing.T) {
+	cases := []struct {
+		text string
+		want question
+	}{
+		{"will it be sunny in Monaco this weekend", question{Topic: "sun", Place: "Monaco", Weekend: true}},
+		{"rain on the weekend in Oslo?", question{Topic: "rain", Place: "Oslo", Weekend: true}},
+		{"will it rain in Paris next week", question{Topic: "rain", Place: "Paris", Span: "next week"}},
+		{"will it snow in a week", question{Topic: "snow", Day: "in a week"}},
+		{"will it rain in Kathmandu tomorrow evening?", question{Topic: "rain", Place: "Kathmandu", Day: "tomorrow", Part: "Evening"}},
+	}
+
+	for _, test := range cases {
+		if got := parseQuestion(test.text); got != test.want {
+			t.Errorf("parseQuestion(%q) = %+v, want %+v", test.text, got, test.want)
+		}
+	}
+}
+
+// The weekend starts on the coming Saturday, or today once it has begun
+func TestWeekendStart(t *testing.T) {
+	day := func(date int) time.Time { return time.Date(2026, time.October, date, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{day(14).Add(15 * time.Hour), day(17)}, // Wednesday
+		{day(17).Add(9 * time.Hour), day(17)},  // Saturday
+		{day(18).Add(20 * time.Hour), day(18)}, // Sunday
+		{day(19), day(24)},                     // Monday
+	}
+
+	for _, test := range cases {
+		if got := weekendStart(test.now); !got.Equal(test.want) {
+			t.Errorf("weekendStart(%s) = %s, want %s", test.now.Format("Mon Jan 2"), got.Format("Mon Jan 2"), test.want.Format("Mon Jan 2"))
+		}
+	}
+}
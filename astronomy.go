@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sun elevations in degrees `weather sun` lists the crossings of by default
+const DEFAULT_SUN_ELEVATIONS = "0,10,20,30"
+
+// A length of time such as "11h 14m", or "2m 13s" under an hour, for people
+// rather than for parsing like time.Duration's own format
+func formatLength(length time.Duration) string {
+	length = length.Abs().Round(time.Second)
+	if length >= time.Hour {
+		return fmt.Sprintf("%dh %dm", int(length.Hours()), int(length.Minutes())%60)
+	}
+
+	return fmt.Sprintf("%dm %ds", int(length.Minutes()), int(length.Seconds())%60)
+}
+
+// " (in 1h 42m)" for a moment still ahead, nothing once it has passed
+func countdown(moment time.Time, now time.Time) string {
+	if !moment.After(now) {
+		return ""
+	}
+
+	return " (" + fmt.Sprintf(tr("in %s"), formatLength(moment.Sub(now))) + ")"
+}
+
+// Moments of the sun `weather sun -until` counts down to, on the local
+// calendar day of the moment given
+var sunEvents = map[string]func(day time.Time, at coordinate) (time.Time, bool){
+	"dawn": func(day time.Time, at coordinate) (time.Time, bool) {
+		return sunPasses(day, at, twilights[0].Altitude, true)
+	},
+	"sunrise": func(day time.Time, at coordinate) (time.Time, bool) {
+		return sunPasses(day, at, SUNRISE_ALTITUDE, true)
+	},
+	"noon": func(day time.Time, at coordinate) (time.Time, bool) {
+		noon, _ := solarNoon(day, at)
+		return noon, true
+	},
+	"sunset": func(day time.Time, at coordinate) (time.Time, bool) {
+		return sunPasses(day, at, SUNRISE_ALTITUDE, false)
+	},
+	"dusk": func(day time.Time, at coordinate) (time.Time, bool) {
+		return sunPasses(day, at, twilights[0].Altitude, false)
+	},
+}
+
+// Names of sunEvents in the order they happen, for flags and completion
+func sunEventNames() []string {
+	return []string{"dawn", "sunrise", "noon", "sunset", "dusk"}
+}
+
+// The next time a sun event happens after now. Polar night and summer can
+// keep the sun from rising or setting for months, so a year is searched.
+func nextSunEvent(name string, now time.Time, at coordinate) (time.Time, bool) {
+	event := sunEvents[name]
+	for offset := 0; offset <= 366; offset++ {
+		if moment, ok := event(now.AddDate(0, 0, offset), at); ok && moment.After(now) {
+			return moment, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Elevations in degrees from a comma separated list such as "-6,0,15.5"
+func parseElevations(text string) ([]float64, error) {
+	var elevations []float64
+	for _, field := range strings.Split(text, ",") {
+		elevation, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || elevation < -90 || elevation > 90 {
+			return nil, fmt.Errorf("invalid elevation %q, expected degrees between -90 and 90 such as 0,10,30", field)
+		}
+		elevations = append(elevations, elevation)
+	}
+
+	return elevations, nil
+}
+
+// Prints the sun and moon of today in the full report. Sun times are
+// computed when the provider doesn't report them; the moon may not rise or
+// set at all on a day.
+func (w weatherData) printAstronomy(options displayOptions) {
+	timeFormat := options.timeFormat()
+	clock := options.clockFormat()
+	current := w.Current
+
+	fmt.Printf("\n🔭  %s: \n", tr("Astronomy"))
+
+	// Twilights begin with the darkest at dawn and end with it at dusk
+	var dawn, dusk []string
+	for index := len(twilights) - 1; index >= 0; index-- {
+		if begins, ok := sunPasses(current.Time, w.Coord, twilights[index].Altitude, true); ok {
+			dawn = append(dawn, tr(twilights[index].Name)+" "+begins.Format(clock))
+		}
+	}
+	for _, twilight := range twilights {
+		if ends, ok := sunPasses(current.Time, w.Coord, twilight.Altitude, false); ok {
+			dusk = append(dusk, tr(twilight.Name)+" "+ends.Format(clock))
+		}
+	}
+
+	if len(dawn) > 0 {
+		printField("Dawn", strings.Join(dawn, ", "))
+	}
+	if rise, set, ok := sunTimes(w); ok {
+		printField("Sunrise", rise.Format(timeFormat)+countdown(rise, current.Time))
+		noon, position := solarNoon(current.Time, w.Coord)
+		printField("Solar Noon", fmt.Sprintf(tr("%s, sun %.0f° high"), noon.Format(timeFormat), position.Altitude))
+		printField("Sunset", set.Format(timeFormat)+countdown(set, current.Time))
+	}
+	if len(dusk) > 0 {
+		printField("Dusk", strings.Join(dusk, ", "))
+	}
+
+	// Through the seasons the change matters more than the length itself
+	today, yesterday := daylight(current.Time, w.Coord), daylight(current.Time.AddDate(0, 0, -1), w.Coord)
+	change := "+" + formatLength(today-yesterday)
+	if today < yesterday {
+		change = "−" + formatLength(today-yesterday)
+	}
+	printField("Daylight", fmt.Sprintf(tr("%s, %s on yesterday"), formatLength(today), change))
+
+	spells := map[string][]string{}
+	for _, session := range lightSessions(current.Time, w.Coord) {
+		spells[session.Name] = append(spells[session.Name], session.From.Format(clock)+"–"+session.To.Format(clock))
+	}
+	if golden := spells["golden hour"]; len(golden) > 0 {
+		printField("Golden Hour", strings.Join(golden, ", "))
+	}
+	if blue := spells["blue hour"]; len(blue) > 0 {
+		printField("Blue Hour", strings.Join(blue, ", "))
+	}
+
+	if rise, ok := moonPasses(current.Time, w.Coord, true); ok {
+		printField("Moonrise", rise.Format(timeFormat))
+	}
+	if set, ok := moonPasses(current.Time, w.Coord, false); ok {
+		printField("Moonset", set.Format(timeFormat))
+	}
+	printField("Moon", fmt.Sprintf(tr("%s %s, %.0f%% lit"),
+		moonPhaseEmoji(current.Time, w.Coord.Lat), tr(moonPhaseName(current.Time)), moonIllumination(current.Time)*100))
+}
+
+// Implements `weather sun`, where the sun stands now and when it passes each
+// of the elevations today, for placing solar panels or planning shade. With
+// an event to wait for it prints only the time left, for scripts and prompts.
+func runSun(ctx context.Context, target location, elevations []float64, until string, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	clock := result.Options.clockFormat()
+	now := weather.Current.Time
+
+	if until != "" {
+		next, ok := nextSunEvent(until, now, weather.Coord)
+		if !ok {
+			return fmt.Errorf("no %s at %.4f, %.4f within a year", until, weather.Coord.Lat, weather.Coord.Lon)
+		}
+
+		fmt.Println(formatLength(next.Sub(now)))
+		return nil
+	}
+	position := sunAt(now, weather.Coord)
+
+	if position.Altitude >= 0 {
+		fmt.Printf(tr("Sun now: %.1f° up at azimuth %.0f° (%s)")+"\n", position.Altitude, position.Azimuth, compassDirection(int64(math.Round(position.Azimuth))))
+	} else {
+		fmt.Printf(tr("Sun now: %.1f° below the horizon at azimuth %.0f° (%s)")+"\n", -position.Altitude, position.Azimuth, compassDirection(int64(math.Round(position.Azimuth))))
+	}
+
+	noon, highest := solarNoon(now, weather.Coord)
+	fmt.Printf(tr("Highest at %s: %.1f° up at azimuth %.0f°")+"\n\n", noon.Format(clock), highest.Altitude, highest.Azimuth)
+
+	rows := [][]string{translated("Elevation", "Rising", "Azimuth", "Setting", "Azimuth")}
+	for _, elevation := range elevations {
+		row := []string{fmt.Sprintf("%g°", elevation), "-", "-", "-", "-"}
+		if rise, ok := sunPasses(now, weather.Coord, elevation, true); ok {
+			row[1], row[2] = rise.Format(clock), fmt.Sprintf("%.0f°", sunAt(rise, weather.Coord).Azimuth)
+		}
+		if set, ok := sunPasses(now, weather.Coord, elevation, false); ok {
+			row[3], row[4] = set.Format(clock), fmt.Sprintf("%.0f°", sunAt(set, weather.Coord).Azimuth)
+		}
+		rows = append(rows, row)
+	}
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats understood by batch mode
+var batchFormats = []string{"text", "json", "csv"}
+
+// One line of batch output
+type batchRecord struct {
+	Query       string  `json:"query"`
+	Name        string  `json:"name,omitempty"`
+	Country     string  `json:"country,omitempty"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Time        string  `json:"time,omitempty"`
+	Provider    string  `json:"provider,omitempty"`
+	Units       string  `json:"units"`
+	Temperature float64 `json:"temp"`
+	FeelsLike   float64 `json:"feels_like"`
+	Humidity    int64   `json:"humidity"`
+	Pressure    int64   `json:"pressure"`
+	WindSpeed   float64 `json:"wind_speed"`
+	WindDeg     int64   `json:"wind_deg"`
+	Condition   string  `json:"condition,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Reads one location per line, skipping blanks and # comments
+func readLocations(reader io.Reader) ([]string, error) {
+	var queries []string
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		queries = append(queries, line)
+	}
+
+	return queries, scanner.Err()
+}
+
+// Processes every location in a file (or stdin for "-") and writes one
+// result per line in the chosen format
+func runBatch(ctx context.Context, path string, format string, units string) error {
+	if !slices.Contains(batchFormats, format) {
+		return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(batchFormats, ", "))
+	}
+
+	input := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening batch file: %w", err)
+		}
+		defer file.Close()
+
+		input = file
+	}
+
+	queries, err := readLocations(input)
+	if err != nil {
+		return fmt.Errorf("reading batch locations: %w", err)
+	}
+
+	results := runPool("Processing batch", queries, func(query string) string { return query }, func(query string) (report, error) {
+		target, err := resolveLocation(ctx, query)
+		if err != nil {
+			return report{}, err
+		}
+
+		return fetchReport(ctx, target, units)
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	records := make([]batchRecord, len(results))
+	for index, result := range results {
+		records[index] = newBatchRecord(queries[index], result)
+	}
+
+	return writeBatch(os.Stdout, format, records)
+}
+
+func newBatchRecord(query string, result jobResult[report]) batchRecord {
+	if result.Err != nil {
+		return batchRecord{Query: query, Error: result.Err.Error()}
+	}
+
+	place := result.Value.Location
+	weather := result.Value.Weather
+	current := weather.Current
+
+	return batchRecord{
+		Query:       query,
+		Name:        place.CompactName,
+		Country:     place.Country,
+		Lat:         weather.Coord.Lat,
+		Lon:         weather.Coord.Lon,
+		Time:        current.Time.Format(time.RFC3339),
+		Provider:    result.Value.Provider,
+		Units:       string(result.Value.Options.Units),
+		Temperature: current.Temp,
+		FeelsLike:   current.FeelsLike,
+		Humidity:    current.Humidity,
+		Pressure:    current.Pressure,
+		WindSpeed:   current.WindSpeed,
+		WindDeg:     current.WindDeg,
+		Condition:   current.Condition.Description,
+	}
+}
+
+func writeBatch(out io.Writer, format string, records []batchRecord) error {
+	switch format {
+	case "json":
+		// JSON Lines: one object per location
+		encoder := json.NewEncoder(out)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+	case "csv":
+		writer := csv.NewWriter(out)
+		writer.Write([]string{"query", "name", "country", "lat", "lon", "time", "provider", "units", "temp", "feels_like", "humidity", "pressure", "wind_speed", "wind_deg", "condition", "error"})
+
+		for _, record := range records {
+			writer.Write([]string{
+				record.Query,
+				record.Name,
+				record.Country,
+				strconv.FormatFloat(record.Lat, 'f', 4, 64),
+				strconv.FormatFloat(record.Lon, 'f', 4, 64),
+				record.Time,
+				record.Provider,
+				record.Units,
+				strconv.FormatFloat(record.Temperature, 'f', 2, 64),
+				strconv.FormatFloat(record.FeelsLike, 'f', 2, 64),
+				strconv.FormatInt(record.Humidity, 10),
+				strconv.FormatInt(record.Pressure, 10),
+				strconv.FormatFloat(record.WindSpeed, 'f', 2, 64),
+				strconv.FormatInt(record.WindDeg, 10),
+				record.Condition,
+				record.Error,
+			})
+		}
+
+		writer.Flush()
+		return writer.Error()
+	default:
+		for _, record := range records {
+			if record.Error != "" {
+				fmt.Fprintf(out, "%s: error: %s\n", record.Query, record.Error)
+				continue
+			}
+
+			// The wind is shown in the unit of -wind, as in the other views
+			options, err := resolveDisplay(record.Units, record.Country)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "%s: %.1f%s, %s, %s %s %s, %s %d%%\n", record.Query, record.Temperature, options.Units.temperature(), record.Condition, tr("wind"), options.wind(record.WindSpeed, 1), compassDirection(record.WindDeg), tr("humidity"), record.Humidity)
+		}
+	}
+
+	return nil
+}
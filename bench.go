@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Outcome of one benchmark request
+type benchSample struct {
+	Duration     time.Duration
+	Completeness float64
+	Err          error
+}
+
+// Share of the fields renderers rely on that a provider actually filled in
+func completeness(weather weatherData) float64 {
+	current := weather.Current
+
+	checks := []bool{
+		current.Temp != 0,
+		current.FeelsLike != 0,
+		current.Humidity != 0,
+		current.Pressure != 0,
+		current.DewPoint != 0,
+		current.WindSpeed != 0,
+		current.WindGust != 0,
+		current.Clouds != 0,
+		current.UVI != 0,
+		current.Visibility != 0,
+		!current.Sunrise.IsZero() && !current.Sunset.IsZero(),
+		current.Condition.Kind != "",
+		weather.Timezone != "",
+		len(weather.Minutely) > 0,
+		len(weather.Hourly) > 0,
+		len(weather.Daily) > 0,
+	}
+
+	filled := 0
+	for _, check := range checks {
+		if check {
+			filled++
+		}
+	}
+
+	return float64(filled) / float64(len(checks))
+}
+
+// Implements `weather bench`, timing every configured provider for the same spot
+func runBench(ctx context.Context, target location, rounds int, units string) error {
+	if rounds < 1 {
+		return fmt.Errorf("-rounds must be at least 1")
+	}
+
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	// Requests go out one at a time so they don't slow each other down
+	samples := map[string][]benchSample{}
+	bar := newProgressBar("Benchmarking", len(activeProviders)*rounds)
+
+	previousQuiet := quiet
+	quiet = true
+
+	for _, name := range activeProviders {
+		for range rounds {
+			start := time.Now()
+			weather, err := weatherProviders[name](ctx, target.Coord, options.Units)
+
+			sample := benchSample{Duration: time.Since(start), Err: err}
+			if err == nil {
+				sample.Completeness = completeness(weather)
+			}
+
+			samples[name] = append(samples[name], sample)
+			bar.step()
+
+			if ctx.Err() != nil {
+				quiet = previousQuiet
+				bar.finish()
+				return ctx.Err()
+			}
+		}
+	}
+
+	quiet = previousQuiet
+	bar.finish()
+
+	fmt.Printf("\n"+tr("Provider benchmark at %.4f, %.4f (%d rounds)")+"\n\n", target.Coord.Lat, target.Coord.Lon, rounds)
+
+	rows := [][]string{translated("Provider", "Success", "Median", "Fastest", "Slowest", "Completeness")}
+	for _, name := range activeProviders {
+		var durations []time.Duration
+		var completenessSum float64
+
+		for _, sample := range samples[name] {
+			if sample.Err != nil {
+				logger.Debug("benchmark request failed", "provider", name, "error", sample.Err)
+				continue
+			}
+
+			durations = append(durations, sample.Duration)
+			completenessSum += sample.Completeness
+		}
+
+		successRate := fmt.Sprintf("%.0f%%", float64(len(durations))/float64(rounds)*100)
+
+		if len(durations) == 0 {
+			rows = append(rows, []string{name, successRate, "-", "-", "-", "-"})
+			continue
+		}
+
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+		rows = append(rows, []string{
+			name,
+			successRate,
+			durations[len(durations)/2].Round(time.Millisecond).String(),
+			durations[0].Round(time.Millisecond).String(),
+			durations[len(durations)-1].Round(time.Millisecond).String(),
+			fmt.Sprintf("%.0f%%", completenessSum/float64(len(durations))*100),
+		})
+	}
+
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Things worth knowing about the rest of today's hours, such as when rain
+// arrives or frost sets in, each at the first hour it happens
+func notableEvents(weather weatherData, options displayOptions) []string {
+	clock := options.clockFormat()
+	units := options.Units
+
+	var events []string
+	var rain, storm, frost bool
+	var gust *conditions
+	hours := hoursLeftToday(weather)
+
+	for index, hour := range hours {
+		at := hour.Time.Format(clock)
+
+		switch {
+		case !storm && hour.Condition.Kind == THUNDERSTORM:
+			events = append(events, fmt.Sprintf(tr("thunderstorms from %s"), at))
+			storm, rain = true, true
+		case !rain && (hour.Pop >= RAIN_LIKELY || hour.Condition.Kind.precipitating()):
+			events = append(events, fmt.Sprintf(tr("%s from %s (%.0f%%)"), tr(precipitationWord(hour.Condition.Kind)), at, hour.Pop*100))
+			rain = true
+		}
+
+		if !frost && units.toCelsius(hour.Temp) <= FROST_C {
+			events = append(events, fmt.Sprintf(tr("frost from %s"), at))
+			frost = true
+		}
+
+		if KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(hour.WindGust)) >= STRONG_GUST_KMH && (gust == nil || hour.WindGust > gust.WindGust) {
+			gust = &hours[index]
+		}
+	}
+
+	if gust != nil {
+		events = append(events, fmt.Sprintf(tr("gusts to %s at %s"), options.wind(gust.WindGust, 0), gust.Time.Format(clock)))
+	}
+
+	return events
+}
+
+// What falls from the sky, for the events
+func precipitationWord(kind conditionKind) string {
+	switch kind {
+	case SNOW:
+		return "snow"
+	case SLEET:
+		return "sleet"
+	}
+
+	return "rain"
+}
+
+// Sunrise and sunset of today, from the provider or else computed
+func sunTimes(weather weatherData) (time.Time, time.Time, bool) {
+	if !weather.Current.Sunrise.IsZero() && !weather.Current.Sunset.IsZero() {
+		return weather.Current.Sunrise, weather.Current.Sunset, true
+	}
+
+	rise, rose := sunPasses(weather.Current.Time, weather.Coord, SUNRISE_ALTITUDE, true)
+	set, sets := sunPasses(weather.Current.Time, weather.Coord, SUNRISE_ALTITUDE, false)
+
+	return rise, set, rose && sets
+}
+
+// Implements `weather brief`, a digest of today in a few short lines meant
+// for the morning or for a notification
+func runBrief(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	current := weather.Current
+	temperature := options.Units.temperature()
+	clock := options.clockFormat()
+
+	place := firstNonEmpty(target.CompactName, target.Name, fmt.Sprintf("%.4f, %.4f", weather.Coord.Lat, weather.Coord.Lon))
+	fmt.Printf("%s, %s\n", place, current.Time.Format("Mon Jan 2"))
+
+	fmt.Printf("%s: %s %.0f%s %s, %s %.0f%s, %s %s\n", tr("Now"),
+		current.Condition.emoji(), current.Temp, temperature, current.Condition.Description,
+		tr("feels"), current.FeelsLike, temperature, tr("wind"), options.wind(current.WindSpeed, 1))
+
+	if result.Yesterday != "" {
+		fmt.Printf("%s: %s\n", tr("Vs Yesterday"), result.Yesterday)
+	}
+
+	if len(weather.Daily) > 0 {
+		today := weather.Daily[0]
+		line := fmt.Sprintf("%s: %.0f–%.0f%s, %s %.0f%%", tr("Today"), today.TempMin, today.TempMax, temperature, tr("rain chance"), today.Pop*100)
+		if today.Precipitation > 0 {
+			line += ", " + fmt.Sprintf(tr("about %s"), options.Units.formatPrecipitation(today.Precipitation))
+		}
+		fmt.Println(line)
+	}
+
+	var ahead []string
+	if nowcast := weather.nowcast(options); nowcast != "" {
+		ahead = append(ahead, nowcast)
+	}
+	ahead = append(ahead, notableEvents(weather, options)...)
+	if len(ahead) > 0 {
+		fmt.Printf("%s: %s\n", tr("Ahead"), strings.Join(ahead, ", "))
+	}
+
+	if rise, set, ok := sunTimes(weather); ok {
+		fmt.Printf("%s: %s %s, %s %s\n", tr("Sun"), tr("rises"), rise.Format(clock), tr("sets"), set.Format(clock))
+	}
+
+	// Air quality is a bonus, the brief goes out without it
+	if airQuality, err := fetchAirQuality(ctx, weather.Coord); err != nil {
+		logger.Debug("air quality lookup failed", "error", err)
+	} else if aqi, found := airQuality[current.Time.Truncate(time.Hour).Unix()]; found {
+		fmt.Printf("%s: %s %.0f (%s)\n", tr("Air"), tr("AQI"), aqi, tr(aqiCategory(aqi)))
+	}
+
+	for _, alert := range weather.Alerts {
+		if !alert.End.IsZero() && alert.End.Before(current.Time) {
+			continue
+		}
+
+		line := "⚠️  " + alert.Event
+		if !alert.End.IsZero() {
+			line += " " + fmt.Sprintf(tr("until %s"), alert.End.Format("Mon "+clock))
+		}
+		fmt.Println(line)
+	}
+
+	return nil
+}
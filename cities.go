@@ -0,0 +1,345 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Larger cities of the world from GeoNames, compiled into the binary so most
+// searches need no network lookup
+//
+//go:embed cities.csv
+var citiesCSV string
+
+// File in the data directory holding the full list from `weather cities update`
+const CITIES_FILE = "cities.csv"
+
+// GeoNames export of every city with at least 15000 inhabitants
+const GEONAMES_CITIES_URL = "https://download.geonames.org/export/dump/cities15000.zip"
+
+// GeoNames names of the first level regions, keyed like "US.CA"
+const GEONAMES_REGIONS_URL = "https://download.geonames.org/export/dump/admin1CodesASCII.txt"
+
+// Consults the offline city list before the online geocoder. `search
+// -online` turns it off.
+var offlineCities = true
+
+// One city of the offline list
+type city struct {
+	Name       string
+	ASCIIName  string // Empty when the same as Name
+	Region     string
+	RegionCode string // Such as a US state code, which queries may use
+	Country    string
+	Coord      coordinate
+	Population int64
+}
+
+// The offline city list in use and where it came from
+type cityList struct {
+	Cities []city
+	Source string // Path of the downloaded list, empty for the built in one
+}
+
+// Reads a city list in the format of the embedded one
+func parseCities(data io.Reader) ([]city, error) {
+	reader := csv.NewReader(data)
+	reader.FieldsPerRecord = 8
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("city list is empty")
+	}
+
+	var parsed []city
+	for _, record := range records[1:] {
+		lat, latErr := strconv.ParseFloat(record[5], 64)
+		lon, lonErr := strconv.ParseFloat(record[6], 64)
+		population, populationErr := strconv.ParseInt(record[7], 10, 64)
+		if latErr != nil || lonErr != nil || populationErr != nil {
+			return nil, fmt.Errorf("bad entry for %s", record[0])
+		}
+
+		parsed = append(parsed, city{
+			Name:       record[0],
+			ASCIIName:  record[1],
+			Region:     record[2],
+			RegionCode: record[3],
+			Country:    record[4],
+			Coord:      coordinate{Lat: lat, Lon: lon},
+			Population: population,
+		})
+	}
+
+	return parsed, nil
+}
+
+// The downloaded list when there is a readable one, otherwise the embedded
+// list. Loaded on first use.
+var cities = sync.OnceValue(func() cityList {
+	if path, err := dataPath(CITIES_FILE); err == nil {
+		if file, err := os.Open(path); err == nil {
+			parsed, err := parseCities(bufio.NewReader(file))
+			file.Close()
+
+			if err == nil {
+				return cityList{Cities: parsed, Source: path}
+			}
+
+			logger.Debug("ignoring downloaded city list", "path", path, "error", err)
+		}
+	}
+
+	parsed, err := parseCities(strings.NewReader(citiesCSV))
+	if err != nil {
+		panic("parsing the embedded city list: " + err.Error())
+	}
+
+	return cityList{Cities: parsed}
+})
+
+// Reports whether a query part names the city's region
+func (c city) inRegion(text string) bool {
+	return strings.EqualFold(c.Region, text) || (c.RegionCode != "" && strings.EqualFold(c.RegionCode, text))
+}
+
+// Finds cities for queries like "Paris", "London, CA" or "Springfield, IL, US",
+// the most populous first. Nothing is found for anything else, so the query
+// goes to the online geocoder.
+func findCities(query string) []location {
+	parts := strings.Split(query, ",")
+	for index := range parts {
+		parts[index] = strings.TrimSpace(parts[index])
+	}
+
+	name, qualifiers := parts[0], parts[1:]
+	if name == "" || len(qualifiers) > 2 {
+		return nil
+	}
+
+	var found []city
+	for _, entry := range cities().Cities {
+		if !strings.EqualFold(entry.Name, name) && !strings.EqualFold(entry.ASCIIName, name) {
+			continue
+		}
+
+		switch len(qualifiers) {
+		case 1:
+			if !strings.EqualFold(entry.Country, qualifiers[0]) && !entry.inRegion(qualifiers[0]) {
+				continue
+			}
+		case 2:
+			if !entry.inRegion(qualifiers[0]) || !strings.EqualFold(entry.Country, qualifiers[1]) {
+				continue
+			}
+		}
+
+		found = append(found, entry)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Population > found[j].Population })
+
+	places := make([]location, len(found))
+	for index, entry := range found {
+		places[index] = entry.location()
+	}
+
+	return places
+}
+
+// The city of the offline list closest to a coordinate and its distance in
+// kilometers
+func nearestCity(coord coordinate) (city, float64, bool) {
+	var nearest city
+	best := math.Inf(1)
+
+	for _, entry := range cities().Cities {
+		if km := distanceKm(coord, entry.Coord); km < best {
+			nearest, best = entry, km
+		}
+	}
+
+	return nearest, best, !math.IsInf(best, 1)
+}
+
+// The city as a location, named like the online search names it
+func (c city) location() location {
+	fullName := c.Name
+	if c.Region != "" && c.Region != c.Name {
+		fullName += ", " + c.Region
+	}
+
+	return location{
+		Coord:       c.Coord,
+		Name:        c.Name,
+		FullName:    fullName + ", " + c.Country,
+		CompactName: c.Name + ", " + c.Country,
+		Country:     c.Country,
+		Population:  c.Population,
+	}
+}
+
+// Implements `weather cities`
+func runCities(ctx context.Context, args []string) error {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "status":
+		list := cities()
+		if list.Source == "" {
+			fmt.Printf("Using the built in list of %d cities, run weather cities update for the full one\n", len(list.Cities))
+		} else {
+			fmt.Printf("Using %d cities from %s\n", len(list.Cities), list.Source)
+		}
+	case "update":
+		return downloadCities(ctx)
+	case "remove":
+		path, err := dataPath(CITIES_FILE)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return errors.New("no downloaded city list to remove")
+			}
+			return err
+		}
+
+		status("[+] Removed " + path + ", the built in list is used again")
+	default:
+		return fmt.Errorf("unknown cities action %q, expected status, update or remove", action)
+	}
+
+	return nil
+}
+
+// Downloads the GeoNames cities and saves them in the format of the embedded
+// list
+func downloadCities(ctx context.Context) error {
+	path, err := dataPath(CITIES_FILE)
+	if err != nil {
+		return err
+	}
+
+	// The archive is a few megabytes
+	if requestTimeout == DEFAULT_TIMEOUT {
+		requestTimeout = DOWNLOAD_TIMEOUT
+	}
+
+	status("[@] Downloading region names from GeoNames")
+
+	regionsBody, err := fetch(ctx, GEONAMES_REGIONS_URL)
+	if err != nil {
+		return fmt.Errorf("downloading region names: %w", err)
+	}
+
+	// Lines look like "US.CA<tab>California<tab>California<tab>5332921"
+	regions := map[string]string{}
+	for _, line := range strings.Split(string(regionsBody), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) >= 2 {
+			regions[fields[0]] = fields[1]
+		}
+	}
+
+	status("[@] Downloading cities from GeoNames")
+
+	archiveBody, err := fetch(ctx, GEONAMES_CITIES_URL)
+	if err != nil {
+		return fmt.Errorf("downloading cities: %w", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(archiveBody), int64(len(archiveBody)))
+	if err != nil {
+		return fmt.Errorf("opening the city archive: %w", err)
+	}
+
+	if len(archive.File) == 0 {
+		return errors.New("the city archive is empty")
+	}
+
+	dump, err := archive.File[0].Open()
+	if err != nil {
+		return fmt.Errorf("opening the city archive: %w", err)
+	}
+	defer dump.Close()
+
+	var converted bytes.Buffer
+	writer := csv.NewWriter(&converted)
+	writer.Write(strings.Split(strings.SplitN(citiesCSV, "\n", 2)[0], ","))
+
+	// Columns of the GeoNames dump: id, name, ASCII name, alternate names,
+	// latitude, longitude, feature class and code, country, other countries,
+	// region code, three finer codes and the population, among others
+	count := 0
+	scanner := bufio.NewScanner(dump)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 15 {
+			continue
+		}
+
+		name, asciiName, country, regionCode := fields[1], fields[2], fields[8], fields[10]
+		if asciiName == name {
+			asciiName = ""
+		}
+
+		writer.Write([]string{name, asciiName, regions[country+"."+regionCode], regionCode, country, fields[4], fields[5], fields[14]})
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading the city archive: %w", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	// Refuse to replace the list with something that won't load
+	if count == 0 {
+		return errors.New("the city archive lists no cities")
+	}
+	if _, err := parseCities(bytes.NewReader(converted.Bytes())); err != nil {
+		return fmt.Errorf("the downloaded city list is unusable: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+
+	// Write beside the old file and rename, so a crash never leaves half a file
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, converted.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", CITIES_FILE, err)
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		return err
+	}
+
+	status(fmt.Sprintf("[+] Saved %d cities to %s", count, path))
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// The heat index only means something from this temperature and humidity
+const (
+	HEAT_INDEX_MIN_F        = 80.0
+	HEAT_INDEX_MIN_HUMIDITY = 40
+)
+
+// Wind chill only applies at or below this temperature and above this wind
+const (
+	WIND_CHILL_MAX_C   = 10.0
+	WIND_CHILL_MIN_KMH = 4.8
+)
+
+// Upper dew points in °C of the comfort categories below oppressive
+const (
+	DRY_DEW_POINT_C         = 10.0
+	COMFORTABLE_DEW_POINT_C = 16.0
+	STICKY_DEW_POINT_C      = 21.0
+)
+
+// Whether reports include the comfort assessment, off with -no-comfort
+var showComfort = true
+
+// A value computed from the conditions along with what it means
+type comfortReading struct {
+	Value float64 // In the units system of the conditions
+	Note  string
+}
+
+// Heat index in °F from the NWS Rothfusz regression, with its adjustments
+// for very dry and very humid air
+func heatIndexF(tempF float64, humidity float64) float64 {
+	// The simple formula is good enough below 80°F
+	simple := 0.5 * (tempF + 61 + (tempF-68)*1.2 + humidity*0.094)
+	if (simple+tempF)/2 < HEAT_INDEX_MIN_F {
+		return simple
+	}
+
+	index := -42.379 + 2.04901523*tempF + 10.14333127*humidity - 0.22475541*tempF*humidity -
+		0.00683783*tempF*tempF - 0.05481717*humidity*humidity + 0.00122874*tempF*tempF*humidity +
+		0.00085282*tempF*humidity*humidity - 0.00000199*tempF*tempF*humidity*humidity
+
+	switch {
+	case humidity < 13 && tempF >= 80 && tempF <= 112:
+		index -= (13 - humidity) / 4 * math.Sqrt((17-math.Abs(tempF-95))/17)
+	case humidity > 85 && tempF >= 80 && tempF <= 87:
+		index += (humidity - 85) / 10 * (87 - tempF) / 5
+	}
+
+	return index
+}
+
+// Wind chill in °C from the formula shared by the NWS and Environment Canada
+func windChillC(tempC float64, windKmh float64) float64 {
+	factor := math.Pow(windKmh, 0.16)
+
+	return 13.12 + 0.6215*tempC - 11.37*factor + 0.3965*tempC*factor
+}
+
+// The heat index when it is hot and humid enough to matter, with the NWS
+// caution level
+func (c conditions) heatIndex(units unitSystem) (comfortReading, bool) {
+	tempF := IMPERIAL.fromCelsius(units.toCelsius(c.Temp))
+	if tempF < HEAT_INDEX_MIN_F || c.Humidity < HEAT_INDEX_MIN_HUMIDITY {
+		return comfortReading{}, false
+	}
+
+	index := heatIndexF(tempF, float64(c.Humidity))
+
+	var note string
+	switch {
+	case index >= 125:
+		note = "extreme danger, heat stroke is likely"
+	case index >= 103:
+		note = "danger, heat exhaustion is likely with activity"
+	case index >= 90:
+		note = "extreme caution, heat exhaustion is possible"
+	default:
+		note = "caution, tiring with prolonged activity"
+	}
+
+	return comfortReading{Value: units.fromCelsius(IMPERIAL.toCelsius(index)), Note: note}, true
+}
+
+// The wind chill when it is cold and windy enough to matter, with the
+// Environment Canada frostbite risk
+func (c conditions) windChill(units unitSystem) (comfortReading, bool) {
+	tempC := units.toCelsius(c.Temp)
+	windKmh := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(c.WindSpeed))
+	if tempC > WIND_CHILL_MAX_C || windKmh <= WIND_CHILL_MIN_KMH {
+		return comfortReading{}, false
+	}
+
+	chill := windChillC(tempC, windKmh)
+
+	var note string
+	switch {
+	case chill <= -55:
+		note = "extreme risk, exposed skin freezes in under 2 minutes"
+	case chill <= -48:
+		note = "severe risk, exposed skin freezes in 2 to 5 minutes"
+	case chill <= -40:
+		note = "very high risk, exposed skin freezes in 5 to 10 minutes"
+	case chill <= -28:
+		note = "high risk, exposed skin freezes in 10 to 30 minutes"
+	case chill <= -10:
+		note = "moderate risk, hypothermia with long exposure"
+	default:
+		note = "low risk, uncomfortable"
+	}
+
+	return comfortReading{Value: units.fromCelsius(chill), Note: note}, true
+}
+
+// How humid the air feels by its dew point, which unlike relative humidity
+// doesn't change with the temperature
+func (c conditions) dewPointComfort(units unitSystem) string {
+	switch dewPoint := units.toCelsius(c.DewPoint); {
+	case dewPoint < DRY_DEW_POINT_C:
+		return "dry"
+	case dewPoint < COMFORTABLE_DEW_POINT_C:
+		return "comfortable"
+	case dewPoint < STICKY_DEW_POINT_C:
+		return "sticky"
+	default:
+		return "oppressive"
+	}
+}
+
+// A short assessment of how the temperature and humidity together feel and
+// what they do indoors, such as air dry enough to bother sinuses
+func (c conditions) humidityComfort(units unitSystem) string {
+	tempC := units.toCelsius(c.Temp)
+	dewPoint := units.toCelsius(c.DewPoint)
+
+	switch {
+	case tempC >= 24 && c.Humidity >= 70:
+		return "muggy, sweat barely evaporates"
+	case c.Humidity >= 80:
+		return "damp, mold grows easily indoors, air rooms out"
+	case c.Humidity < 25:
+		return "very dry, hard on sinuses, skin and eyes"
+	case tempC < 5 && dewPoint < -5:
+		// Warming such air to room temperature leaves it at 20-30% humidity
+		return "cold dry air, heated rooms get too dry for sinuses"
+	case c.Humidity < 35:
+		return "dry, a humidifier helps indoors"
+	case tempC >= 18 && tempC <= 26 && c.Humidity >= 40 && c.Humidity <= 60:
+		return "ideal"
+	}
+
+	return "fine"
+}
+
+// Formats a computed temperature with its translated note
+func (r comfortReading) temperature(units unitSystem) string {
+	return fmt.Sprintf("%.2f%s (%s)", r.Value, units.temperature(), tr(r.Note))
+}
@@ -0,0 +1,1054 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runs a command with its positional arguments once flags are parsed
+type commandRunner func(ctx context.Context, args []string, units string) error
+
+// A subcommand such as `weather now`
+type command struct {
+	Name    string
+	Args    string // Positional arguments shown in the usage line
+	Summary string
+
+	// Words offered when completing positional arguments
+	Completions []string
+
+	// Left out of the usage, for commands meant to be run by scripts
+	Hidden bool
+
+	// Registers the command's own flags and returns what runs it
+	Setup func(flags *flag.FlagSet) commandRunner
+}
+
+// Every subcommand in the order they are listed in the usage
+var commands = []command{
+	{
+		Name:    "now",
+		Args:    "[location ...]",
+		Summary: "Current weather for each location, or for your own when none is given",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			consensus := flags.Bool("consensus", false, "Query every provider in -providers and show the averaged forecast with its spread")
+
+			return func(ctx context.Context, args []string, units string) error {
+				return runNow(ctx, args, *consensus, units)
+			}
+		},
+	},
+	{
+		Name:    "last",
+		Summary: "Current weather for the location used most recently",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				return runNow(ctx, []string{LAST_LOCATION}, false, units)
+			}
+		},
+	},
+	{
+		Name:    "forecast",
+		Args:    "[location]",
+		Summary: "Daily forecast, optionally with the next hours",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			days := flags.Int("days", 5, "Number of days to show")
+			hours := flags.Int("hours", 0, "Also show this many hours of the hourly forecast")
+			when := flags.String("when", "", "Only show one day, such as tomorrow, friday or \"in 3 days\"")
+
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				if *when != "" {
+					return runForecastDay(ctx, target, *when, units)
+				}
+
+				return runForecast(ctx, target, *days, *hours, units)
+			}
+		},
+	},
+	{
+		Name:    "search",
+		Args:    "query",
+		Summary: "List places matching a name",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			pick := flags.Bool("pick", false, "Choose one of the matches and show its weather")
+			online := flags.Bool("online", false, "Ask the online geocoder even when the offline city list has matches")
+			country := flags.String("country", "", "Only list places in this country, a two letter code such as NP")
+			limit := flags.Int("limit", 0, "List at most this many places (0 for all)")
+			order := flags.String("sort", RELEVANCE_ORDER, "Order of the matches: "+strings.Join(searchOrders, ", ")+", where distance is from your own location")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if len(args) == 0 {
+					return errors.New("search needs a place name, e.g. weather search paris")
+				}
+				offlineCities = !*online
+
+				return runSearch(ctx, strings.Join(args, " "), *pick, *country, *order, *limit, units)
+			}
+		},
+	},
+	{
+		Name:    "alerts",
+		Args:    "[location]",
+		Summary: "Active official weather warnings",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runAlerts(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "rain",
+		Args:    "[location]",
+		Summary: "Whether it will rain today in one sentence, exiting with 2 when it should stay dry",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runRain(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "wear",
+		Args:    "[location]",
+		Summary: "What to wear for how cold, windy and wet the next hours feel",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			offset := flags.String("offset", "", "Degrees you run warm (positive) or cold (negative) in the units shown, overriding wear_offset")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *offset != "" {
+					var err error
+					if wearOffset, err = strconv.ParseFloat(*offset, 64); err != nil {
+						return fmt.Errorf("invalid -offset %q, expected a number of degrees such as -2", *offset)
+					}
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runWear(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "run",
+		Args:    "[location]",
+		Summary: "Score today's hours for running or cycling and suggest the best training windows",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			window := flags.String("window", DEFAULT_ACTIVITY_WINDOW, "Hours of the day to consider, such as 06:00-21:00")
+			sport := flags.String("sport", "running", "Sport to score for: "+strings.Join(activityNames(), ", "))
+
+			return func(ctx context.Context, args []string, units string) error {
+				span, err := parseClockSpan(*window)
+				if err != nil {
+					return err
+				}
+
+				chosen, found := findActivity(*sport)
+				if !found {
+					return fmt.Errorf("unknown sport %q, expected one of %s", *sport, strings.Join(activityNames(), ", "))
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runActivity(ctx, target, chosen, span, units)
+			}
+		},
+	},
+	{
+		Name:    "stars",
+		Args:    "[location]",
+		Summary: "Score the coming night for stargazing from clouds, moonlight and humidity",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runStars(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "fishing",
+		Args:    "[location]",
+		Summary: "Best fishing times of the coming days from the solunar periods, rated by moon, pressure and wind",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runFishing(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "photo",
+		Args:    "[location]",
+		Summary: "Blue and golden hours of today and tomorrow with the clouds forecast for each",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runPhoto(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "snow",
+		Args:    "[location]",
+		Summary: "Fresh snow, snow depth, freezing level and snow line for skiers, with the summit temperature",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			summit := flags.Float64("summit", 0, "Summit elevation to forecast too, in meters or feet with imperial units")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *summit < 0 {
+					return errors.New("-summit can't be negative")
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runSnow(ctx, target, *summit, units)
+			}
+		},
+	},
+	{
+		Name:    "sun",
+		Args:    "[location]",
+		Summary: "Where the sun stands now and when it passes chosen elevations today, for solar panels and shade",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			elevations := flags.String("elevations", DEFAULT_SUN_ELEVATIONS, "Comma separated sun elevations in degrees to list the crossings of")
+			until := flags.String("until", "", "Print only the time left until the next "+strings.Join(sunEventNames(), ", "))
+
+			return func(ctx context.Context, args []string, units string) error {
+				parsed, err := parseElevations(*elevations)
+				if err != nil {
+					return err
+				}
+
+				if _, known := sunEvents[*until]; *until != "" && !known {
+					return fmt.Errorf("unknown sun event %q, expected one of %s", *until, strings.Join(sunEventNames(), ", "))
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runSun(ctx, target, parsed, *until, units)
+			}
+		},
+	},
+	{
+		Name:    "trip",
+		Args:    "[location]",
+		Summary: "Day by day outlook for a trip, from the forecast and past years' normals beyond it",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			from := flags.String("from", "today", "First day of the trip, such as 2025-09-10 or friday")
+			to := flags.String("to", "", "Last day of the trip, the first day when left out")
+
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runTrip(ctx, target, *from, *to, units)
+			}
+		},
+	},
+	{
+		Name:    "almanac",
+		Args:    "[location]",
+		Summary: "A month of sunrise, sunset, daylight and moon phases at the place",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			month := flags.String("month", "", "Month to show, such as 2025-07, instead of the current one")
+
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runAlmanac(ctx, target, *month, units)
+			}
+		},
+	},
+	{
+		Name:    "laundry",
+		Args:    "[location]",
+		Summary: "Whether laundry will dry outside over the next hours",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runLaundry(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "commute",
+		Args:    "[location]",
+		Summary: "Suggest the driest, least windy departure for a commute",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			duration := flags.String("duration", "", "How long the trip takes, such as 30m or 1h15m (default "+DEFAULT_COMMUTE_DURATION+" or commute_duration)")
+			between := flags.String("between", "", "Hours to leave between, such as 07:00-10:00 (default "+DEFAULT_COMMUTE_BETWEEN+" or commute_between)")
+
+			return func(ctx context.Context, args []string, units string) error {
+				length, err := time.ParseDuration(firstNonEmpty(*duration, commuteDuration))
+				if err != nil || length < time.Minute {
+					return fmt.Errorf("invalid commute duration %q, expected e.g. 30m or 1h15m", firstNonEmpty(*duration, commuteDuration))
+				}
+
+				span, err := parseClockSpan(firstNonEmpty(*between, commuteBetween))
+				if err != nil {
+					return err
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runCommute(ctx, target, length, span, units)
+			}
+		},
+	},
+	{
+		Name:    "road",
+		Args:    "[location]",
+		Summary: "Freezing rain, black ice, snow, crosswinds and poor visibility for drivers, hour by hour",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runRoad(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "fog",
+		Args:    "[location]",
+		Summary: "Fog spells of the coming day from the visibility and dew point, with desktop notifications while watching",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			watch := flags.Bool("watch", false, "Keep checking and send a desktop notification of each new fog spell until interrupted")
+
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runFog(ctx, target, *watch, units)
+			}
+		},
+	},
+	{
+		Name:    "agri",
+		Args:    "[location]",
+		Summary: "Growing degree days since the start of the season, and the soil temperature and moisture for planting",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			base := flags.String("base", "", fmt.Sprintf("Base temperature in the units shown, overriding gdd_base (default %.0f°C or %.0f°F)", DEFAULT_GDD_BASE_C, IMPERIAL.fromCelsius(DEFAULT_GDD_BASE_C)))
+			since := flags.String("since", "", "First day counted, such as 2025-04-15 (default January 1, or July 1 south of the equator)")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *base != "" {
+					var err error
+					if gddBase, err = strconv.ParseFloat(*base, 64); err != nil {
+						return fmt.Errorf("invalid -base %q, expected a number of degrees such as 10", *base)
+					}
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runAgri(ctx, target, *since, units)
+			}
+		},
+	},
+	{
+		Name:    "irrigate",
+		Args:    "[location]",
+		Summary: "Whether the week ahead needs watering from evapotranspiration and rain, exiting with 2 when it doesn't",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			coefficient := flags.Float64("kc", DEFAULT_CROP_COEFFICIENT, "Crop coefficient, the share of the reference evapotranspiration the plants use")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *coefficient <= 0 || *coefficient > 2 {
+					return errors.New("-kc must be above 0 and at most 2")
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runIrrigate(ctx, target, *coefficient, units)
+			}
+		},
+	},
+	{
+		Name:    "degreedays",
+		Args:    "[location]",
+		Summary: "Heating and cooling degree days of the past days and the forecast, for energy use and sizing HVAC",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			base := flags.String("base", "", fmt.Sprintf("Base temperature in the units shown, overriding degree_day_base (default %.0f°C or %.0f°F)", DEFAULT_DEGREE_DAY_BASE_C, DEFAULT_DEGREE_DAY_BASE_F))
+			days := flags.Int("days", DEFAULT_DEGREE_DAY_PAST_DAYS, "Past days counted before the forecast")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *base != "" {
+					var err error
+					if degreeDayBase, err = strconv.ParseFloat(*base, 64); err != nil {
+						return fmt.Errorf("invalid -base %q, expected a number of degrees such as 18", *base)
+					}
+				}
+				if *days < 0 || *days > MAX_DEGREE_DAY_PAST_DAYS {
+					return fmt.Errorf("-days must be between 0 and %d", MAX_DEGREE_DAY_PAST_DAYS)
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runDegreeDays(ctx, target, *days, units)
+			}
+		},
+	},
+	{
+		Name:    "weekend",
+		Args:    "[location]",
+		Summary: "Saturday and Sunday at a glance, by day and by part of the day",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runWeekend(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "ask",
+		Args:    "question",
+		Summary: "Answer a question such as \"will it rain in Kathmandu tomorrow evening?\"",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				if len(args) == 0 {
+					return errors.New("ask needs a question, e.g. weather ask \"will it rain tomorrow?\"")
+				}
+
+				return runAsk(ctx, strings.Join(args, " "), units)
+			}
+		},
+	},
+	{
+		Name:    "brief",
+		Args:    "[location]",
+		Summary: "A few lines on today for the morning or a notification: now, high and low, what's ahead, sun, air and alerts",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runBrief(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "map",
+		Args:    "[location]",
+		Summary: "Map of the current temperatures at the larger cities around the location",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			radius := flags.Float64("radius", DEFAULT_MAP_RADIUS, "Distance to the east and west edges, in km or miles with imperial units")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *radius <= 0 {
+					return errors.New("-radius must be positive")
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runMap(ctx, target, *radius, units)
+			}
+		},
+	},
+	{
+		Name:    "marine",
+		Args:    "[location]",
+		Summary: "Waves, swell, sea temperature and tides off the location for sailors and surfers",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runMarine(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "tides",
+		Args:    "[location]",
+		Summary: "Today's high and low tides from the nearest NOAA station in the US, else the modeled sea level",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runTides(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "radar",
+		Args:    "[location]",
+		Summary: "Precipitation radar around the location drawn in terminals that show images, optionally animated",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			zoom := flags.Int("zoom", DEFAULT_RADAR_ZOOM, fmt.Sprintf("Map zoom from 1 (continents) to %d (cities)", MAX_RADAR_ZOOM))
+			protocol := flags.String("protocol", AUTO_PROTOCOL, "Terminal image protocol: "+strings.Join(graphicsProtocols, ", "))
+			animate := flags.Bool("animate", false, "Loop the radar of the past hour until interrupted")
+			save := flags.String("save", "", "Save the latest radar as a PNG file instead of drawing it")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *zoom < 1 || *zoom > MAX_RADAR_ZOOM {
+					return fmt.Errorf("invalid -zoom %d, expected 1 to %d", *zoom, MAX_RADAR_ZOOM)
+				}
+				if !slices.Contains(graphicsProtocols, *protocol) {
+					return fmt.Errorf("unknown image protocol %q, expected one of %s", *protocol, strings.Join(graphicsProtocols, ", "))
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runRadar(ctx, target, *zoom, *protocol, *animate, *save)
+			}
+		},
+	},
+	{
+		Name:    "satellite",
+		Args:    "[location]",
+		Summary: "Latest infrared or visible satellite picture around the location drawn in terminals that show images",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			band := flags.String("band", AUTO_BAND, "Satellite band: "+strings.Join(satelliteBands, ", "))
+			zoom := flags.Int("zoom", DEFAULT_SATELLITE_ZOOM, "Map zoom from 1 (continents) to 6 (infrared) or 9 (visible)")
+			protocol := flags.String("protocol", AUTO_PROTOCOL, "Terminal image protocol: "+strings.Join(graphicsProtocols, ", "))
+			save := flags.String("save", "", "Save the picture as a PNG file instead of drawing it")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if !slices.Contains(satelliteBands, *band) {
+					return fmt.Errorf("unknown satellite band %q, expected one of %s", *band, strings.Join(satelliteBands, ", "))
+				}
+				if *zoom < 1 {
+					return fmt.Errorf("invalid -zoom %d, expected 1 or more", *zoom)
+				}
+				if !slices.Contains(graphicsProtocols, *protocol) {
+					return fmt.Errorf("unknown image protocol %q, expected one of %s", *protocol, strings.Join(graphicsProtocols, ", "))
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runSatellite(ctx, target, *band, *zoom, *protocol, *save)
+			}
+		},
+	},
+	{
+		Name:    "storms",
+		Args:    "[location]",
+		Summary: "Active hurricanes and tropical storms by distance, warning when the location is in a forecast cone",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runStorms(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "fire",
+		Args:    "[location]",
+		Summary: "Fire danger now and on the coming days, warning of critical fire weather",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runFire(ctx, target, units)
+			}
+		},
+	},
+	{
+		Name:    "hazards",
+		Args:    "[location]",
+		Summary: "Earthquakes, wildfires, floods and volcanic eruptions of the past days near the location",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			radius := flags.Float64("radius", DEFAULT_HAZARD_RADIUS, "Only events within this distance, in km or miles with imperial units")
+			days := flags.Int("days", DEFAULT_HAZARD_DAYS, "Number of past days to list events for")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *radius <= 0 || *days < 1 {
+					return errors.New("-radius and -days must be positive")
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runHazards(ctx, target, *radius, *days, units)
+			}
+		},
+	},
+	{
+		Name:    "lightning",
+		Args:    "[location]",
+		Summary: "Lightning strikes near the location from the Blitzortung network, or watch and ring when they get close",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			radius := flags.Float64("radius", DEFAULT_LIGHTNING_RADIUS, "Only strikes within this distance, in km or miles with imperial units")
+			listen := flags.Duration("listen", DEFAULT_LIGHTNING_LISTEN, "How long to listen for strikes, the feed only carries new ones")
+			watch := flags.Bool("watch", false, "Keep listening and print strikes as they happen until interrupted")
+			alert := flags.Float64("alert", 0, "While watching, ring the terminal bell for strikes within this distance")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *radius <= 0 || *alert < 0 {
+					return errors.New("-radius must be positive and -alert can't be negative")
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runLightning(ctx, target, *radius, *alert, *listen, *watch, units)
+			}
+		},
+	},
+	{
+		Name:    "compare",
+		Args:    "location location [location ...]",
+		Summary: "Current conditions of several places side by side",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return runCompare
+		},
+	},
+	{
+		Name:    "batch",
+		Args:    "file",
+		Summary: "Weather for one location per line of a file (- for stdin)",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			format := flags.String("format", "text", "Output format: "+strings.Join(batchFormats, ", "))
+
+			return func(ctx context.Context, args []string, units string) error {
+				if len(args) != 1 {
+					return errors.New("batch needs a single file, or - to read locations from stdin")
+				}
+
+				return runBatch(ctx, args[0], *format, units)
+			}
+		},
+	},
+	{
+		Name:    "history",
+		Args:    "[location]",
+		Summary: "Browse stored observations, kept as JSON lines rather than SQLite, or what the weather was at a place on a past date",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			limit := flags.Int("limit", 20, "Number of most recent observations to show")
+			place := flags.String("location", "", "Only show observations whose location contains this text")
+			date := flags.String("date", "", "Show the weather of a past day, such as 2023-08-14 or yesterday, from the Open-Meteo archive")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *date == "" {
+					return runHistory(*limit, *place, units)
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runHistoryDate(ctx, target, *date, units)
+			}
+		},
+	},
+	{
+		Name:    "verify",
+		Summary: "How accurate past forecasts were against what was observed",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			days := flags.Int("days", 30, "Only verify forecasts for the last n days")
+			place := flags.String("location", "", "Only verify locations containing this text")
+
+			return func(ctx context.Context, args []string, units string) error {
+				return runVerify(*days, *place, units)
+			}
+		},
+	},
+	{
+		Name:    "aloft",
+		Args:    "[location]",
+		Summary: "Winds and temperatures aloft at the standard pressure levels, as a briefing for pilots and balloonists",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			hours := flags.Int("in", 0, fmt.Sprintf("Hours ahead to brief, up to %d", MAX_ALOFT_HOURS))
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *hours < 0 || *hours > MAX_ALOFT_HOURS {
+					return fmt.Errorf("-in must be between 0 and %d hours", MAX_ALOFT_HOURS)
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runAloft(ctx, target, *hours, units)
+			}
+		},
+	},
+	{
+		Name:    "drone",
+		Args:    "[location]",
+		Summary: "GO, CAUTION or NO-GO for flying a drone, with the reasons, exiting with 2 for no-go and 3 for caution",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			maxWind := flags.Float64("max-wind", DEFAULT_DRONE_MAX_WIND, "Wind the drone can fly in, in km/h or mph with imperial units")
+			hours := flags.Int("hours", DEFAULT_DRONE_HOURS, fmt.Sprintf("Hours ahead to rate, up to %d", MAX_DRONE_HOURS))
+
+			return func(ctx context.Context, args []string, units string) error {
+				if *maxWind <= 0 {
+					return errors.New("-max-wind must be positive")
+				}
+				if *hours < 1 || *hours > MAX_DRONE_HOURS {
+					return fmt.Errorf("-hours must be between 1 and %d", MAX_DRONE_HOURS)
+				}
+
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runDrone(ctx, target, *maxWind, *hours, units)
+			}
+		},
+	},
+	{
+		Name:    "metar",
+		Args:    "station",
+		Summary: "Decoded METAR and TAF aviation reports for an airport (ICAO or IATA code)",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			taf := flags.Bool("taf", true, "Also show the terminal aerodrome forecast")
+
+			return func(ctx context.Context, args []string, units string) error {
+				if len(args) != 1 {
+					return errors.New("metar needs one airport code, e.g. weather metar EGLL")
+				}
+
+				return runMetar(ctx, args[0], *taf, units)
+			}
+		},
+	},
+	{
+		Name:    "bench",
+		Args:    "[location]",
+		Summary: "Time each configured provider and check how complete its data is",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			rounds := flags.Int("rounds", 3, "Requests sent to each provider")
+
+			return func(ctx context.Context, args []string, units string) error {
+				target, err := targetLocation(ctx, args)
+				if err != nil {
+					return err
+				}
+
+				return runBench(ctx, target, *rounds, units)
+			}
+		},
+	},
+	{
+		Name:    "self-update",
+		Summary: "Replace this binary with the latest release after verifying its checksum",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			check := flags.Bool("check", false, "Only report whether a newer release exists")
+			force := flags.Bool("force", false, "Reinstall even when already on the latest release")
+
+			return func(ctx context.Context, args []string, units string) error {
+				return runSelfUpdate(ctx, *check, *force)
+			}
+		},
+	},
+	{
+		Name:    "version",
+		Summary: "Print the version of this build",
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			check := flags.Bool("check", false, "Also report whether a newer release exists")
+
+			return func(ctx context.Context, args []string, units string) error {
+				return runVersion(ctx, *check)
+			}
+		},
+	},
+	{
+		Name:        "cities",
+		Args:        "[status|update|remove]",
+		Summary:     "Download the full GeoNames city list for offline search, or show which list is used",
+		Completions: []string{"status", "update", "remove"},
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				return runCities(ctx, args)
+			}
+		},
+	},
+	{
+		Name:        "auth",
+		Args:        "[set|delete|status] [provider]",
+		Summary:     "Keep provider API keys in the system keyring instead of the config file",
+		Completions: []string{"set", "delete", "status"},
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				return runAuth(ctx, globals.ConfigPath, args)
+			}
+		},
+	},
+	{
+		Name:        "config",
+		Args:        "[show|path|init]",
+		Summary:     "Show the effective settings, print the config path or create a starter config",
+		Completions: []string{"show", "path", "init"},
+		Setup: func(flags *flag.FlagSet) commandRunner {
+			return func(ctx context.Context, args []string, units string) error {
+				return runConfig(globals.ConfigPath, args)
+			}
+		},
+	},
+}
+
+// Looks up a subcommand by name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// Prints how to call a command along with the flags only it accepts
+func (c command) usage(out io.Writer) {
+	fmt.Fprintf(out, "Usage: weather %s [flags] %s\n\n%s.\n", c.Name, c.Args, c.Summary)
+
+	own := c.ownFlags()
+	own.SetOutput(out)
+
+	hasFlags := false
+	own.VisitAll(func(*flag.Flag) { hasFlags = true })
+
+	if hasFlags {
+		fmt.Fprintf(out, "\nFlags:\n")
+		own.PrintDefaults()
+	}
+
+	fmt.Fprintf(out, "\nGlobal flags are listed by `weather -h`.\n")
+}
+
+// Flag set holding only the command's own flags
+func (c command) ownFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	c.Setup(flags)
+
+	return flags
+}
+
+// Flags accepted before the command as well as after it
+type globalFlags struct {
+	Units      string
+	Wind       string
+	Pressure   string
+	Proxy      string
+	CACert     string
+	Insecure   bool
+	ConfigPath string
+	NoHistory  bool
+	NoComfort  bool
+	Providers  string
+	Debug      bool
+	Profile    string
+	Style      string
+	Coords     string
+	GeoSource  string
+	Privacy    bool
+	Zone       string
+	Local      bool
+	TimeFormat string
+	Language   string
+}
+
+// Global flags given on the command line
+var globals = globalFlags{ConfigPath: defaultConfigPath()}
+
+// Registers the global flags on a flag set. The current values become the
+// defaults, so parsing a command's flags keeps what was given before it.
+func (g *globalFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&g.Units, "units", g.Units, "Units to display: auto (from the location's country), metric or imperial (default from WEATHER_UNITS, the profile, then auto)")
+	flags.StringVar(&g.Wind, "wind", g.Wind, "Unit of wind speeds: "+strings.Join(windUnits, ", ")+", auto following -units (default from WEATHER_WIND, the profile, then auto)")
+	flags.StringVar(&g.Pressure, "pressure", g.Pressure, "Unit of pressures: "+strings.Join(pressureUnits, ", ")+", auto following -units and the country (default from WEATHER_PRESSURE, the profile, then auto)")
+	flags.BoolVar(&quiet, "q", quiet, "Suppress progress messages (shorthand)")
+	flags.BoolVar(&quiet, "quiet", quiet, "Suppress progress messages")
+	flags.DurationVar(&requestTimeout, "timeout", requestTimeout, "Time allowed for each request, e.g. 30s (0 for no limit)")
+	flags.IntVar(&maxRetries, "retries", maxRetries, "Extra attempts for requests that fail with a timeout, network error or server error")
+	flags.StringVar(&g.Proxy, "proxy", g.Proxy, "Proxy URL such as http://host:3128 or socks5://127.0.0.1:9050 (default from HTTPS_PROXY/HTTP_PROXY/ALL_PROXY)")
+	flags.StringVar(&g.CACert, "cacert", g.CACert, "PEM file with extra CA certificates to trust")
+	flags.BoolVar(&g.Insecure, "insecure", g.Insecure, "Skip TLS certificate verification (unsafe)")
+	flags.StringVar(&g.ConfigPath, "config", g.ConfigPath, "Path to the config file (default from WEATHER_CONFIG)")
+	flags.BoolVar(&g.NoHistory, "no-history", g.NoHistory, "Don't store fetched observations in the history")
+	flags.BoolVar(&g.NoComfort, "no-comfort", g.NoComfort, "Leave the comfort assessment out of reports (default from comfort in the config)")
+	flags.StringVar(&g.Providers, "providers", g.Providers, "Comma separated weather providers tried in order: "+strings.Join(providerNames, ", ")+" (default from WEATHER_PROVIDERS, the config, then "+DEFAULT_PROVIDERS+")")
+	flags.BoolVar(&g.Debug, "debug", g.Debug, "Log requests, timings and retries to stderr")
+	flags.StringVar(&g.Profile, "profile", g.Profile, "Named profile from the config file (default from WEATHER_PROFILE, then the config's profile key)")
+	flags.StringVar(&g.Style, "style", g.Style, "How current conditions are printed: "+strings.Join(outputStyles, ", ")+" (default from WEATHER_STYLE, the profile, then "+FULL_STYLE+")")
+	flags.StringVar(&g.Coords, "coords", g.Coords, "Coordinates used when no location is given, such as 40.4461,-79.9822 or 40°26'46\"N 79°58'56\"W (default from WEATHER_LOCATION, then the profile)")
+	flags.StringVar(&g.GeoSource, "geo-source", g.GeoSource, "Comma separated location sources tried in order when no location is given, system being the OS location service and gps a local gpsd: "+strings.Join(geoSourceNames, ", ")+" (default from WEATHER_GEO_SOURCE, then "+DEFAULT_GEO_SOURCES+")")
+	flags.BoolVar(&g.Privacy, "privacy", g.Privacy, "Never look up your location from your IP address or send coordinates to be named, only the OS location service and gpsd are asked (default from WEATHER_PRIVACY, then the config)")
+	flags.StringVar(&g.TimeFormat, "time-format", g.TimeFormat, "Clock used for times: 12, 24 or auto (from the location's country) (default from WEATHER_TIME_FORMAT, the profile, then auto)")
+	flags.StringVar(&g.Language, "lang", g.Language, "Language of labels and weather descriptions: "+strings.Join(languages(), ", ")+" (default from WEATHER_LANG, the profile, then the system locale)")
+	flags.StringVar(&g.Zone, "tz", g.Zone, "Show every time in this zone, such as Europe/Paris, instead of the place's own (default from WEATHER_TZ)")
+	flags.BoolVar(&g.Local, "local", g.Local, "Show every time in this machine's zone, the same as -tz local")
+	flags.IntVar(&concurrency, "concurrency", concurrency, "Maximum number of locations processed at once in bulk operations")
+}
+
+// Place used when a command is given no location, set by -coords, the
+// environment or the profile
+var defaultLocation string
+
+// Where the user is: the configured default location, or the one detected
+// from their IP address
+func ownLocation(ctx context.Context) (location, error) {
+	if defaultLocation != "" {
+		return resolveLocation(ctx, defaultLocation)
+	}
+
+	return fetchUserLocation(ctx)
+}
+
+// Resolves the one location a command works on and remembers it as the last
+// one. Every argument is part of the query, so `weather forecast new york`
+// needs no quotes. Without arguments the profile's location is used, and
+// your own location is detected when there is none. Bare coordinates get
+// the name of the nearest place.
+func targetLocation(ctx context.Context, args []string) (location, error) {
+	var target location
+	var err error
+
+	if len(args) == 0 {
+		target, err = ownLocation(ctx)
+	} else {
+		target, err = resolveLocation(ctx, strings.Join(args, " "))
+	}
+	if err != nil {
+		return location{}, err
+	}
+
+	target = nameCoordinates(ctx, target)
+	rememberLocation(target)
+
+	return target, nil
+}
+
+// Implements `weather now`
+func runNow(ctx context.Context, queries []string, consensus bool, units string) error {
+	// Coordinates written with a space, as in 40.4461 -79.9822, are one place
+	if joined := strings.Join(queries, " "); len(queries) > 1 {
+		if _, ok := parseCoordinate(joined); ok {
+			queries = []string{joined}
+		}
+	}
+
+	if consensus {
+		if len(queries) > 1 {
+			return errors.New("-consensus works on a single location")
+		}
+
+		target, err := targetLocation(ctx, queries)
+		if err != nil {
+			return err
+		}
+
+		return runConsensus(ctx, target, units)
+	}
+
+	if len(queries) <= 1 {
+		target, err := targetLocation(ctx, queries)
+		if err != nil {
+			return err
+		}
+
+		result, err := fetchReport(ctx, target, units)
+		if err != nil {
+			return err
+		}
+
+		result.show()
+		return nil
+	}
+
+	return printMany(ctx, queries, units)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Defaults of weather commute, also settable as commute_duration and
+// commute_between in the config
+const (
+	DEFAULT_COMMUTE_DURATION = "30m"
+	DEFAULT_COMMUTE_BETWEEN  = "07:00-10:00"
+)
+
+// Length and departure hours of the commute from the environment, the
+// profile or the defaults; the flags of weather commute beat them
+var (
+	commuteDuration = DEFAULT_COMMUTE_DURATION
+	commuteBetween  = DEFAULT_COMMUTE_BETWEEN
+)
+
+// Departures weather commute compares are this far apart
+const COMMUTE_STEP = 15 * time.Minute
+
+// Wind in km/h that doesn't yet make a commute worse
+const COMMUTE_CALM_KMH = 15.0
+
+// The weather over one possible trip, averaged minute by minute
+type commuteTrip struct {
+	Leave  time.Time
+	Arrive time.Time
+	Pop    float64 // Average chance of being rained on
+	Amount float64 // Precipitation expected over the trip
+	Wind   float64 // Average in the units fetched
+	Storm  bool
+}
+
+// The weather over a trip from leaving to arriving, false when the forecast
+// doesn't reach that far. The minutely forecast stands in for the hourly one
+// where there is one, as it says exactly when rain comes.
+func (w weatherData) commute(leave time.Time, duration time.Duration, units unitSystem) (commuteTrip, bool) {
+	trip := commuteTrip{Leave: leave, Arrive: leave.Add(duration)}
+	threshold := units.fromMillimeters(WET_MM_PER_HOUR)
+
+	minutes := 0
+	for moment := leave; moment.Before(trip.Arrive); moment = moment.Add(time.Minute) {
+		hour, found := w.hourAt(moment)
+		if !found {
+			return commuteTrip{}, false
+		}
+
+		pop, intensity := hour.Pop, hour.Precipitation
+		for _, step := range w.Minutely {
+			if !moment.Before(step.Time) && moment.Before(step.Time.Add(time.Minute)) {
+				pop, intensity = 0, step.Precipitation
+				if intensity >= threshold {
+					pop = 1
+				}
+				break
+			}
+		}
+
+		trip.Pop += pop
+		trip.Amount += intensity / 60
+		trip.Wind += hour.WindSpeed
+		trip.Storm = trip.Storm || hour.Condition.Kind == THUNDERSTORM
+		minutes++
+	}
+
+	if minutes == 0 {
+		return commuteTrip{}, false
+	}
+
+	trip.Pop /= float64(minutes)
+	trip.Wind /= float64(minutes)
+
+	return trip, true
+}
+
+// How unpleasant a trip is, lower is better. Getting wet counts the most.
+func (t commuteTrip) cost(units unitSystem) float64 {
+	wind := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(t.Wind))
+	millimeters := t.Amount / units.fromMillimeters(1)
+
+	cost := t.Pop*10 + millimeters*4 + max(0, wind-COMMUTE_CALM_KMH)*0.2
+	if t.Storm {
+		cost += 10
+	}
+
+	return cost
+}
+
+// Implements `weather commute`, comparing departures within the span for a
+// trip of the duration and suggesting the driest, least windy one. Once
+// today's span is over it plans tomorrow's.
+func runCommute(ctx context.Context, target location, duration time.Duration, span clockSpan, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	clock := options.clockFormat()
+	now := weather.Current.Time
+
+	plan := func(day time.Time) []commuteTrip {
+		year, month, date := day.Date()
+		midnight := time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+
+		var trips []commuteTrip
+		for leave := midnight.Add(span.From); !leave.After(midnight.Add(span.To)); leave = leave.Add(COMMUTE_STEP) {
+			if leave.Before(now) {
+				continue
+			}
+
+			if trip, ok := weather.commute(leave, duration, options.Units); ok {
+				trips = append(trips, trip)
+			}
+		}
+
+		return trips
+	}
+
+	day := tr("today")
+	trips := plan(now)
+	if len(trips) == 0 {
+		day = tr("tomorrow")
+		trips = plan(now.AddDate(0, 0, 1))
+	}
+	if len(trips) == 0 {
+		return fmt.Errorf("%s has no forecast for that time range", result.Provider)
+	}
+
+	best := 0
+	rows := [][]string{translated("Leave", "Arrive", "Rain chance", "Amount", "Wind")}
+	for index, trip := range trips {
+		if trip.cost(options.Units) < trips[best].cost(options.Units) {
+			best = index
+		}
+
+		rows = append(rows, []string{
+			trip.Leave.Format(clock),
+			trip.Arrive.Format(clock),
+			fmt.Sprintf("%.0f%%", trip.Pop*100),
+			options.Units.formatPrecipitation(trip.Amount),
+			options.wind(trip.Wind, 1),
+		})
+	}
+
+	fmt.Printf("\n"+tr("Commute of %.0f min %s")+"\n\n", duration.Minutes(), day)
+	printTable(os.Stdout, rows)
+
+	trip := trips[best]
+	fmt.Printf("\n"+tr("Best departure: %s, arriving around %s with a %.0f%% chance of rain and %s wind")+"\n",
+		trip.Leave.Format(clock), trip.Arrive.Format(clock), trip.Pop*100, options.wind(trip.Wind, 1))
+
+	// Drivers hear of the roads over the trip in winter
+	ahead := int(trip.Arrive.Sub(now).Hours()) + 1
+	if weather.wintry(ahead, options.Units) {
+		var during []roadMoment
+		for _, moment := range weather.roadForecast(ahead, nil, options.Units) {
+			if moment.Conditions.Time.Before(trip.Arrive) && !moment.Conditions.Time.Before(trip.Leave.Truncate(time.Hour)) {
+				during = append(during, moment)
+			}
+		}
+
+		if summary := roadSummary(during, clock); summary != "" {
+			fmt.Printf("\n⚠️  %s: %s\n", tr("Road Warnings"), summary)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Fetches several places and prints their current weather side by side
+func runCompare(ctx context.Context, queries []string, units string) error {
+	if len(queries) < 2 {
+		return errors.New("compare needs at least two locations, e.g. weather compare london tokyo \"new york\"")
+	}
+
+	// Every column uses the same units so the numbers can be compared
+	options, err := resolveDisplay(units, "")
+	if err != nil {
+		return err
+	}
+
+	results := runPool("Comparing", queries, func(query string) string { return query }, func(query string) (report, error) {
+		target, err := resolveLocation(ctx, query)
+		if err != nil {
+			return report{}, err
+		}
+
+		return fetchReportWith(ctx, target, options)
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	rows := [][]string{{""}, {tr("Temperature")}, {tr("Condition")}, {tr("Wind")}, {tr("Humidity")}, {tr("Local time")}}
+
+	for index, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		current := result.Value.Weather.Current
+
+		name := result.Value.Location.CompactName
+		if name == "" {
+			name = queries[index]
+		}
+
+		rows[0] = append(rows[0], name)
+		rows[1] = append(rows[1], fmt.Sprintf("%.1f%s", current.Temp, options.Units.temperature()))
+		rows[2] = append(rows[2], current.Condition.emoji()+" "+current.Condition.Description)
+		rows[3] = append(rows[3], options.wind(current.WindSpeed, 1)+" "+compassDirection(current.WindDeg))
+		rows[4] = append(rows[4], fmt.Sprintf("%d%%", current.Humidity))
+		rows[5] = append(rows[5], current.Time.Format("Mon "+options.clockFormat()))
+	}
+
+	if len(rows[0]) == 1 {
+		return errors.New("no location could be fetched")
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Shells `weather completion` can write a script for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// The completion commands read the command table, so they are added to it
+// here instead of in its initializer
+func init() {
+	commands = append(commands,
+		command{
+			Name:        "completion",
+			Args:        strings.Join(completionShells, "|"),
+			Summary:     "Print a shell completion script",
+			Completions: completionShells,
+			Setup: func(flags *flag.FlagSet) commandRunner {
+				return func(ctx context.Context, args []string, units string) error {
+					if len(args) != 1 {
+						return fmt.Errorf("completion needs a shell: %s", strings.Join(completionShells, ", "))
+					}
+
+					return writeCompletion(os.Stdout, args[0])
+				}
+			},
+		},
+		command{
+			Name:    "__complete",
+			Args:    "locations",
+			Summary: "Print completion candidates for the shell scripts",
+			Hidden:  true,
+			Setup: func(flags *flag.FlagSet) commandRunner {
+				return func(ctx context.Context, args []string, units string) error {
+					for _, name := range completionLocations() {
+						fmt.Println(name)
+					}
+
+					return nil
+				}
+			},
+		},
+	)
+}
+
+// Saved location names offered wherever a location is expected
+func completionLocations() []string {
+	var names []string
+	for name := range aliases {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	if _, err := loadLastLocation(); err == nil {
+		names = append(names, LAST_LOCATION)
+	}
+
+	return names
+}
+
+// Flags of one command, split by whether they take a value
+type commandFlags struct {
+	Switches []string
+	Valued   []string
+}
+
+// Names of the flags in a set, with their leading dash
+func splitFlags(flags *flag.FlagSet) commandFlags {
+	var split commandFlags
+	flags.VisitAll(func(f *flag.Flag) {
+		if isSwitch(f) {
+			split.Switches = append(split.Switches, "-"+f.Name)
+		} else {
+			split.Valued = append(split.Valued, "-"+f.Name)
+		}
+	})
+
+	return split
+}
+
+// Whether a flag is a boolean that takes no value
+func isSwitch(f *flag.Flag) bool {
+	boolean, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolean.IsBoolFlag()
+}
+
+// Flag set holding only the global flags
+func globalFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("weather", flag.ContinueOnError)
+
+	// Registering again keeps every current value
+	globals.register(flags)
+
+	return flags
+}
+
+// Every flag name, switches first
+func (c commandFlags) all() []string {
+	return append(slices.Clone(c.Switches), c.Valued...)
+}
+
+// Fixed values offered after a flag that takes one
+type flagValues struct {
+	Flag   string
+	Values []string
+}
+
+// Flags whose values come from a fixed list
+func flagValueCompletions() []flagValues {
+	return []flagValues{
+		{"-units", []string{AUTO_UNITS, string(METRIC), string(IMPERIAL)}},
+		{"-wind", windUnits},
+		{"-pressure", pressureUnits},
+		{"-providers", providerNames},
+		{"-format", batchFormats},
+		{"-style", outputStyles},
+		{"-time-format", timeFormats},
+		{"-lang", languages()},
+		{"-sort", searchOrders},
+		{"-sport", activityNames()},
+		{"-until", sunEventNames()},
+		{"-protocol", graphicsProtocols},
+		{"-band", satelliteBands},
+		{"-geo-source", geoSourceNames},
+	}
+}
+
+// Fixed values for a flag, nil when any value goes
+func knownValues(name string) []string {
+	for _, known := range flagValueCompletions() {
+		if known.Flag == name {
+			return known.Values
+		}
+	}
+
+	return nil
+}
+
+// Commands listed in completions, leaving out the hidden ones
+func visibleCommands() []command {
+	var visible []command
+	for _, cmd := range commands {
+		if !cmd.Hidden {
+			visible = append(visible, cmd)
+		}
+	}
+
+	return visible
+}
+
+// Whether a command's positional arguments are locations
+func (c command) takesLocations() bool {
+	return strings.Contains(c.Args, "location")
+}
+
+// Writes the completion script for a shell
+func writeCompletion(out io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(out)
+	case "zsh":
+		writeZshCompletion(out)
+	case "fish":
+		writeFishCompletion(out)
+	case "powershell":
+		writePowerShellCompletion(out)
+	default:
+		return fmt.Errorf("unknown shell %q, expected one of %s", shell, strings.Join(completionShells, ", "))
+	}
+
+	return nil
+}
+
+// Names of the commands that take locations
+func locationCommands() []string {
+	var names []string
+	for _, cmd := range visibleCommands() {
+		if cmd.takesLocations() {
+			names = append(names, cmd.Name)
+		}
+	}
+
+	return names
+}
+
+// Every flag that takes a value, global or per command
+func valuedFlags() []string {
+	valued := splitFlags(globalFlagSet()).Valued
+	for _, cmd := range visibleCommands() {
+		for _, name := range splitFlags(cmd.ownFlags()).Valued {
+			if !slices.Contains(valued, name) {
+				valued = append(valued, name)
+			}
+		}
+	}
+
+	return valued
+}
+
+func writeBashCompletion(out io.Writer) {
+	globalFlags := splitFlags(globalFlagSet())
+
+	var names []string
+	for _, cmd := range visibleCommands() {
+		names = append(names, cmd.Name)
+	}
+
+	fmt.Fprintf(out, `# bash completion for weather, load with: source <(weather completion bash)
+_weather() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local command="" index
+
+    # The first word that is neither a flag nor a flag's value is the command
+    for ((index = 1; index < COMP_CWORD; index++)); do
+        case "${COMP_WORDS[index]}" in
+            %s) ((index++)) ;;
+            -*) ;;
+            *) command="${COMP_WORDS[index]}"; break ;;
+        esac
+    done
+
+    case "$prev" in
+`, strings.Join(valuedFlags(), "|"))
+
+	for _, known := range flagValueCompletions() {
+		fmt.Fprintf(out, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", known.Flag, strings.Join(known.Values, " "))
+	}
+	fmt.Fprintf(out, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(valuedFlags(), "|"))
+
+	fmt.Fprintf(out, `    esac
+
+    local flags=%q
+    case "$command" in
+`, strings.Join(globalFlags.all(), " "))
+
+	for _, cmd := range visibleCommands() {
+		if own := splitFlags(cmd.ownFlags()).all(); len(own) > 0 {
+			fmt.Fprintf(out, "        %s) flags=\"$flags %s\" ;;\n", cmd.Name, strings.Join(own, " "))
+		}
+	}
+
+	fmt.Fprintf(out, `    esac
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+        return
+    fi
+
+    local locations
+    locations="$(weather __complete locations 2>/dev/null)"
+
+    case "$command" in
+        "") COMPREPLY=($(compgen -W "%s $locations" -- "$cur")) ;;
+        %s) COMPREPLY=($(compgen -W "$locations" -- "$cur")) ;;
+        batch) COMPREPLY=($(compgen -f -- "$cur")) ;;
+`, strings.Join(names, " "), strings.Join(locationCommands(), "|"))
+
+	for _, cmd := range visibleCommands() {
+		if len(cmd.Completions) > 0 {
+			fmt.Fprintf(out, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", cmd.Name, strings.Join(cmd.Completions, " "))
+		}
+	}
+
+	fmt.Fprintf(out, `    esac
+}
+complete -F _weather weather
+`)
+}
+
+func writeZshCompletion(out io.Writer) {
+	globalFlags := splitFlags(globalFlagSet())
+
+	fmt.Fprintf(out, `#compdef weather
+# zsh completion for weather, load with: source <(weather completion zsh)
+_weather() {
+    local -a commands flags locations
+    local command="" index
+
+    commands=(
+`)
+
+	for _, cmd := range visibleCommands() {
+		fmt.Fprintf(out, "        %q\n", cmd.Name+":"+cmd.Summary)
+	}
+
+	fmt.Fprintf(out, `    )
+
+    # The first word that is neither a flag nor a flag's value is the command
+    for ((index = 2; index < CURRENT; index++)); do
+        case "${words[index]}" in
+            %s) ((index++)) ;;
+            -*) ;;
+            *) command="${words[index]}"; break ;;
+        esac
+    done
+
+    case "${words[CURRENT-1]}" in
+`, strings.Join(valuedFlags(), "|"))
+
+	for _, known := range flagValueCompletions() {
+		fmt.Fprintf(out, "        %s) compadd -- %s; return ;;\n", known.Flag, strings.Join(known.Values, " "))
+	}
+	fmt.Fprintf(out, "        %s) _files; return ;;\n", strings.Join(valuedFlags(), "|"))
+
+	fmt.Fprintf(out, `    esac
+
+    flags=(%s)
+    case "$command" in
+`, strings.Join(globalFlags.all(), " "))
+
+	for _, cmd := range visibleCommands() {
+		if own := splitFlags(cmd.ownFlags()).all(); len(own) > 0 {
+			fmt.Fprintf(out, "        %s) flags+=(%s) ;;\n", cmd.Name, strings.Join(own, " "))
+		}
+	}
+
+	fmt.Fprintf(out, `    esac
+
+    if [[ "${words[CURRENT]}" == -* ]]; then
+        compadd -- $flags
+        return
+    fi
+
+    locations=(${(f)"$(weather __complete locations 2>/dev/null)"})
+
+    case "$command" in
+        "") _describe command commands; compadd -- $locations ;;
+        %s) compadd -- $locations ;;
+        batch) _files ;;
+`, strings.Join(locationCommands(), "|"))
+
+	for _, cmd := range visibleCommands() {
+		if len(cmd.Completions) > 0 {
+			fmt.Fprintf(out, "        %s) compadd -- %s ;;\n", cmd.Name, strings.Join(cmd.Completions, " "))
+		}
+	}
+
+	fmt.Fprintf(out, `    esac
+}
+compdef _weather weather
+`)
+}
+
+func writeFishCompletion(out io.Writer) {
+	fmt.Fprintln(out, "# fish completion for weather, load with: weather completion fish | source")
+	fmt.Fprintln(out, "complete -c weather -f")
+
+	var names []string
+	for _, cmd := range visibleCommands() {
+		names = append(names, cmd.Name)
+		fmt.Fprintf(out, "complete -c weather -n __fish_use_subcommand -a %s -d %s\n", cmd.Name, fishQuote(cmd.Summary))
+	}
+
+	describe := func(condition string, flags *flag.FlagSet) {
+		flags.VisitAll(func(f *flag.Flag) {
+			line := "complete -c weather"
+			if condition != "" {
+				line += " -n " + fishQuote(condition)
+			}
+			line += " -o " + f.Name
+
+			if !isSwitch(f) {
+				if values := knownValues("-" + f.Name); values != nil {
+					line += " -x -a " + fishQuote(strings.Join(values, " "))
+				} else {
+					line += " -r -F"
+				}
+			}
+
+			fmt.Fprintln(out, line+" -d "+fishQuote(f.Usage))
+		})
+	}
+
+	describe("", globalFlagSet())
+
+	for _, cmd := range visibleCommands() {
+		describe("__fish_seen_subcommand_from "+cmd.Name, cmd.ownFlags())
+
+		if len(cmd.Completions) > 0 {
+			fmt.Fprintf(out, "complete -c weather -n %s -a %s\n", fishQuote("__fish_seen_subcommand_from "+cmd.Name), fishQuote(strings.Join(cmd.Completions, " ")))
+		}
+	}
+
+	condition := "__fish_use_subcommand; or __fish_seen_subcommand_from " + strings.Join(locationCommands(), " ")
+	fmt.Fprintf(out, "complete -c weather -n %s -a %s\n", fishQuote(condition), fishQuote("(weather __complete locations 2>/dev/null)"))
+	fmt.Fprintf(out, "complete -c weather -n %s -F\n", fishQuote("__fish_seen_subcommand_from batch"))
+}
+
+// Single quotes a string for fish
+func fishQuote(text string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(text) + "'"
+}
+
+func writePowerShellCompletion(out io.Writer) {
+	quote := func(items []string) string {
+		quoted := make([]string, len(items))
+		for index, item := range items {
+			quoted[index] = "'" + strings.ReplaceAll(item, "'", "''") + "'"
+		}
+
+		return "@(" + strings.Join(quoted, ", ") + ")"
+	}
+
+	var names []string
+	for _, cmd := range visibleCommands() {
+		names = append(names, cmd.Name)
+	}
+
+	fmt.Fprintf(out, `# PowerShell completion for weather, load with:
+#   weather completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName weather -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $valued = %s
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })
+
+    # The first word that is neither a flag nor a flag's value is the command
+    $command = ''
+    for ($index = 0; $index -lt $words.Count; $index++) {
+        if ($valued -contains $words[$index]) { $index++ }
+        elseif (-not $words[$index].StartsWith('-')) { $command = $words[$index]; break }
+    }
+
+    $previous = if ($words.Count -gt 0) { $words[-1] } else { '' }
+    $flags = %s
+    $candidates = switch ($previous) {
+`, quote(valuedFlags()), quote(splitFlags(globalFlagSet()).all()))
+
+	for _, known := range flagValueCompletions() {
+		fmt.Fprintf(out, "        '%s' { %s }\n", known.Flag, quote(known.Values))
+	}
+
+	fmt.Fprintf(out, `        default {
+            switch ($command) {
+`)
+
+	for _, cmd := range visibleCommands() {
+		if own := splitFlags(cmd.ownFlags()).all(); len(own) > 0 {
+			fmt.Fprintf(out, "                '%s' { $flags += %s }\n", cmd.Name, quote(own))
+		}
+	}
+
+	fmt.Fprintf(out, `            }
+
+            if ($wordToComplete.StartsWith('-')) { $flags }
+            else {
+                $locations = @(weather __complete locations 2>$null)
+                switch ($command) {
+                    '' { %s + $locations }
+`, quote(names))
+
+	for _, name := range locationCommands() {
+		fmt.Fprintf(out, "                    '%s' { $locations }\n", name)
+	}
+	for _, cmd := range visibleCommands() {
+		if len(cmd.Completions) > 0 {
+			fmt.Fprintf(out, "                    '%s' { %s }\n", cmd.Name, quote(cmd.Completions))
+		}
+	}
+
+	fmt.Fprintf(out, `                }
+            }
+        }
+    }
+
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`)
+}
@@ -0,0 +1,669 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Settings read from the config file
+type config struct {
+	// Requests per second allowed for each provider, plus "default"
+	RateLimits map[string]float64
+
+	// Nicknames for places, mapping to a search query or "lat,lon"
+	Aliases map[string]string
+
+	// Top-level settings, used wherever the chosen profile leaves a gap
+	Defaults profile
+
+	// Named sets of settings chosen with -profile or WEATHER_PROFILE
+	Profiles map[string]profile
+
+	// Profile used when neither -profile nor WEATHER_PROFILE names one
+	Profile string
+
+	// Personal API keys by provider name
+	APIKeys map[string]string
+
+	// Mention newer releases after commands
+	UpdateNotice bool
+
+	// Never send the user's IP address or position to lookup services
+	Privacy bool
+
+	// Leave the comfort assessment out of reports, set with comfort = false
+	HideComfort bool
+}
+
+// Settings that can differ between profiles
+type profile struct {
+	// Place used when a command is given no location
+	Location string
+
+	// Units system, as accepted by -units
+	Units string
+
+	// Unit of wind speeds, as accepted by -wind
+	Wind string
+
+	// Unit of pressures, as accepted by -pressure
+	Pressure string
+
+	// Weather providers in order of preference
+	Providers []string
+
+	// How current conditions are printed, as accepted by -style
+	Style string
+
+	// 12 or 24 hour clock, as accepted by -time-format
+	TimeFormat string
+
+	// Language of the output labels, as accepted by -lang
+	Language string
+
+	// Degrees `weather wear` shifts by, as accepted by its -offset
+	WearOffset string
+
+	// Length and departure hours of `weather commute`, as accepted by its
+	// -duration and -between
+	CommuteDuration string
+	CommuteBetween  string
+
+	// Base temperature of `weather agri`, as accepted by its -base
+	GDDBase string
+
+	// Base temperature of `weather degreedays`, as accepted by its -base
+	DegreeDayBase string
+}
+
+// Sections and keys of a parsed config file; top-level keys live under ""
+type configFile map[string]map[string]string
+
+// Location of the config file unless -config says otherwise
+func defaultConfigPath() string {
+	if path := os.Getenv("WEATHER_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "weather", "config.toml")
+}
+
+// Reads the config file. A missing file is not an error, it just means
+// every setting keeps its default.
+func loadConfig(path string) (config, error) {
+	settings := config{RateLimits: map[string]float64{}, Aliases: map[string]string{}, Profiles: map[string]profile{}, APIKeys: map[string]string{}}
+
+	if path == "" {
+		return settings, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("opening config: %w", err)
+	}
+	defer file.Close()
+
+	parsed, err := parseConfig(file, path)
+	if err != nil {
+		return settings, err
+	}
+
+	for section, values := range parsed {
+		switch {
+		case section == "":
+			for key, raw := range values {
+				if key == "profile" {
+					settings.Profile = raw
+					continue
+				}
+
+				if key == "update_notice" {
+					enabled, err := strconv.ParseBool(raw)
+					if err != nil {
+						return settings, fmt.Errorf("%s: update_notice must be true or false", path)
+					}
+
+					settings.UpdateNotice = enabled
+					continue
+				}
+
+				if key == "privacy" {
+					enabled, err := strconv.ParseBool(raw)
+					if err != nil {
+						return settings, fmt.Errorf("%s: privacy must be true or false", path)
+					}
+
+					settings.Privacy = enabled
+					continue
+				}
+
+				if key == "comfort" {
+					enabled, err := strconv.ParseBool(raw)
+					if err != nil {
+						return settings, fmt.Errorf("%s: comfort must be true or false", path)
+					}
+
+					settings.HideComfort = !enabled
+					continue
+				}
+
+				if !settings.Defaults.set(key, raw) {
+					return settings, fmt.Errorf("%s: unknown setting %q", path, key)
+				}
+			}
+		case strings.HasPrefix(section, "profiles."):
+			name := unquote(strings.TrimPrefix(section, "profiles."))
+
+			var named profile
+			for key, raw := range values {
+				if !named.set(key, raw) {
+					return settings, fmt.Errorf("%s: unknown setting %q in [%s]", path, key, section)
+				}
+			}
+
+			settings.Profiles[name] = named
+		case section == "rate_limits":
+			for provider, raw := range values {
+				rate, err := strconv.ParseFloat(raw, 64)
+				if err != nil || rate < 0 {
+					return settings, fmt.Errorf("%s: rate_limits.%s must be a non-negative number of requests per second", path, provider)
+				}
+
+				settings.RateLimits[provider] = rate
+			}
+		case section == "api_keys":
+			for provider, key := range values {
+				if !keyedProviders[provider] {
+					return settings, fmt.Errorf("%s: api_keys.%s is not a provider that takes a key", path, provider)
+				}
+
+				settings.APIKeys[provider] = key
+			}
+		case section == "aliases":
+			for name, target := range values {
+				if target == "" {
+					return settings, fmt.Errorf("%s: alias %q has no location", path, name)
+				}
+
+				settings.Aliases[strings.ToLower(name)] = target
+			}
+		default:
+			return settings, fmt.Errorf("%s: unknown section [%s]", path, section)
+		}
+	}
+
+	return settings, nil
+}
+
+// Stores one config key in the profile, reporting false for unknown keys
+func (p *profile) set(key string, raw string) bool {
+	switch key {
+	case "location":
+		p.Location = raw
+	case "units":
+		p.Units = raw
+	case "wind":
+		p.Wind = raw
+	case "pressure":
+		p.Pressure = raw
+	case "providers":
+		p.Providers = parseList(raw)
+	case "style":
+		p.Style = raw
+	case "time_format":
+		p.TimeFormat = raw
+	case "lang":
+		p.Language = raw
+	case "wear_offset":
+		p.WearOffset = raw
+	case "commute_duration":
+		p.CommuteDuration = raw
+	case "commute_between":
+		p.CommuteBetween = raw
+	case "gdd_base":
+		p.GDDBase = raw
+	case "degree_day_base":
+		p.DegreeDayBase = raw
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Settings of the named profile with the top-level ones filling its gaps.
+// An empty name falls back to the profile picked in the config, if any.
+func (c config) effective(name string) (profile, error) {
+	if name == "" {
+		name = c.Profile
+	}
+
+	result := c.Defaults
+	if name == "" {
+		return result, nil
+	}
+
+	named, found := c.Profiles[name]
+	if !found {
+		if len(c.Profiles) == 0 {
+			return result, fmt.Errorf("unknown profile %q, the config defines none", name)
+		}
+
+		return result, fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(sortedKeys(c.Profiles), ", "))
+	}
+
+	if named.Location != "" {
+		result.Location = named.Location
+	}
+	if named.Units != "" {
+		result.Units = named.Units
+	}
+	if named.Wind != "" {
+		result.Wind = named.Wind
+	}
+	if named.Pressure != "" {
+		result.Pressure = named.Pressure
+	}
+	if len(named.Providers) > 0 {
+		result.Providers = named.Providers
+	}
+	if named.Style != "" {
+		result.Style = named.Style
+	}
+	if named.TimeFormat != "" {
+		result.TimeFormat = named.TimeFormat
+	}
+	if named.Language != "" {
+		result.Language = named.Language
+	}
+	if named.WearOffset != "" {
+		result.WearOffset = named.WearOffset
+	}
+	if named.CommuteDuration != "" {
+		result.CommuteDuration = named.CommuteDuration
+	}
+	if named.CommuteBetween != "" {
+		result.CommuteBetween = named.CommuteBetween
+	}
+	if named.GDDBase != "" {
+		result.GDDBase = named.GDDBase
+	}
+	if named.DegreeDayBase != "" {
+		result.DegreeDayBase = named.DegreeDayBase
+	}
+
+	return result, nil
+}
+
+// Parses the small TOML subset the config uses: [sections], key = value
+// pairs, # comments, quoted or bare strings, numbers and booleans.
+func parseConfig(file io.Reader, path string) (configFile, error) {
+	parsed := configFile{}
+	section := ""
+
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: unterminated section header", path, number)
+			}
+
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if parsed[section] == nil {
+				parsed[section] = map[string]string{}
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, number)
+		}
+
+		key = unquote(strings.TrimSpace(key))
+		value = unquote(strings.TrimSpace(value))
+
+		if parsed[section] == nil {
+			parsed[section] = map[string]string{}
+		}
+		parsed[section][key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	return parsed, nil
+}
+
+// Drops a trailing # comment that isn't inside a quoted string
+func stripComment(line string) string {
+	inQuotes := false
+
+	for index, char := range line {
+		switch {
+		case char == '"':
+			inQuotes = !inQuotes
+		case char == '#' && !inQuotes:
+			return line[:index]
+		}
+	}
+
+	return line
+}
+
+// Removes surrounding quotes, leaving bare values untouched
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+
+	return value
+}
+
+// Splits an array value like ["a", "b"] (or a bare a,b) into its items
+func parseList(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]")
+
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		item = unquote(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Starter config written by `weather config init`
+const CONFIG_TEMPLATE = `# Weather providers tried in order until one answers (-providers and
+# WEATHER_PROVIDERS override this)
+# providers = ["owm", "open-meteo", "met.no"]
+
+# Mention newer releases after commands, checked at most once a day
+# update_notice = true
+
+# Never look up your location from your IP address or send coordinates to be
+# named; only the OS location service and gpsd are asked (-privacy, WEATHER_PRIVACY)
+# privacy = true
+
+# Assess in reports how the temperature and humidity feel, like dry air that
+# is hard on sinuses or damp air that lets mold grow (-no-comfort)
+# comfort = true
+
+# Requests per second allowed for each provider (0 disables the limit)
+[rate_limits]
+# default = 5
+# owm = 2
+
+# Personal API keys, WEATHER_API_KEY overrides the owm one. To keep them out
+# of this file, store them with weather auth set owm instead.
+[api_keys]
+# owm = "your One Call API key"
+# what3words = "your what3words API key, for ///three.word.addresses"
+
+# Nicknames usable anywhere a location is expected, e.g. weather now cabin
+[aliases]
+# cabin = "61.2,-149.9"
+
+# Named settings picked with -profile or WEATHER_PROFILE, or profile = "home"
+# at the top. Profiles can set location, units, wind, pressure, providers,
+# style, time_format (12, 24 or auto), lang, wear_offset (degrees you run
+# warm, negative when you run cold), commute_duration ("30m"),
+# commute_between ("07:00-10:00"), gdd_base (degrees growing degree days
+# count from) and degree_day_base (degrees heating and cooling degree days
+# count from).
+# [profiles.home]
+# location = "cabin"
+# units = "metric"
+`
+
+// Environment variable that takes the place of a flag or config setting
+type environmentSetting struct {
+	Name    string
+	Meaning string
+}
+
+// Environment variables that take the place of flags, for containers and CI.
+// A flag beats its variable, which beats the config file.
+var environmentSettings = []environmentSetting{
+	{"WEATHER_CONFIG", "Path to the config file, like -config"},
+	{"WEATHER_PROFILE", "Profile to use, like -profile"},
+	{"WEATHER_LOCATION", "Location used when a command is given none"},
+	{"WEATHER_UNITS", "Units to display, like -units"},
+	{"WEATHER_WIND", "Unit of wind speeds, like -wind"},
+	{"WEATHER_PRESSURE", "Unit of pressures, like -pressure"},
+	{"WEATHER_PROVIDERS", "Weather providers tried in order, like -providers"},
+	{"WEATHER_PROVIDER", "Same as WEATHER_PROVIDERS"},
+	{"WEATHER_STYLE", "Output style, like -style"},
+	{"WEATHER_TIME_FORMAT", "12 or 24 hour clock, like -time-format"},
+	{"WEATHER_LANG", "Language of the output, like -lang (default from LC_ALL, LC_MESSAGES or LANG)"},
+	{"WEATHER_GEO_SOURCE", "Location sources tried in order, like -geo-source"},
+	{"WEATHER_GPSD", "host:port of the gpsd used by -geo-source gps (default " + GPSD_ADDRESS + ")"},
+	{"WEATHER_PRIVACY", "Set to true for privacy mode, like -privacy"},
+	{"WEATHER_TZ", "Zone every time is shown in, like -tz"},
+	{"WEATHER_WEAR_OFFSET", "Degrees you run warm or cold, like -offset of weather wear"},
+	{"WEATHER_COMMUTE_DURATION", "How long your commute takes, like -duration of weather commute"},
+	{"WEATHER_COMMUTE_BETWEEN", "Hours you leave between, like -between of weather commute"},
+	{"WEATHER_GDD_BASE", "Base temperature of growing degree days, like -base of weather agri"},
+	{"WEATHER_DEGREE_DAY_BASE", "Base temperature of heating and cooling degree days, like -base of weather degreedays"},
+	{"WEATHER_API_KEY", "Personal OpenWeatherMap key, like owm in [api_keys]"},
+}
+
+// Implements `weather config`
+func runConfig(path string, args []string) error {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "path":
+		if path == "" {
+			return errors.New("no config directory could be determined, pass -config")
+		}
+
+		fmt.Println(path)
+	case "init":
+		if path == "" {
+			return errors.New("no config directory could be determined, pass -config")
+		}
+
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("creating config directory: %w", err)
+		}
+
+		if err := os.WriteFile(path, []byte(CONFIG_TEMPLATE), 0o644); err != nil {
+			return fmt.Errorf("writing config: %w", err)
+		}
+
+		status("[+] Wrote " + path)
+	case "show":
+		settings, err := loadConfig(path)
+		if err != nil {
+			return err
+		}
+
+		settings.write(os.Stdout, path)
+
+		overrides := false
+		for _, setting := range environmentSettings {
+			name := setting.Name
+			value := os.Getenv(name)
+			if value == "" {
+				continue
+			}
+
+			if !overrides {
+				fmt.Println("\n# Overridden by the environment")
+				overrides = true
+			}
+
+			if name == "WEATHER_API_KEY" {
+				value = maskSecret(value)
+			}
+			fmt.Printf("# %s=%s\n", name, value)
+		}
+	default:
+		return fmt.Errorf("unknown config action %q, expected show, path or init", action)
+	}
+
+	return nil
+}
+
+// Writes the settings in config file syntax, so the output can be saved as is
+func (c config) write(out io.Writer, path string) {
+	fmt.Fprintf(out, "# Settings from %s\n", path)
+
+	if c.Profile != "" {
+		fmt.Fprintf(out, "profile = %s\n", strconv.Quote(c.Profile))
+	}
+	if c.UpdateNotice {
+		fmt.Fprintln(out, "update_notice = true")
+	}
+	if c.Privacy {
+		fmt.Fprintln(out, "privacy = true")
+	}
+	if c.HideComfort {
+		fmt.Fprintln(out, "comfort = false")
+	}
+
+	if len(c.Defaults.Providers) == 0 {
+		fmt.Fprintln(out, "# providers not set, using the default")
+		fmt.Fprintf(out, "# providers = [%s]\n", quoteList(strings.Split(DEFAULT_PROVIDERS, ",")))
+	}
+	c.Defaults.write(out)
+
+	writeSection(out, "rate_limits", c.RateLimits, func(rate float64) string { return strconv.FormatFloat(rate, 'g', -1, 64) })
+	writeSection(out, "aliases", c.Aliases, strconv.Quote)
+	writeSection(out, "api_keys", c.APIKeys, func(key string) string { return strconv.Quote(maskSecret(key)) })
+
+	for _, name := range sortedKeys(c.Profiles) {
+		fmt.Fprintf(out, "\n[profiles.%s]\n", quoteKey(name))
+		c.Profiles[name].write(out)
+	}
+}
+
+// Writes the keys a profile sets
+func (p profile) write(out io.Writer) {
+	if p.Location != "" {
+		fmt.Fprintf(out, "location = %s\n", strconv.Quote(p.Location))
+	}
+	if p.Units != "" {
+		fmt.Fprintf(out, "units = %s\n", strconv.Quote(p.Units))
+	}
+	if p.Wind != "" {
+		fmt.Fprintf(out, "wind = %s\n", strconv.Quote(p.Wind))
+	}
+	if p.Pressure != "" {
+		fmt.Fprintf(out, "pressure = %s\n", strconv.Quote(p.Pressure))
+	}
+	if len(p.Providers) > 0 {
+		fmt.Fprintf(out, "providers = [%s]\n", quoteList(p.Providers))
+	}
+	if p.Style != "" {
+		fmt.Fprintf(out, "style = %s\n", strconv.Quote(p.Style))
+	}
+	if p.TimeFormat != "" {
+		fmt.Fprintf(out, "time_format = %s\n", strconv.Quote(p.TimeFormat))
+	}
+	if p.Language != "" {
+		fmt.Fprintf(out, "lang = %s\n", strconv.Quote(p.Language))
+	}
+	if p.WearOffset != "" {
+		fmt.Fprintf(out, "wear_offset = %s\n", p.WearOffset)
+	}
+	if p.CommuteDuration != "" {
+		fmt.Fprintf(out, "commute_duration = %s\n", strconv.Quote(p.CommuteDuration))
+	}
+	if p.CommuteBetween != "" {
+		fmt.Fprintf(out, "commute_between = %s\n", strconv.Quote(p.CommuteBetween))
+	}
+	if p.GDDBase != "" {
+		fmt.Fprintf(out, "gdd_base = %s\n", p.GDDBase)
+	}
+	if p.DegreeDayBase != "" {
+		fmt.Fprintf(out, "degree_day_base = %s\n", p.DegreeDayBase)
+	}
+}
+
+// Writes a [section] with its keys sorted, skipping empty sections
+func writeSection[T any](out io.Writer, name string, values map[string]T, format func(T) string) {
+	if len(values) == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "\n[%s]\n", name)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(out, "%s = %s\n", quoteKey(key), format(values[key]))
+	}
+}
+
+// Hides all but the last four characters of a secret
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+
+	return strings.Repeat("*", len(secret)-4) + secret[len(secret)-4:]
+}
+
+// Keys of a map in sorted order, so output doesn't change between runs
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Quotes a key unless it is a bare TOML key
+func quoteKey(key string) string {
+	for _, char := range key {
+		if !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || char == '_' || char == '-') {
+			return strconv.Quote(key)
+		}
+	}
+
+	return key
+}
+
+// Formats items as the inside of a TOML array of strings
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for index, item := range items {
+		quoted[index] = strconv.Quote(item)
+	}
+
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A profile whose name needs quoting comes back under the same name after
+// being written and loaded again
+func TestProfileRoundTrip(t *testing.T) {
+	written := config{Profiles: map[string]profile{
+		"my boat": {Location: "61.2,-149.9", Units: "metric"},
+		"home":    {Units: "imperial"},
+	}}
+
+	var out bytes.Buffer
+	written.write(&out, "test")
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loading the written config: %v\n%s", err, out.String())
+	}
+
+	for name, want := range written.Profiles {
+		got, found := loaded.Profiles[name]
+		if !found {
+			t.Errorf("profile %q missing after the round trip, have %q", name, sortedKeys(loaded.Profiles))
+			continue
+		}
+		if got.Location != want.Location || got.Units != want.Units {
+			t.Errorf("profile %q = %+v, want %+v", name, got, want)
+		}
+	}
+
+	if _, err := loaded.effective("my boat"); err != nil {
+		t.Errorf("effective(%q): %v", "my boat", err)
+	}
+}
+
+// The default providers are shown commented out, so saving the output
+// doesn't pin them
+func TestDefaultProvidersNotPinned(t *testing.T) {
+	var out bytes.Buffer
+	config{}.write(&out, "test")
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loading the written config: %v\n%s", err, out.String())
+	}
+	if len(loaded.Defaults.Providers) != 0 {
+		t.Errorf("providers = %q after the round trip, want none so the default applies", loaded.Defaults.Providers)
+	}
+}
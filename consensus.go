@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A current-conditions value compared across providers
+type consensusField struct {
+	Label  string
+	Unit   func(displayOptions) string
+	Format string
+	Value  func(conditions, displayOptions) float64
+}
+
+var consensusFields = []consensusField{
+	{"Temperature", temperatureUnit, "%.1f", func(c conditions, _ displayOptions) float64 { return c.Temp }},
+	{"Feels like", temperatureUnit, "%.1f", func(c conditions, _ displayOptions) float64 { return c.FeelsLike }},
+	{"Dew point", temperatureUnit, "%.1f", func(c conditions, _ displayOptions) float64 { return c.DewPoint }},
+	{"Humidity", fixedUnit("%"), "%.0f", func(c conditions, _ displayOptions) float64 { return float64(c.Humidity) }},
+	{"Pressure", pressureUnitOf, "%.4g", func(c conditions, options displayOptions) float64 {
+		return options.Pressure.fromHectopascals(float64(c.Pressure))
+	}},
+	{"Clouds", fixedUnit("%"), "%.0f", func(c conditions, _ displayOptions) float64 { return float64(c.Clouds) }},
+	{"UV index", fixedUnit(""), "%.1f", func(c conditions, _ displayOptions) float64 { return c.UVI }},
+	{"Wind speed", speedUnit, "%.1f", windValue(func(c conditions) float64 { return c.WindSpeed })},
+	{"Wind gust", speedUnit, "%.1f", windValue(func(c conditions) float64 { return c.WindGust })},
+}
+
+func temperatureUnit(options displayOptions) string { return options.Units.temperature() }
+func speedUnit(options displayOptions) string       { return " " + options.Wind.symbol() }
+func pressureUnitOf(options displayOptions) string  { return " " + string(options.Pressure) }
+func fixedUnit(unit string) func(displayOptions) string {
+	return func(displayOptions) string { return unit }
+}
+
+// Reads a wind speed in the chosen wind unit
+func windValue(speed func(conditions) float64) func(conditions, displayOptions) float64 {
+	return func(c conditions, options displayOptions) float64 {
+		return options.Wind.fromMetersPerSecond(options.Units.toMetersPerSecond(speed(c)))
+	}
+}
+
+// Mean and range (max - min) of a set of values
+func meanSpread(values []float64) (float64, float64) {
+	sum, lowest, highest := 0.0, math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		sum += value
+		lowest = math.Min(lowest, value)
+		highest = math.Max(highest, value)
+	}
+
+	return sum / float64(len(values)), highest - lowest
+}
+
+// Circular mean of directions in degrees and the widest angle between any two
+func meanDirection(degrees []float64) (float64, float64) {
+	var sin, cos float64
+	for _, degree := range degrees {
+		sin += math.Sin(degree * math.Pi / 180)
+		cos += math.Cos(degree * math.Pi / 180)
+	}
+
+	mean := math.Mod(math.Atan2(sin, cos)*180/math.Pi+360, 360)
+
+	spread := 0.0
+	for _, a := range degrees {
+		for _, b := range degrees {
+			difference := math.Abs(math.Mod(a-b+540, 360) - 180)
+			spread = math.Max(spread, difference)
+		}
+	}
+
+	return mean, spread
+}
+
+// Queries every active provider at once and prints the averaged forecast
+// along with the spread between providers for each field
+func runConsensus(ctx context.Context, target location, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	results := runPool("Querying providers", activeProviders, func(name string) string { return name }, func(name string) (weatherData, error) {
+		return weatherProviders[name](ctx, target.Coord, options.Units)
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var names []string
+	var data []weatherData
+	for index, result := range results {
+		if result.Err == nil {
+			names = append(names, activeProviders[index])
+			data = append(data, result.Value)
+		}
+	}
+
+	if len(data) == 0 {
+		return errors.New("no provider returned weather")
+	}
+
+	place := target.CompactName
+	if place == "" {
+		place = fmt.Sprintf("%.4f, %.4f", target.Coord.Lat, target.Coord.Lon)
+	}
+	fmt.Printf("\n"+tr("Consensus for %s from %s")+"\n\n", place, strings.Join(names, ", "))
+
+	header := append(append([]string{""}, names...), tr("Mean"), tr("Spread"))
+	rows := [][]string{header}
+
+	descriptions := []string{tr("Condition")}
+	for _, weather := range data {
+		condition := weather.Current.Condition
+		descriptions = append(descriptions, condition.emoji()+" "+condition.Description)
+	}
+	rows = append(rows, descriptions)
+
+	for _, field := range consensusFields {
+		unit := field.Unit(options)
+		row := []string{tr(field.Label)}
+
+		var values []float64
+		for _, weather := range data {
+			value := field.Value(weather.Current, options)
+			values = append(values, value)
+			row = append(row, fmt.Sprintf(field.Format, value)+unit)
+		}
+
+		mean, spread := meanSpread(values)
+		rows = append(rows, append(row, fmt.Sprintf(field.Format, mean)+unit, fmt.Sprintf("±"+field.Format, spread/2)+unit))
+	}
+
+	directions := []string{tr("Wind direction")}
+	var degrees []float64
+	for _, weather := range data {
+		degrees = append(degrees, float64(weather.Current.WindDeg))
+		directions = append(directions, compassDirection(weather.Current.WindDeg))
+	}
+	mean, spread := meanDirection(degrees)
+	rows = append(rows, append(directions, compassDirection(int64(mean+0.5)), fmt.Sprintf("±%.0f°", spread/2)))
+
+	printTable(os.Stdout, rows)
+
+	printDailyConsensus(data, options)
+
+	return nil
+}
+
+// Averages the daily highs, lows and rain chances of dates every provider covers
+func printDailyConsensus(data []weatherData, options displayOptions) {
+	type dayValues struct{ highs, lows, pops []float64 }
+	days := map[string]*dayValues{}
+
+	for _, weather := range data {
+		for _, day := range weather.Daily {
+			date := day.Date.Format(time.DateOnly)
+			if days[date] == nil {
+				days[date] = &dayValues{}
+			}
+
+			days[date].highs = append(days[date].highs, day.TempMax)
+			days[date].lows = append(days[date].lows, day.TempMin)
+			days[date].pops = append(days[date].pops, day.Pop*100)
+		}
+	}
+
+	var dates []string
+	for date, values := range days {
+		if len(values.highs) == len(data) {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+
+	if len(dates) == 0 {
+		return
+	}
+
+	symbol := options.Units.temperature()
+	rows := [][]string{translated("Date", "High", "Low", "Rain chance")}
+
+	for _, date := range dates {
+		values := days[date]
+		high, highSpread := meanSpread(values.highs)
+		low, lowSpread := meanSpread(values.lows)
+		pop, popSpread := meanSpread(values.pops)
+
+		rows = append(rows, []string{
+			date,
+			fmt.Sprintf("%.1f%s ±%.1f", high, symbol, highSpread/2),
+			fmt.Sprintf("%.1f%s ±%.1f", low, symbol, lowSpread/2),
+			fmt.Sprintf("%.0f%% ±%.0f", pop, popSpread/2),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Words for a number of days from today
+var relativeDays = map[string]int{
+	"today":                0,
+	"tonight":              0,
+	"tomorrow":             1,
+	"tmrw":                 1,
+	"day after tomorrow":   2,
+	"overmorrow":           2,
+	"yesterday":            -1,
+	"day before yesterday": -2,
+}
+
+// Numbers spelled out in phrases like "in three days"
+var spelledNumbers = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "fourteen": 14,
+}
+
+// Weekday named by a word such as "fri" or "friday"
+func parseWeekday(word string) (time.Weekday, bool) {
+	word = strings.TrimSuffix(strings.ToLower(word), "s") // "fridays"
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if len(word) >= 3 && strings.HasPrefix(name, word) {
+			return day, true
+		}
+	}
+
+	return 0, false
+}
+
+// Local midnight of the day a phrase such as "tomorrow", "friday", "next
+// monday", "in 3 days" or 2025-07-14 means, seen from now
+func parseDay(phrase string, now time.Time) (time.Time, error) {
+	text := strings.Join(strings.Fields(strings.ToLower(phrase)), " ")
+	year, month, date := now.Date()
+	today := time.Date(year, month, date, 0, 0, 0, 0, now.Location())
+
+	if offset, found := relativeDays[text]; found {
+		return today.AddDate(0, 0, offset), nil
+	}
+
+	if day, err := time.ParseInLocation("2006-01-02", text, now.Location()); err == nil {
+		return day, nil
+	}
+
+	words := strings.Fields(text)
+
+	// "in 3 days", "in a week", "3 days from now"
+	if len(words) >= 2 {
+		count := words
+		if count[0] == "in" {
+			count = count[1:]
+		}
+		count = trimWords(count, "from", "now")
+
+		if len(count) == 2 {
+			number, err := strconv.Atoi(count[0])
+			if err != nil {
+				var spelled bool
+				if number, spelled = spelledNumbers[count[0]]; !spelled {
+					number = -1
+				}
+			}
+
+			if number >= 0 {
+				switch strings.TrimSuffix(count[1], "s") {
+				case "day":
+					return today.AddDate(0, 0, number), nil
+				case "week":
+					return today.AddDate(0, 0, 7*number), nil
+				}
+			}
+		}
+	}
+
+	// "friday", "this friday", "next friday", "on friday"
+	next := false
+	switch {
+	case len(words) == 2 && (words[0] == "this" || words[0] == "on" || words[0] == "coming"):
+		words = words[1:]
+	case len(words) == 2 && words[0] == "next":
+		next = true
+		words = words[1:]
+	}
+
+	if len(words) == 1 {
+		if weekday, ok := parseWeekday(words[0]); ok {
+			ahead := (int(weekday) - int(now.Weekday()) + 7) % 7
+			// "next friday" on a Friday means in a week, not today
+			if next && ahead == 0 {
+				ahead = 7
+			}
+
+			return today.AddDate(0, 0, ahead), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("can't tell which day %q is, try tomorrow, friday, \"in 3 days\" or 2025-07-14", phrase)
+}
+
+// Drops the given words from the end of a phrase, in that order
+func trimWords(words []string, suffix ...string) []string {
+	if len(words) < len(suffix) {
+		return words
+	}
+
+	tail := words[len(words)-len(suffix):]
+	for index, word := range suffix {
+		if tail[index] != word {
+			return words
+		}
+	}
+
+	return words[:len(words)-len(suffix)]
+}
+
+// Whether two moments fall on the same calendar date, each in its own zone
+func sameDate(a time.Time, b time.Time) bool {
+	aYear, aMonth, aDay := a.Date()
+	bYear, bMonth, bDay := b.Date()
+
+	return aYear == bYear && aMonth == bMonth && aDay == bDay
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Base temperatures of heating and cooling degree days, the 18°C of Europe's
+// statistics and the 65°F of American utilities
+const (
+	DEFAULT_DEGREE_DAY_BASE_C = 18.0
+	DEFAULT_DEGREE_DAY_BASE_F = 65.0
+)
+
+// Base of the heating and cooling degree days in the temperature unit shown,
+// from -base, WEATHER_DEGREE_DAY_BASE or degree_day_base; NaN for the default
+// of the units
+var degreeDayBase = math.NaN()
+
+// Past days `weather degreedays` counts by default, and the most it takes
+const (
+	DEFAULT_DEGREE_DAY_PAST_DAYS = 7
+	MAX_DEGREE_DAY_PAST_DAYS     = 366
+)
+
+// Heating and cooling degree days of a day from its mean temperature, all in
+// the same unit
+func heatingCoolingDegreeDays(low float64, high float64, base float64) (float64, float64) {
+	mean := (low + high) / 2
+
+	return max(base-mean, 0), max(mean-base, 0)
+}
+
+// Implements `weather degreedays`, the heating and cooling degree days of the
+// past days from the stored history and the archive, and of the forecast, for
+// tracking energy use and sizing heating and air conditioning
+func runDegreeDays(ctx context.Context, target location, days int, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	now := weather.Current.Time.In(time.FixedZone(weather.Timezone, weather.Offset))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Degree days are counted in the unit shown, from a base given in it
+	base := DEFAULT_DEGREE_DAY_BASE_C
+	if options.Units == IMPERIAL {
+		base = IMPERIAL.toCelsius(DEFAULT_DEGREE_DAY_BASE_F)
+	}
+	if !math.IsNaN(degreeDayBase) {
+		base = options.Units.toCelsius(degreeDayBase)
+	}
+	degrees := func(celsius float64) string {
+		return fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+	}
+
+	season, err := seasonTemperatures(ctx, result, today.AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+
+	var past, ahead [2]float64
+	var last time.Time
+	rows := [][]string{translated("Day", "Low", "High", "Mean", "HDD", "CDD", "Source")}
+	for _, day := range season {
+		low, high := options.Units.fromCelsius(day.Min), options.Units.fromCelsius(day.Max)
+		heating, cooling := heatingCoolingDegreeDays(low, high, options.Units.fromCelsius(base))
+		if day.Source == FORECAST_SOURCE {
+			ahead[0], ahead[1] = ahead[0]+heating, ahead[1]+cooling
+			last = day.Date
+		} else {
+			past[0], past[1] = past[0]+heating, past[1]+cooling
+		}
+
+		rows = append(rows, []string{
+			day.Date.Format("Mon Jan 2"),
+			degrees(day.Min),
+			degrees(day.Max),
+			degrees((day.Min + day.Max) / 2),
+			fmt.Sprintf("%.1f", heating),
+			fmt.Sprintf("%.1f", cooling),
+			tr(day.Source),
+		})
+	}
+
+	fmt.Printf(tr("Heating and cooling degree days from %s")+"\n", degrees(base))
+	if days > 0 {
+		fmt.Printf(tr("Past %d days: %.0f heating, %.0f cooling")+"\n", days, past[0], past[1])
+	}
+	if !last.IsZero() {
+		fmt.Printf(tr("Forecast to %s: %.0f heating, %.0f cooling")+"\n", last.Format("Mon Jan 2"), ahead[0], ahead[1])
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// One line description used as the NAME of the man pages
+const PROGRAM_DESCRIPTION = "know the weather from your command-line"
+
+// The documentation commands read the command table, so like the completion
+// commands they are added to it here
+func init() {
+	commands = append(commands,
+		command{
+			Name:    "gen-man",
+			Summary: "Write roff man pages for weather and each command, for packagers",
+			Setup: func(flags *flag.FlagSet) commandRunner {
+				dir := flags.String("dir", ".", "Directory the pages are written to")
+
+				return func(ctx context.Context, args []string, units string) error {
+					return writeManPages(*dir)
+				}
+			},
+		},
+		command{
+			Name:    "gen-docs",
+			Summary: "Print a Markdown reference of every command and flag",
+			Setup: func(flags *flag.FlagSet) commandRunner {
+				return func(ctx context.Context, args []string, units string) error {
+					writeMarkdownReference(os.Stdout)
+					return nil
+				}
+			},
+		},
+	)
+}
+
+// A flag as it appears in the documentation
+type documentedFlag struct {
+	Name    string // With the leading dash
+	Arg     string // Kind of value taken, empty for switches
+	Usage   string
+	Default string // Empty when the default is the zero value
+}
+
+// Flags of a set in the order they are listed
+func documentFlags(flags *flag.FlagSet) []documentedFlag {
+	var documented []documentedFlag
+	flags.VisitAll(func(f *flag.Flag) {
+		arg, usage := flag.UnquoteUsage(f)
+
+		entry := documentedFlag{Name: "-" + f.Name, Arg: arg, Usage: usage}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			entry.Default = f.DefValue
+		}
+
+		documented = append(documented, entry)
+	})
+
+	return documented
+}
+
+// Global flags with their built in defaults rather than whatever was given
+// on this command line, and without the machine specific config path
+func documentedGlobalFlags() []documentedFlag {
+	flags := flag.NewFlagSet("weather", flag.ContinueOnError)
+
+	defaults := globalFlags{}
+	defaults.register(flags)
+
+	return documentFlags(flags)
+}
+
+// Date printed in the man pages. SOURCE_DATE_EPOCH keeps package builds
+// reproducible.
+func documentationDate() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+
+	return time.Now()
+}
+
+// Escapes text for roff, so dashes stay dashes and lines can't turn into
+// requests
+func roffEscape(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+
+	return text
+}
+
+// Writes the flags as a roff tagged list
+func writeRoffFlags(out io.Writer, flags []documentedFlag) {
+	for _, f := range flags {
+		if f.Arg != "" {
+			fmt.Fprintf(out, ".TP\n.BI %s \" %s\"\n", roffEscape(f.Name), roffEscape(f.Arg))
+		} else {
+			fmt.Fprintf(out, ".TP\n.B %s\n", roffEscape(f.Name))
+		}
+
+		usage := f.Usage
+		if f.Default != "" {
+			usage += " (default " + f.Default + ")"
+		}
+		fmt.Fprintln(out, roffEscape(usage))
+	}
+}
+
+// Name of a command's man page, without the section
+func manPageName(cmd command) string {
+	return "weather-" + cmd.Name
+}
+
+// Writes the main weather(1) page
+func writeMainManPage(out io.Writer, date time.Time) {
+	fmt.Fprintf(out, ".TH WEATHER 1 %q %q \"User Commands\"\n", date.Format("2006-01-02"), "weather "+version)
+
+	fmt.Fprintf(out, ".SH NAME\nweather \\- %s\n", roffEscape(PROGRAM_DESCRIPTION))
+
+	fmt.Fprintln(out, ".SH SYNOPSIS")
+	fmt.Fprintln(out, ".B weather\n[\\fIflags\\fR] \\fIcommand\\fR [\\fIcommand flags\\fR] [\\fIarguments\\fR]")
+	fmt.Fprintln(out, ".br\n.B weather\n[\\fIflags\\fR] [\\fIlocation\\fR ...]")
+
+	fmt.Fprintln(out, ".SH DESCRIPTION")
+	fmt.Fprintln(out, roffEscape("Shows current weather, forecasts and alerts from several providers. Locations are place names, aliases from the config file, last for the most recently used place, or coordinates such as 40.4461,-79.9822 or 40°26'46\"N 79°58'56\"W. Without a command the arguments are locations for weather now."))
+
+	fmt.Fprintln(out, ".SH COMMANDS")
+	for _, cmd := range visibleCommands() {
+		fmt.Fprintf(out, ".TP\n.B %s\n%s, see\n.BR %s (1).\n", roffEscape(cmd.Name), roffEscape(cmd.Summary), roffEscape(manPageName(cmd)))
+	}
+
+	fmt.Fprintln(out, ".SH GLOBAL FLAGS")
+	fmt.Fprintln(out, "Accepted before or after the command.")
+	writeRoffFlags(out, documentedGlobalFlags())
+
+	fmt.Fprintln(out, ".SH ENVIRONMENT")
+	for _, setting := range environmentSettings {
+		fmt.Fprintf(out, ".TP\n.B %s\n%s\n", roffEscape(setting.Name), roffEscape(setting.Meaning))
+	}
+
+	fmt.Fprintln(out, ".SH FILES")
+	fmt.Fprintf(out, ".TP\n.I %s\n%s\n", roffEscape("~/.config/weather/config.toml"), roffEscape("Settings, created with weather config init. The directory follows the platform's config location."))
+	fmt.Fprintf(out, ".TP\n.I %s\n%s\n", roffEscape("~/.local/share/weather/"), roffEscape("History, the last used location, update checks and the city list from weather cities update, or $XDG_DATA_HOME/weather/ when set."))
+
+	fmt.Fprintln(out, ".SH SEE ALSO")
+	for index, cmd := range visibleCommands() {
+		separator := ","
+		if index == len(visibleCommands())-1 {
+			separator = ""
+		}
+		fmt.Fprintf(out, ".BR %s (1)%s\n", roffEscape(manPageName(cmd)), separator)
+	}
+}
+
+// Writes the page of one command
+func writeCommandManPage(out io.Writer, cmd command, date time.Time) {
+	name := manPageName(cmd)
+
+	fmt.Fprintf(out, ".TH %s 1 %q %q \"User Commands\"\n", strings.ToUpper(roffEscape(name)), date.Format("2006-01-02"), "weather "+version)
+
+	fmt.Fprintf(out, ".SH NAME\n%s \\- %s\n", roffEscape(name), roffEscape(strings.ToLower(cmd.Summary[:1])+cmd.Summary[1:]))
+
+	fmt.Fprintln(out, ".SH SYNOPSIS")
+	fmt.Fprintf(out, ".B weather %s\n[\\fIflags\\fR]", roffEscape(cmd.Name))
+	if cmd.Args != "" {
+		fmt.Fprintf(out, " \\fI%s\\fR", roffEscape(cmd.Args))
+	}
+	fmt.Fprintln(out)
+
+	fmt.Fprintf(out, ".SH DESCRIPTION\n%s.\n", roffEscape(cmd.Summary))
+	if cmd.takesLocations() {
+		fmt.Fprintln(out, roffEscape("Every argument is part of the location, so names with spaces need no quotes."))
+	}
+
+	if flags := documentFlags(cmd.ownFlags()); len(flags) > 0 {
+		fmt.Fprintln(out, ".SH OPTIONS")
+		writeRoffFlags(out, flags)
+	}
+
+	fmt.Fprintln(out, ".SH SEE ALSO")
+	fmt.Fprintln(out, ".BR weather (1)")
+}
+
+// Implements `weather gen-man`, writing weather.1 and a page per command
+func writeManPages(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	date := documentationDate()
+
+	write := func(name string, render func(out io.Writer)) error {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+
+		render(file)
+
+		return file.Close()
+	}
+
+	pages := []string{"weather.1"}
+	err := write("weather.1", func(out io.Writer) { writeMainManPage(out, date) })
+
+	for _, cmd := range visibleCommands() {
+		if err != nil {
+			break
+		}
+
+		name := manPageName(cmd) + ".1"
+		pages = append(pages, name)
+		err = write(name, func(out io.Writer) { writeCommandManPage(out, cmd, date) })
+	}
+	if err != nil {
+		return fmt.Errorf("writing man pages: %w", err)
+	}
+
+	status(fmt.Sprintf("[+] Wrote %d man pages to %s", len(pages), dir))
+
+	return nil
+}
+
+// Writes flags as a Markdown table
+func writeMarkdownFlags(out io.Writer, flags []documentedFlag) {
+	escape := strings.NewReplacer("|", `\|`).Replace
+
+	fmt.Fprintln(out, "| Flag | Description | Default |")
+	fmt.Fprintln(out, "| --- | --- | --- |")
+	for _, f := range flags {
+		name := "`" + f.Name
+		if f.Arg != "" {
+			name += " " + f.Arg
+		}
+		name += "`"
+
+		defaultValue := ""
+		if f.Default != "" {
+			defaultValue = "`" + f.Default + "`"
+		}
+
+		fmt.Fprintf(out, "| %s | %s | %s |\n", name, escape(f.Usage), defaultValue)
+	}
+}
+
+// Implements `weather gen-docs`
+func writeMarkdownReference(out io.Writer) {
+	fmt.Fprintf(out, "# weather reference\n\n")
+	fmt.Fprintf(out, "Generated by `weather gen-docs` from weather %s.\n\n", version)
+	fmt.Fprintf(out, "```\nweather [flags] <command> [command flags] [arguments]\nweather [flags] [location ...]  (same as weather now)\n```\n\n")
+	fmt.Fprintf(out, "Locations are place names, aliases from the config file, `last` for the most recently used place, or coordinates such as `40.4461,-79.9822` or `40°26'46\"N 79°58'56\"W`.\n\n")
+
+	fmt.Fprintf(out, "## Commands\n\n")
+	for _, cmd := range visibleCommands() {
+		fmt.Fprintf(out, "- [`%s`](#weather-%s): %s\n", cmd.Name, cmd.Name, cmd.Summary)
+	}
+
+	fmt.Fprintf(out, "\n## Global flags\n\nAccepted before or after the command.\n\n")
+	writeMarkdownFlags(out, documentedGlobalFlags())
+
+	fmt.Fprintf(out, "\n## Environment\n\n| Variable | Meaning |\n| --- | --- |\n")
+	for _, setting := range environmentSettings {
+		fmt.Fprintf(out, "| `%s` | %s |\n", setting.Name, setting.Meaning)
+	}
+
+	for _, cmd := range visibleCommands() {
+		fmt.Fprintf(out, "\n## weather %s\n\n", cmd.Name)
+
+		usage := "weather " + cmd.Name + " [flags]"
+		if cmd.Args != "" {
+			usage += " " + cmd.Args
+		}
+		fmt.Fprintf(out, "```\n%s\n```\n\n%s.\n", usage, cmd.Summary)
+
+		if flags := documentFlags(cmd.ownFlags()); len(flags) > 0 {
+			fmt.Fprintln(out)
+			writeMarkdownFlags(out, flags)
+		}
+	}
+}
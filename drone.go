@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Observed and forecast planetary K index of NOAA's Space Weather Prediction
+// Center, in three hour blocks
+const SWPC_KP_FORECAST_URL = "https://services.swpc.noaa.gov/products/noaa-planetary-k-index-forecast.json"
+
+// Hourly values `weather drone` fetches from Open-Meteo, always in metric:
+// winds in m/s at 10, 80 and 120 m, the usual ceiling of hobby drones
+const OPEN_METEO_DRONE_HOURLY = "temperature_2m,precipitation,precipitation_probability,visibility,wind_speed_10m,wind_speed_80m,wind_speed_120m,wind_gusts_10m"
+
+// Default wind a small drone holds its position in, in km/h, or mph with
+// imperial units
+const DEFAULT_DRONE_MAX_WIND = 36
+
+// Default and most hours `weather drone` rates
+const (
+	DEFAULT_DRONE_HOURS = 6
+	MAX_DRONE_HOURS     = 24
+)
+
+// Share of the wind limit from which flying calls for care
+const DRONE_WIND_CAUTION = 0.7
+
+// Limits of a safe flight within sight
+const (
+	DRONE_RAIN_MM            = 0.1
+	DRONE_RAIN_CHANCE        = 40
+	DRONE_VISIBILITY_NO_GO_M = 1000
+	DRONE_VISIBILITY_M       = 5000
+	DRONE_COLD_C             = 0.0
+	DRONE_HOT_C              = 40.0
+)
+
+// Geomagnetic storms from this K index degrade GPS, and from the lower one
+// the compass and GPS may need watching
+const (
+	DRONE_KP_NO_GO   = 5.0
+	DRONE_KP_CAUTION = 4.0
+)
+
+// Exit statuses of `weather drone` other than 0 for go, kept apart from the
+// 1 of errors
+const (
+	DRONE_NO_GO_EXIT_STATUS   = 2
+	DRONE_CAUTION_EXIT_STATUS = 3
+)
+
+// How safe flying is, worst last
+type droneVerdict int
+
+const (
+	DRONE_GO droneVerdict = iota
+	DRONE_CAUTION
+	DRONE_NO_GO
+)
+
+// Names of the verdicts
+var droneVerdictNames = map[droneVerdict]string{DRONE_GO: "GO", DRONE_CAUTION: "CAUTION", DRONE_NO_GO: "NO-GO"}
+
+// Hourly weather near the ground from Open-Meteo
+type openMeteoDrone struct {
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+	Hourly           struct {
+		Time          []int64   `json:"time"`
+		Temperature   []float64 `json:"temperature_2m"`
+		Precipitation []float64 `json:"precipitation"`
+		RainChance    []float64 `json:"precipitation_probability"`
+		Visibility    []float64 `json:"visibility"`
+		Wind10m       []float64 `json:"wind_speed_10m"`
+		Wind80m       []float64 `json:"wind_speed_80m"`
+		Wind120m      []float64 `json:"wind_speed_120m"`
+		Gust10m       []float64 `json:"wind_gusts_10m"`
+	} `json:"hourly"`
+}
+
+// Fetches the weather at drone heights for today and tomorrow
+func fetchDroneWeather(ctx context.Context, at coordinate) (openMeteoDrone, error) {
+	status("[@] Fetching the weather at drone heights from Open-Meteo")
+
+	query := openMeteoQuery(at, METRIC)
+	query.Set("hourly", OPEN_METEO_DRONE_HOURLY)
+	query.Set("forecast_days", "2")
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return openMeteoDrone{}, fmt.Errorf("fetching the weather at drone heights from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoDrone
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return openMeteoDrone{}, fmt.Errorf("parsing the Open-Meteo weather at drone heights: %w", err)
+	}
+
+	return parsed, nil
+}
+
+// Formats a visibility given in meters, in miles with imperial units
+func formatVisibility(meters float64, units unitSystem) string {
+	switch {
+	case units == IMPERIAL:
+		return fmt.Sprintf("%.1f mi", meters/1609.344)
+	case meters < 5000:
+		return fmt.Sprintf("%.0f m", meters)
+	}
+
+	return fmt.Sprintf("%.0f km", meters/1000)
+}
+
+// One three hour block of the K index
+type kpBlock struct {
+	Start time.Time
+	Kp    float64
+}
+
+// Fetches the K index of the past days and the coming ones. The feed is a
+// table of strings whose first row names the columns.
+func fetchKpIndex(ctx context.Context) ([]kpBlock, error) {
+	status("[@] Fetching the K index from NOAA SWPC")
+
+	body, err := fetch(ctx, SWPC_KP_FORECAST_URL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching the K index from NOAA SWPC: %w", err)
+	}
+
+	var table [][]any
+	if err := json.Unmarshal(body, &table); err != nil {
+		return nil, fmt.Errorf("parsing the NOAA SWPC K index: %w", err)
+	}
+
+	var blocks []kpBlock
+	for _, row := range table {
+		if len(row) < 2 {
+			continue
+		}
+
+		start, timeErr := time.Parse("2006-01-02 15:04:05", fmt.Sprint(row[0]))
+		kp, kpErr := strconv.ParseFloat(fmt.Sprint(row[1]), 64)
+		if timeErr != nil || kpErr != nil {
+			continue
+		}
+		blocks = append(blocks, kpBlock{Start: start, Kp: kp})
+	}
+
+	if len(blocks) == 0 {
+		return nil, errors.New("NOAA SWPC sent no K index")
+	}
+
+	return blocks, nil
+}
+
+// The K index of the block a moment falls in, NaN when none covers it
+func kpAt(blocks []kpBlock, moment time.Time) float64 {
+	kp := math.NaN()
+	for _, block := range blocks {
+		if !block.Start.After(moment) && moment.Before(block.Start.Add(3*time.Hour)) {
+			kp = block.Kp
+		}
+	}
+
+	return kp
+}
+
+// The verdict on one hour and the reasons for it, worst first. The wind
+// limit is in m/s.
+func (d openMeteoDrone) verdict(index int, kp float64, maxWind float64, options displayOptions) (droneVerdict, []string) {
+	hourly := d.Hourly
+	type finding struct {
+		Verdict droneVerdict
+		Reason  string
+	}
+	var findings []finding
+	check := func(verdict droneVerdict, reason string) {
+		findings = append(findings, finding{verdict, reason})
+	}
+
+	// Only the gusts near the ground are forecast; aloft they are taken to
+	// blow as far above the mean wind
+	wind120 := valueAt(hourly.Wind120m, index)
+	gust := max(valueAt(hourly.Gust10m, index), wind120+valueAt(hourly.Gust10m, index)-valueAt(hourly.Wind10m, index))
+	switch wind := max(wind120, valueAt(hourly.Wind80m, index)); {
+	case wind >= maxWind:
+		check(DRONE_NO_GO, fmt.Sprintf(tr("wind %s at 120 m, over the limit of %s"), options.Wind.format(wind, 0), options.Wind.format(maxWind, 0)))
+	case gust >= maxWind:
+		check(DRONE_NO_GO, fmt.Sprintf(tr("gusts %s aloft, over the limit of %s"), options.Wind.format(gust, 0), options.Wind.format(maxWind, 0)))
+	case gust >= maxWind*DRONE_WIND_CAUTION:
+		check(DRONE_CAUTION, fmt.Sprintf(tr("gusts %s aloft, near the limit of %s"), options.Wind.format(gust, 0), options.Wind.format(maxWind, 0)))
+	}
+
+	rain := valueAt(hourly.Precipitation, index)
+	switch chance := valueAt(hourly.RainChance, index); {
+	case rain >= DRONE_RAIN_MM:
+		check(DRONE_NO_GO, fmt.Sprintf(tr("%s of rain"), options.Units.formatPrecipitation(options.Units.fromMillimeters(rain))))
+	case chance >= DRONE_RAIN_CHANCE:
+		check(DRONE_CAUTION, fmt.Sprintf(tr("%.0f%% chance of rain"), chance))
+	}
+
+	// Zero is a missing visibility rather than a wall of fog
+	switch visibility := valueAt(hourly.Visibility, index); {
+	case visibility <= 0:
+	case visibility < DRONE_VISIBILITY_NO_GO_M:
+		check(DRONE_NO_GO, fmt.Sprintf(tr("visibility %s, too short to keep the drone in sight"), formatVisibility(visibility, options.Units)))
+	case visibility < DRONE_VISIBILITY_M:
+		check(DRONE_CAUTION, fmt.Sprintf(tr("visibility %s"), formatVisibility(visibility, options.Units)))
+	}
+
+	celsius := valueAt(hourly.Temperature, index)
+	temperature := fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+	switch {
+	case celsius < DRONE_COLD_C:
+		check(DRONE_CAUTION, fmt.Sprintf(tr("%s, batteries drain fast in the cold"), temperature))
+	case celsius > DRONE_HOT_C:
+		check(DRONE_CAUTION, fmt.Sprintf(tr("%s, motors and batteries may overheat"), temperature))
+	}
+
+	switch {
+	case kp >= DRONE_KP_NO_GO:
+		check(DRONE_NO_GO, fmt.Sprintf(tr("geomagnetic storm, K index %.0f, GPS may fail"), kp))
+	case kp >= DRONE_KP_CAUTION:
+		check(DRONE_CAUTION, fmt.Sprintf(tr("K index %.0f, GPS and compass may drift"), kp))
+	}
+
+	verdict := DRONE_GO
+	var reasons []string
+	for _, level := range []droneVerdict{DRONE_NO_GO, DRONE_CAUTION} {
+		for _, found := range findings {
+			if found.Verdict == level {
+				verdict = max(verdict, level)
+				reasons = append(reasons, found.Reason)
+			}
+		}
+	}
+
+	return verdict, reasons
+}
+
+// Implements `weather drone`, a GO, CAUTION or NO-GO verdict with its
+// reasons on flying now and over the coming hours, from the wind up to
+// 120 m, rain, visibility, temperature and the K index. Exits with
+// DRONE_NO_GO_EXIT_STATUS or DRONE_CAUTION_EXIT_STATUS unless it's a go, so
+// scripts can ask with `weather drone && ...`.
+func runDrone(ctx context.Context, target location, maxWind float64, hours int, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	// The limit is given in km/h, or mph with imperial units
+	limit := maxWind / KILOMETERS_PER_HOUR.fromMetersPerSecond(1)
+	if options.Units == IMPERIAL {
+		limit = maxWind / MILES_PER_HOUR.fromMetersPerSecond(1)
+	}
+
+	weather, err := fetchDroneWeather(ctx, target.Coord)
+	if err != nil {
+		return err
+	}
+
+	// The K index is a bonus, the verdict goes out without it
+	blocks, err := fetchKpIndex(ctx)
+	if err != nil {
+		logger.Debug("K index lookup failed", "error", err)
+	}
+
+	zone := time.FixedZone(weather.Timezone, weather.UTCOffsetSeconds)
+	now := time.Now().Truncate(time.Hour)
+	hourly := weather.Hourly
+
+	var upcoming []int
+	for index, unix := range hourly.Time {
+		if moment := time.Unix(unix, 0); !moment.Before(now) && len(upcoming) < hours {
+			upcoming = append(upcoming, index)
+		}
+	}
+	if len(upcoming) == 0 {
+		return errors.New("the forecast at drone heights has no hours ahead")
+	}
+
+	current := upcoming[0]
+	kp := kpAt(blocks, time.Unix(hourly.Time[current], 0))
+	verdict, reasons := weather.verdict(current, kp, limit, options)
+
+	fmt.Printf("%s: %s\n", tr("Now"), tr(droneVerdictNames[verdict]))
+	for _, reason := range reasons {
+		fmt.Println("  - " + reason)
+	}
+
+	printField("Wind", fmt.Sprintf(tr("%s at 10 m, %s at 80 m, %s at 120 m"), options.Wind.format(valueAt(hourly.Wind10m, current), 0),
+		options.Wind.format(valueAt(hourly.Wind80m, current), 0), options.Wind.format(valueAt(hourly.Wind120m, current), 0)))
+	printField("Gusts", options.Wind.format(valueAt(hourly.Gust10m, current), 0))
+	if !math.IsNaN(kp) {
+		printField("K Index", fmt.Sprintf("%.1f", kp))
+	}
+
+	rows := [][]string{translated("Time", "Wind 10 m", "Wind 120 m", "Gusts", "Rain", "Visibility", "Verdict")}
+	for _, index := range upcoming {
+		hourVerdict, _ := weather.verdict(index, kpAt(blocks, time.Unix(hourly.Time[index], 0)), limit, options)
+
+		visibility := "-"
+		if meters := valueAt(hourly.Visibility, index); meters > 0 {
+			visibility = formatVisibility(meters, options.Units)
+		}
+
+		rows = append(rows, []string{
+			unixIn(hourly.Time[index], zone).Format(options.clockFormat()),
+			options.Wind.format(valueAt(hourly.Wind10m, index), 0),
+			options.Wind.format(valueAt(hourly.Wind120m, index), 0),
+			options.Wind.format(valueAt(hourly.Gust10m, index), 0),
+			fmt.Sprintf("%.0f%%", valueAt(hourly.RainChance, index)),
+			visibility,
+			tr(droneVerdictNames[hourVerdict]),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	switch verdict {
+	case DRONE_NO_GO:
+		return exitStatus(DRONE_NO_GO_EXIT_STATUS)
+	case DRONE_CAUTION:
+		return exitStatus(DRONE_CAUTION_EXIT_STATUS)
+	}
+
+	return nil
+}
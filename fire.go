@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Red flag conditions in the spirit of the NWS criteria: dry air with a
+// strong sustained wind or gusts
+const (
+	RED_FLAG_HUMIDITY = 15
+	RED_FLAG_WIND_KMH = 40.0
+	RED_FLAG_GUST_KMH = 56.0
+)
+
+// Days looked back on for rain that keeps fuels moist
+const DRY_SPELL_DAYS = 7
+
+// Less rain than this over the dry spell leaves fuels dry, in millimeters
+const DRY_SPELL_MM = 2.0
+
+// Ratings of the Fosberg index by their lower bounds, highest first
+var fireDangers = []struct {
+	From float64
+	Name string
+}{
+	{75, "extreme"},
+	{50, "very high"},
+	{30, "high"},
+	{15, "moderate"},
+	{0, "low"},
+}
+
+// Fosberg fire weather index from 0 to 100, how readily fine fuels burn and
+// fire spreads given the temperature in °F, humidity and wind in mph
+func fosbergIndex(tempF float64, humidity float64, windMph float64) float64 {
+	// Equilibrium moisture content of the fuels in percent
+	var moisture float64
+	switch {
+	case humidity < 10:
+		moisture = 0.03229 + 0.281073*humidity - 0.000578*humidity*tempF
+	case humidity <= 50:
+		moisture = 2.22749 + 0.160107*humidity - 0.01478*tempF
+	default:
+		moisture = 21.0606 + 0.005565*humidity*humidity - 0.00035*humidity*tempF - 0.483199*humidity
+	}
+
+	ratio := moisture / 30
+	damping := 1 - 2*ratio + 1.5*ratio*ratio - 0.5*ratio*ratio*ratio
+
+	return min(max(damping*math.Sqrt(1+windMph*windMph)/0.3002, 0), 100)
+}
+
+// The Fosberg index of the conditions
+func (c conditions) fireIndex(units unitSystem) float64 {
+	tempF := IMPERIAL.fromCelsius(units.toCelsius(c.Temp))
+	windMph := MILES_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(c.WindSpeed))
+
+	return fosbergIndex(tempF, float64(c.Humidity), windMph)
+}
+
+// Whether the conditions meet the red flag criteria
+func (c conditions) redFlag(units unitSystem) bool {
+	wind := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(c.WindSpeed))
+	gust := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(c.WindGust))
+
+	return c.Humidity > 0 && c.Humidity <= RED_FLAG_HUMIDITY && (wind >= RED_FLAG_WIND_KMH || gust >= RED_FLAG_GUST_KMH)
+}
+
+// The index from which the full report shows the fire danger, that of high
+const FIRE_DANGER_SHOWN = 30
+
+// Rating of a Fosberg index, one step higher after a dry spell
+func fireDanger(index float64, dry bool) string {
+	for step, danger := range fireDangers {
+		if index >= danger.From {
+			if dry && step > 0 {
+				return fireDangers[step-1].Name
+			}
+			return danger.Name
+		}
+	}
+
+	return "low"
+}
+
+// Implements `weather fire`, the fire danger now and at the driest, windiest
+// hour of each coming day, warning of hours that meet the red flag criteria
+func runFire(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	current := weather.Current
+	clock := options.clockFormat()
+
+	// The rain of the past days is a bonus, the ratings go out without it
+	dry := false
+	today := time.Date(current.Time.Year(), current.Time.Month(), current.Time.Day(), 0, 0, 0, 0, current.Time.Location())
+	if past, err := fetchOpenMeteoDays(ctx, weather.Coord, METRIC, today.AddDate(0, 0, -DRY_SPELL_DAYS), today.AddDate(0, 0, -1)); err != nil {
+		logger.Debug("past rain lookup failed", "error", err)
+	} else {
+		rain := 0.0
+		for _, day := range past.Daily {
+			rain += day.Precipitation
+		}
+
+		dry = rain < DRY_SPELL_MM
+		fmt.Printf("%s: %s\n", fmt.Sprintf(tr("Rain in the past %d days"), DRY_SPELL_DAYS), options.Units.formatPrecipitation(options.Units.fromMillimeters(rain)))
+	}
+
+	index := current.fireIndex(options.Units)
+	danger := fireDanger(index, dry)
+	fmt.Printf("%s: %s (%s %.0f)\n", tr("Now"), tr(danger), tr("Fosberg index"), index)
+
+	var warnings []string
+	rows := [][]string{translated("Day", "Peak", "At", "Humidity", "Wind", "Danger")}
+	for _, day := range weather.Daily {
+		var peak, flagged *conditions
+		for position, hour := range weather.Hourly {
+			if !sameDate(hour.Time, day.Date) || hour.Time.Before(current.Time.Truncate(time.Hour)) {
+				continue
+			}
+
+			if peak == nil || hour.fireIndex(options.Units) > peak.fireIndex(options.Units) {
+				peak = &weather.Hourly[position]
+			}
+
+			if flagged == nil && hour.redFlag(options.Units) {
+				flagged = &weather.Hourly[position]
+			}
+		}
+		if peak == nil {
+			continue
+		}
+
+		// Only the first hour of a day that meets the criteria is warned of
+		if flagged != nil {
+			warnings = append(warnings, fmt.Sprintf(tr("Critical fire weather %s from %s: humidity %d%%, wind %s, gusts %s"),
+				day.Date.Format("Mon Jan 2"), flagged.Time.Format(clock), flagged.Humidity, options.wind(flagged.WindSpeed, 0), options.wind(flagged.WindGust, 0)))
+		}
+
+		peakIndex := peak.fireIndex(options.Units)
+		rows = append(rows, []string{
+			day.Date.Format("Mon Jan 2"),
+			fmt.Sprintf("%.0f", peakIndex),
+			peak.Time.Format(clock),
+			fmt.Sprintf("%d%%", peak.Humidity),
+			options.wind(peak.WindSpeed, 0),
+			tr(fireDanger(peakIndex, dry)),
+		})
+	}
+
+	if len(rows) > 1 {
+		fmt.Println()
+		printTable(os.Stdout, rows)
+	}
+
+	if danger == "extreme" || danger == "very high" || current.redFlag(options.Units) {
+		warnings = append([]string{tr("Extreme fire weather right now, avoid anything that could spark a fire outdoors")}, warnings...)
+	}
+	for _, warning := range warnings {
+		fmt.Println("\n⚠️  " + warning)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Length of the solunar periods: the major ones centered on the moon
+// crossing the meridian overhead or underfoot, the minor ones on moonrise
+// and moonset
+const (
+	SOLUNAR_MAJOR = 2 * time.Hour
+	SOLUNAR_MINOR = time.Hour
+)
+
+// Pressure changes over a day, in hPa, from which anglers call it falling
+// or rising
+const FISHING_PRESSURE_CHANGE = 3.0
+
+// Wind in km/h that ripples the water without making fishing a chore
+const (
+	FISHING_BREEZE_KMH = 5.0
+	FISHING_GALE_KMH   = 40.0
+)
+
+// Ratings of a fishing day by the lowest score that earns them, best first
+var fishingRatings = []struct {
+	From int
+	Name string
+}{
+	{3, "excellent"},
+	{2, "good"},
+	{1, "average"},
+}
+
+// One solunar period
+type solunarPeriod struct {
+	From  time.Time
+	To    time.Time
+	Major bool
+}
+
+// The major and minor solunar periods of the local calendar day, in order
+func solunarPeriods(day time.Time, at coordinate) []solunarPeriod {
+	var periods []solunarPeriod
+	around := func(center time.Time, length time.Duration, major bool) {
+		periods = append(periods, solunarPeriod{From: center.Add(-length / 2), To: center.Add(length / 2), Major: major})
+	}
+
+	// Overhead the moon stands highest, underfoot lowest
+	altitude := func(moment time.Time) float64 { return moonAt(moment, at).Altitude }
+	for _, transit := range skyPeaks(day, altitude) {
+		around(transit, SOLUNAR_MAJOR, true)
+	}
+	for _, transit := range skyPeaks(day, func(moment time.Time) float64 { return -altitude(moment) }) {
+		around(transit, SOLUNAR_MAJOR, true)
+	}
+
+	for _, rising := range []bool{true, false} {
+		if moment, ok := moonPasses(day, at, rising); ok {
+			around(moment, SOLUNAR_MINOR, false)
+		}
+	}
+
+	sort.Slice(periods, func(i, j int) bool { return periods[i].From.Before(periods[j].From) })
+
+	return periods
+}
+
+// How much the moon phase helps, most around new and full moon
+func moonFishingScore(moment time.Time) int {
+	// Days from the nearest new or full moon
+	phase := moonPhase(moment)
+	days := math.Abs(phase-math.Round(phase*2)/2) * 29.53
+
+	switch {
+	case days <= 3:
+		return 2
+	case days <= 5.5:
+		return 1
+	}
+
+	return 0
+}
+
+// Implements `weather fishing`, the solunar periods of the coming days with
+// a rating from the moon phase, the pressure trend and the wind
+func runFishing(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	clock := options.clockFormat()
+	span := func(period solunarPeriod) string {
+		return period.From.Format(clock) + "–" + period.To.Format(clock)
+	}
+
+	rows := [][]string{translated("Day", "Major", "Minor", "Moon", "Pressure", "Wind", "Rating")}
+	for _, day := range weather.Daily {
+		var major, minor []string
+		for _, period := range solunarPeriods(day.Date, weather.Coord) {
+			if period.Major {
+				major = append(major, span(period))
+			} else {
+				minor = append(minor, span(period))
+			}
+		}
+
+		// Noon stands for the day's moon
+		noon := time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 12, 0, 0, 0, day.Date.Location())
+		score := moonFishingScore(noon)
+
+		// Falling pressure ahead of a front bites best, rising after one least
+		trend := "-"
+		var hours []conditions
+		for _, hour := range weather.Hourly {
+			if sameDate(hour.Time, day.Date) {
+				hours = append(hours, hour)
+			}
+		}
+		if len(hours) > 1 {
+			change := float64(hours[len(hours)-1].Pressure - hours[0].Pressure)
+			switch {
+			case change <= -FISHING_PRESSURE_CHANGE:
+				trend = "↓ " + tr("falling")
+				score++
+			case change >= FISHING_PRESSURE_CHANGE:
+				trend = "↑ " + tr("rising")
+				score--
+			default:
+				trend = "→ " + tr("steady")
+			}
+		}
+
+		// A breeze helps, a gale doesn't
+		wind := KILOMETERS_PER_HOUR.fromMetersPerSecond(options.Units.toMetersPerSecond(day.WindSpeed))
+		switch {
+		case wind >= FISHING_GALE_KMH:
+			score--
+		case wind >= FISHING_BREEZE_KMH:
+			score++
+		}
+
+		rating := "poor"
+		for _, candidate := range fishingRatings {
+			if score >= candidate.From {
+				rating = candidate.Name
+				break
+			}
+		}
+
+		rows = append(rows, []string{
+			day.Date.Format("Mon Jan 2"),
+			strings.Join(major, ", "),
+			strings.Join(minor, ", "),
+			moonPhaseEmoji(noon, weather.Coord.Lat) + " " + tr(moonPhaseName(noon)),
+			trend,
+			options.wind(day.WindSpeed, 0),
+			tr(rating),
+		})
+	}
+
+	if len(rows) == 1 {
+		return fmt.Errorf("%s has no daily forecast for fishing times", result.Provider)
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Thresholds of fog in metric units, beyond the visibility of FOG_VISIBILITY_M
+const (
+	DENSE_FOG_VISIBILITY_M = 200
+	FOG_SPREAD_C           = 1.0 // Dew point spread at which the air condenses
+	FOG_HUMIDITY           = 95
+	FOG_CALM_KMH           = 12.0 // Stronger winds mix fog away
+)
+
+// Fog levels, worst first
+var fogLevels = []string{"dense fog", "fog", "fog likely"}
+
+// Hours `weather fog` lists and `weather fog -watch` warns ahead of
+const (
+	FOG_FORECAST_HOURS    = 24
+	FOG_WATCH_AHEAD_HOURS = 12
+)
+
+// How often `weather fog -watch` checks the forecast again
+const FOG_WATCH_INTERVAL = 30 * time.Minute
+
+// Consecutive foggy hours
+type fogSpell struct {
+	From       time.Time
+	To         time.Time
+	Level      string
+	Visibility int64 // Lowest in meters, zero when unknown
+}
+
+// The fog of the conditions: dense fog or fog from the visibility, or fog
+// likely when calm air is saturated; empty without fog
+func (c conditions) fogLevel(units unitSystem) string {
+	switch {
+	case c.Visibility > 0 && c.Visibility < DENSE_FOG_VISIBILITY_M:
+		return "dense fog"
+	case c.Condition.Kind == FOG || (c.Visibility > 0 && c.Visibility < FOG_VISIBILITY_M):
+		return "fog"
+	}
+
+	spread := units.toCelsius(c.Temp) - units.toCelsius(c.DewPoint)
+	wind := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(c.WindSpeed))
+	if spread <= FOG_SPREAD_C && c.Humidity >= FOG_HUMIDITY && wind < FOG_CALM_KMH && !c.Condition.Kind.precipitating() {
+		return "fog likely"
+	}
+
+	return ""
+}
+
+// The fog spells of now and the hours ahead, each with its worst level
+func (w weatherData) fogSpells(hours int, units unitSystem) []fogSpell {
+	until := w.Current.Time.Add(time.Duration(hours) * time.Hour)
+	moments := []conditions{w.Current}
+	for _, hour := range w.Hourly {
+		if hour.Time.After(w.Current.Time) && hour.Time.Before(until) {
+			moments = append(moments, hour)
+		}
+	}
+
+	rank := func(level string) int {
+		for position, known := range fogLevels {
+			if level == known {
+				return position
+			}
+		}
+		return len(fogLevels)
+	}
+
+	var spells []fogSpell
+	foggy := false
+	for _, moment := range moments {
+		level := moment.fogLevel(units)
+		if level == "" {
+			foggy = false
+			continue
+		}
+
+		end := moment.Time.Truncate(time.Hour).Add(time.Hour)
+		if !foggy {
+			spells = append(spells, fogSpell{From: moment.Time, Level: level})
+			foggy = true
+		}
+
+		spell := &spells[len(spells)-1]
+		spell.To = end
+		if rank(level) < rank(spell.Level) {
+			spell.Level = level
+		}
+		if moment.Visibility > 0 && (spell.Visibility == 0 || moment.Visibility < spell.Visibility) {
+			spell.Visibility = moment.Visibility
+		}
+	}
+
+	return spells
+}
+
+// One line about a fog spell, as in "dense fog now until 09:00, visibility
+// down to 150 m"
+func (s fogSpell) describe(now time.Time, options displayOptions) string {
+	clock := options.clockFormat()
+
+	text := fmt.Sprintf(tr("%s %s–%s"), tr(s.Level), s.From.Format(clock), s.To.Format(clock))
+	if !s.From.After(now) {
+		text = fmt.Sprintf(tr("%s now until %s"), tr(s.Level), s.To.Format(clock))
+	}
+	if s.Visibility > 0 {
+		text += ", " + fmt.Sprintf(tr("visibility down to %s"), formatVisibility(float64(s.Visibility), options.Units))
+	}
+
+	return text
+}
+
+// Implements `weather fog`, the fog spells of the coming day from the
+// visibility and how close the air is to its dew point. With watch it keeps
+// checking and sends a desktop notification of each new spell, so early
+// commuters hear of morning fog the night before.
+func runFog(ctx context.Context, target location, watch bool, units string) error {
+	if watch {
+		return watchFog(ctx, target, units)
+	}
+
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	spells := weather.fogSpells(FOG_FORECAST_HOURS, options.Units)
+	if len(spells) == 0 {
+		fmt.Printf(tr("No fog expected in the next %d hours")+"\n", FOG_FORECAST_HOURS)
+		return nil
+	}
+
+	fmt.Println("⚠️  " + spells[0].describe(weather.Current.Time, options))
+
+	rows := [][]string{translated("From", "Until", "Fog", "Visibility")}
+	for _, spell := range spells {
+		visibility := "-"
+		if spell.Visibility > 0 {
+			visibility = formatVisibility(float64(spell.Visibility), options.Units)
+		}
+
+		rows = append(rows, []string{
+			spell.From.Format("Mon " + options.clockFormat()),
+			spell.To.Format("Mon " + options.clockFormat()),
+			tr(spell.Level),
+			visibility,
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
+
+// Checks the forecast every FOG_WATCH_INTERVAL until interrupted, printing
+// and notifying of each fog spell within FOG_WATCH_AHEAD_HOURS once
+func watchFog(ctx context.Context, target location, units string) error {
+	status(fmt.Sprintf("[@] Watching for fog in the next %d hours, Ctrl+C to stop", FOG_WATCH_AHEAD_HOURS))
+
+	notified := map[int64]bool{}
+	for {
+		result, err := fetchReport(ctx, target, units)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			status("[!] " + err.Error())
+		} else {
+			weather := result.Weather
+			for _, spell := range weather.fogSpells(FOG_WATCH_AHEAD_HOURS, result.Options.Units) {
+				if notified[spell.From.Truncate(time.Hour).Unix()] {
+					continue
+				}
+				notified[spell.From.Truncate(time.Hour).Unix()] = true
+
+				message := spell.describe(weather.Current.Time, result.Options)
+				fmt.Printf("%s ⚠️  %s\n", result.Options.in(time.Now()).Format(result.Options.clockFormat()), message)
+				if err := notifyDesktop(ctx, tr("Fog Warning"), message); err != nil {
+					status("[!] " + err.Error())
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(FOG_WATCH_INTERVAL):
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Implements `weather forecast`, printing the daily outlook and optionally
+// the next hours
+func runForecast(ctx context.Context, target location, days int, hours int, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	temperature := options.Units.temperature()
+
+	place := target.CompactName
+	if place == "" {
+		place = fmt.Sprintf("%.4f, %.4f", weather.Coord.Lat, weather.Coord.Lon)
+	}
+	fmt.Printf("\n"+tr("Forecast for %s from %s")+"\n\n", place, result.Provider)
+
+	if hours > 0 && len(weather.Hourly) > 0 {
+		rows := [][]string{translated("Time", "Condition", "Temp", "Rain chance", "Amount", "Wind")}
+		for _, hour := range weather.Hourly[:min(hours, len(weather.Hourly))] {
+			rows = append(rows, []string{
+				hour.Time.Format("Mon " + options.clockFormat()),
+				hour.Condition.emoji() + " " + hour.Condition.Description,
+				fmt.Sprintf("%.1f%s", hour.Temp, temperature),
+				fmt.Sprintf("%.0f%%", hour.Pop*100),
+				options.Units.formatPrecipitation(hour.Precipitation),
+				options.wind(hour.WindSpeed, 1) + " " + compassDirection(hour.WindDeg),
+			})
+		}
+
+		printTable(os.Stdout, rows)
+		fmt.Println()
+	}
+
+	if len(weather.Daily) == 0 {
+		return fmt.Errorf("%s returned no daily forecast", result.Provider)
+	}
+
+	rows := [][]string{translated("Day", "Condition", "High", "Low", "Rain chance", "Amount", "Wind")}
+	for _, day := range weather.Daily[:min(max(days, 1), len(weather.Daily))] {
+		rows = append(rows, []string{
+			day.Date.Format("Mon Jan 2"),
+			day.Condition.emoji() + " " + day.Condition.Description,
+			fmt.Sprintf("%.1f%s", day.TempMax, temperature),
+			fmt.Sprintf("%.1f%s", day.TempMin, temperature),
+			fmt.Sprintf("%.0f%%", day.Pop*100),
+			options.Units.formatPrecipitation(day.Precipitation),
+			options.wind(day.WindSpeed, 1),
+		})
+	}
+
+	printTable(os.Stdout, rows)
+
+	return nil
+}
+
+// Implements `weather forecast -when`, showing the day a phrase such as
+// "friday" names by day and by part of the day
+func runForecastDay(ctx context.Context, target location, when string, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+
+	// The place's own calendar decides which day tomorrow is
+	day, err := parseDay(when, weather.Current.Time)
+	if err != nil {
+		return err
+	}
+
+	for _, forecast := range weather.Daily {
+		if sameDate(forecast.Date, day) {
+			fmt.Printf("\n%s: %s\n", forecast.Date.Format("Monday Jan 2"), forecast.summary(result.Options))
+			weather.printDayParts(forecast.Date, result.Options)
+			return nil
+		}
+	}
+
+	if len(weather.Daily) == 0 {
+		return fmt.Errorf("%s returned no daily forecast", result.Provider)
+	}
+
+	last := weather.Daily[len(weather.Daily)-1].Date
+	return fmt.Errorf("%s is outside the forecast of %s, which runs until %s", day.Format("Mon Jan 2"), result.Provider, last.Format("Mon Jan 2"))
+}
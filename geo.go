@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Mean Earth radius in kilometers
+const EARTH_RADIUS_KM = 6371.0
+
+// Great-circle distance between two coordinates in kilometers
+func distanceKm(a, b coordinate) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	deltaLat := (b.Lat - a.Lat) * math.Pi / 180
+	deltaLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+
+	return 2 * EARTH_RADIUS_KM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Initial compass bearing in degrees for the great-circle route from one
+// coordinate to another
+func bearingDegrees(from, to coordinate) float64 {
+	lat1 := from.Lat * math.Pi / 180
+	lat2 := to.Lat * math.Pi / 180
+	deltaLon := (to.Lon - from.Lon) * math.Pi / 180
+
+	y := math.Sin(deltaLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(deltaLon)
+
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// Formats a distance in kilometers, or miles for imperial units
+func formatDistance(km float64, units unitSystem) string {
+	if units == IMPERIAL {
+		return fmt.Sprintf("%.0f mi", km/1.609344)
+	}
+
+	return fmt.Sprintf("%.0f km", km)
+}
+
+// An area bounded by coordinates in order, the last joining the first
+type polygon []coordinate
+
+// Whether a point lies inside, by counting how often a ray from it to the
+// east crosses the edges. Fine for areas that don't span the antimeridian.
+func (p polygon) contains(point coordinate) bool {
+	inside := false
+	for index := range p {
+		a, b := p[index], p[(index+len(p)-1)%len(p)]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lon < (b.Lon-a.Lon)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Looks up where the user is
+type locator func(ctx context.Context) (location, error)
+
+// Every location source by the name used with -geo-source: the operating
+// system's location service, gpsd and IP geolocation services
+var geoSources = map[string]locator{
+	"system":      locateWithSystem,
+	"gps":         locateWithGPS,
+	"nordvpn":     locateWithNordVPN,
+	"ip-api":      locateWithIPAPI,
+	"ipinfo":      locateWithIPInfo,
+	"ifconfig.co": locateWithIfconfig,
+}
+
+// Location source names in the order they are listed to users
+var geoSourceNames = []string{"system", "gps", "nordvpn", "ip-api", "ipinfo", "ifconfig.co"}
+
+// Sources tried in order when neither -geo-source nor the environment name
+// any. The system's service is the most accurate where there is one; gps
+// needs a gpsd, so it is only used when asked for.
+const DEFAULT_GEO_SOURCES = "system,nordvpn,ip-api,ipinfo,ifconfig.co"
+
+// Sources asked for the user's location in order, set with -geo-source
+var activeGeoSources = strings.Split(DEFAULT_GEO_SOURCES, ",")
+
+// Sources that find the location without telling anyone, the only ones used
+// in privacy mode
+var localGeoSources = map[string]bool{"system": true, "gps": true}
+
+// Keeps the user's location and IP address from lookup services, set with
+// -privacy or privacy in the config
+var privacyMode bool
+
+// Leaves only the local sources for privacy mode. Sources the user named are
+// an error rather than silently dropped.
+func privateSources(names []string, chosen bool) ([]string, error) {
+	var kept []string
+	for _, name := range names {
+		if localGeoSources[name] {
+			kept = append(kept, name)
+		} else if chosen {
+			return nil, fmt.Errorf("privacy mode doesn't allow the %s IP lookup, use -geo-source system or gps", name)
+		}
+	}
+
+	return kept, nil
+}
+
+// Parses a comma separated list of location sources such as
+// "system,ipinfo"
+func parseGeoSources(list string) ([]string, error) {
+	var names []string
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if _, known := geoSources[name]; !known {
+			return nil, fmt.Errorf("unknown location source %q, expected one of %s", name, strings.Join(geoSourceNames, ", "))
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no location sources given, expected one of %s", strings.Join(geoSourceNames, ", "))
+	}
+
+	return names, nil
+}
+
+// Finds the user's location, asking each active source in turn until one
+// answers
+func fetchUserLocation(ctx context.Context) (location, error) {
+	status("[@] Fetching your coordinates")
+
+	var errs []error
+	for index, name := range activeGeoSources {
+		place, err := geoSources[name](ctx)
+		if err == nil && place.Coord == (coordinate{}) {
+			err = errors.New("answered without coordinates")
+		}
+		if err == nil {
+			return place, nil
+		}
+
+		// Don't move on to the next service after Ctrl+C
+		if ctx.Err() != nil {
+			return location{}, ctx.Err()
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		logger.Debug("geolocation failed", "source", name, "error", err)
+
+		// Most machines have no location service, which isn't worth a warning
+		if index < len(activeGeoSources)-1 && !errors.Is(err, errNoLocationService) {
+			status("[!] " + name + " could not locate you, trying the next service")
+		}
+	}
+
+	if privacyMode {
+		return location{}, fmt.Errorf("privacy mode is on and no local location source found you, name a place, pass -coords or set location in the config: %w", errors.Join(errs...))
+	}
+
+	return location{}, fmt.Errorf("looking up your location: %w", errors.Join(errs...))
+}
+
+// Words in the network names of hosting companies and VPN services, whose
+// addresses are placed where their servers are rather than their users
+var hostingNetworkWords = []string{
+	"amazon", "aws", "google cloud", "azure", "digitalocean", "linode", "akamai",
+	"ovh", "hetzner", "vultr", "choopa", "leaseweb", "m247", "datacamp", "cdn77", "oracle",
+	"hosting", "datacenter", "data center", "colocation", "vpn", "proxy",
+	"nordvpn", "mullvad", "expressvpn", "proton", "surfshark", "private internet access",
+	"cloudflare", "packethub", "clouvider", "zscaler",
+}
+
+// Warns when the address looked up belongs to a VPN or a data center, as the
+// location is then wherever the exit server is
+func warnAboutNetwork(network string, flagged bool) {
+	lower := strings.ToLower(network)
+
+	if !flagged {
+		for _, word := range hostingNetworkWords {
+			if strings.Contains(lower, word) {
+				flagged = true
+				break
+			}
+		}
+	}
+
+	if !flagged {
+		return
+	}
+
+	through := "a VPN or data center"
+	if network != "" {
+		through += " (" + network + ")"
+	}
+	status("[!] Your connection goes through " + through + ", so the detected location may be off by hundreds of kilometers. Name a place or find it with weather search instead.")
+}
+
+// Decodes a geolocation answer
+func fetchGeolocation(ctx context.Context, url string, into any) error {
+	body, err := fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("parsing IP info: %w", err)
+	}
+
+	return nil
+}
+
+// Locates the user with NordVPN's IP lookup, which answers with IPInfo
+func locateWithNordVPN(ctx context.Context) (location, error) {
+	var parsed IPInfo
+	if err := fetchGeolocation(ctx, "https://web-api.nordvpn.com/v1/ips/info", &parsed); err != nil {
+		return location{}, err
+	}
+
+	// Protected means the address is a NordVPN exit
+	warnAboutNetwork(parsed.ISP, parsed.Protected)
+
+	return location{
+		Coord:   coordinate{Lat: parsed.Latitude, Lon: parsed.Longitude},
+		Name:    parsed.City,
+		Country: parsed.CountryCode,
+	}, nil
+}
+
+// ip-api.com's answer. Its free tier is only served over plain HTTP.
+type ipAPIResponse struct {
+	Status      string  `json:"status"`
+	Message     string  `json:"message"`
+	CountryCode string  `json:"countryCode"`
+	City        string  `json:"city"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	ISP         string  `json:"isp"`
+	Proxy       bool    `json:"proxy"`   // A known VPN, proxy or Tor exit
+	Hosting     bool    `json:"hosting"` // A data center address
+}
+
+// Locates the user with ip-api.com
+func locateWithIPAPI(ctx context.Context) (location, error) {
+	var parsed ipAPIResponse
+	if err := fetchGeolocation(ctx, "http://ip-api.com/json/?fields=status,message,countryCode,city,lat,lon,isp,proxy,hosting", &parsed); err != nil {
+		return location{}, err
+	}
+
+	if parsed.Status != "success" {
+		return location{}, fmt.Errorf("ip-api: %s", parsed.Message)
+	}
+
+	warnAboutNetwork(parsed.ISP, parsed.Proxy || parsed.Hosting)
+
+	return location{
+		Coord:   coordinate{Lat: parsed.Lat, Lon: parsed.Lon},
+		Name:    parsed.City,
+		Country: parsed.CountryCode,
+	}, nil
+}
+
+// ipinfo.io's answer, with the coordinates as "lat,lon"
+type ipInfoResponse struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+	Loc     string `json:"loc"`
+	Org     string `json:"org"` // Network, such as "AS16509 Amazon.com, Inc."
+}
+
+// Locates the user with ipinfo.io
+func locateWithIPInfo(ctx context.Context) (location, error) {
+	var parsed ipInfoResponse
+	if err := fetchGeolocation(ctx, "https://ipinfo.io/json", &parsed); err != nil {
+		return location{}, err
+	}
+
+	latText, lonText, _ := strings.Cut(parsed.Loc, ",")
+	lat, latErr := strconv.ParseFloat(latText, 64)
+	lon, lonErr := strconv.ParseFloat(lonText, 64)
+	if latErr != nil || lonErr != nil {
+		return location{}, fmt.Errorf("ipinfo: unexpected coordinates %q", parsed.Loc)
+	}
+
+	warnAboutNetwork(parsed.Org, false)
+
+	return location{
+		Coord:   coordinate{Lat: lat, Lon: lon},
+		Name:    parsed.City,
+		Country: parsed.Country,
+	}, nil
+}
+
+// ifconfig.co's answer
+type ifconfigResponse struct {
+	City       string  `json:"city"`
+	CountryISO string  `json:"country_iso"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	ASNOrg     string  `json:"asn_org"`
+}
+
+// Locates the user with ifconfig.co
+func locateWithIfconfig(ctx context.Context) (location, error) {
+	var parsed ifconfigResponse
+	if err := fetchGeolocation(ctx, "https://ifconfig.co/json", &parsed); err != nil {
+		return location{}, err
+	}
+
+	warnAboutNetwork(parsed.ASNOrg, false)
+
+	return location{
+		Coord:   coordinate{Lat: parsed.Latitude, Lon: parsed.Longitude},
+		Name:    parsed.City,
+		Country: parsed.CountryISO,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Where gpsd listens unless WEATHER_GPSD names another host:port
+const GPSD_ADDRESS = "localhost:2947"
+
+// Longest gpsd may take to report a fix
+const GPSD_TIMEOUT = 5 * time.Second
+
+// Asks gpsd to stream reports as JSON lines
+const GPSD_WATCH = `?WATCH={"enable":true,"json":true}` + "\n"
+
+// The parts of a gpsd report that matter here. Time-position-velocity
+// reports carry the position, with a mode of 2 or 3 once there is a fix.
+type gpsdReport struct {
+	Class string  `json:"class"`
+	Mode  int     `json:"mode"`
+	Lat   float64 `json:"lat"`
+	Lon   float64 `json:"lon"`
+}
+
+// Reads the current position from a running gpsd, for machines that move
+// around with a GPS receiver
+func locateWithGPS(ctx context.Context) (location, error) {
+	address := firstEnv("WEATHER_GPSD")
+	if address == "" {
+		address = GPSD_ADDRESS
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, GPSD_TIMEOUT)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return location{}, fmt.Errorf("no gpsd is running at %s", address)
+	}
+	if err != nil {
+		return location{}, fmt.Errorf("connecting to gpsd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte(GPSD_WATCH)); err != nil {
+		return location{}, fmt.Errorf("talking to gpsd: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var report gpsdReport
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			continue
+		}
+
+		if report.Class == "TPV" && report.Mode >= 2 {
+			return location{Coord: coordinate{Lat: report.Lat, Lon: report.Lon}, Name: fmt.Sprintf("%.4f,%.4f", report.Lat, report.Lon)}, nil
+		}
+	}
+
+	var netErr net.Error
+	if err := scanner.Err(); err != nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		return location{}, fmt.Errorf("reading from gpsd: %w", err)
+	}
+
+	return location{}, fmt.Errorf("gpsd has no fix within %s, the receiver may need a clear view of the sky", GPSD_TIMEOUT)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+)
+
+// Earthquakes from the USGS catalog, searchable by distance without a key
+const USGS_EARTHQUAKES_URL = "https://earthquake.usgs.gov/fdsnws/event/1/query"
+
+// Natural events NASA's EONET tracks from satellite and agency reports
+const EONET_EVENTS_URL = "https://eonet.gsfc.nasa.gov/api/v3/events"
+
+// EONET categories that are listed
+const EONET_CATEGORIES = "wildfires,floods,volcanoes"
+
+// Default distance events are listed within, in kilometers or miles with
+// imperial units
+const DEFAULT_HAZARD_RADIUS = 300
+
+// Default number of past days events are listed for
+const DEFAULT_HAZARD_DAYS = 7
+
+// Smaller earthquakes are rarely felt and left out
+const MIN_EARTHQUAKE_MAGNITUDE = 2.5
+
+// One natural event near the place
+type hazard struct {
+	Kind  string
+	Title string
+	Time  time.Time
+	At    coordinate
+}
+
+// Earthquakes of the USGS as GeoJSON, with times in Unix milliseconds
+type usgsEarthquakes struct {
+	Features []struct {
+		Properties struct {
+			Mag   float64 `json:"mag"`
+			Place string  `json:"place"`
+			Time  int64   `json:"time"`
+		} `json:"properties"`
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// Fetches the earthquakes within a distance of the place since a moment
+func fetchEarthquakes(ctx context.Context, at coordinate, radiusKm float64, since time.Time) ([]hazard, error) {
+	status("[@] Fetching earthquakes from the USGS")
+
+	query := url.Values{}
+	query.Set("format", "geojson")
+	query.Set("latitude", fmt.Sprintf("%f", at.Lat))
+	query.Set("longitude", fmt.Sprintf("%f", at.Lon))
+	// The USGS searches up to half way around the world
+	query.Set("maxradiuskm", fmt.Sprintf("%.0f", min(radiusKm, 20001.6)))
+	query.Set("starttime", since.UTC().Format(time.RFC3339))
+	query.Set("minmagnitude", fmt.Sprintf("%.1f", MIN_EARTHQUAKE_MAGNITUDE))
+
+	body, err := fetch(ctx, USGS_EARTHQUAKES_URL+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("fetching earthquakes from the USGS: %w", err)
+	}
+
+	var parsed usgsEarthquakes
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing USGS earthquakes: %w", err)
+	}
+
+	var quakes []hazard
+	for _, feature := range parsed.Features {
+		if len(feature.Geometry.Coordinates) < 2 {
+			continue
+		}
+
+		quakes = append(quakes, hazard{
+			Kind:  "Earthquake",
+			Title: fmt.Sprintf("M %.1f, %s", feature.Properties.Mag, feature.Properties.Place),
+			Time:  time.UnixMilli(feature.Properties.Time),
+			At:    coordinate{Lat: feature.Geometry.Coordinates[1], Lon: feature.Geometry.Coordinates[0]},
+		})
+	}
+
+	return quakes, nil
+}
+
+// Open events of EONET, each with the places it was seen at, latest last
+type eonetEvents struct {
+	Events []struct {
+		Title      string `json:"title"`
+		Categories []struct {
+			Title string `json:"title"`
+		} `json:"categories"`
+		Geometry []struct {
+			Date        time.Time       `json:"date"`
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"events"`
+}
+
+// Fetches the wildfires, floods and eruptions EONET knows of in the square
+// around the place, where each was last seen
+func fetchNaturalEvents(ctx context.Context, at coordinate, radiusKm float64, days int) ([]hazard, error) {
+	status("[@] Fetching wildfires, floods and volcanoes from NASA EONET")
+
+	north, south := min(at.Lat+radiusKm/110.57, 90), max(at.Lat-radiusKm/110.57, -90)
+	east := min(radiusKm/(111.32*math.Cos(at.Lat*math.Pi/180)), 180)
+
+	query := url.Values{}
+	query.Set("status", "open")
+	query.Set("category", EONET_CATEGORIES)
+	query.Set("days", fmt.Sprint(days))
+	query.Set("bbox", fmt.Sprintf("%f,%f,%f,%f", at.Lon-east, north, at.Lon+east, south))
+
+	body, err := fetch(ctx, EONET_EVENTS_URL+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("fetching natural events from NASA EONET: %w", err)
+	}
+
+	var parsed eonetEvents
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing NASA EONET events: %w", err)
+	}
+
+	var events []hazard
+	for _, event := range parsed.Events {
+		if len(event.Geometry) == 0 || len(event.Categories) == 0 {
+			continue
+		}
+		latest := event.Geometry[len(event.Geometry)-1]
+
+		// Areas stand in by the middle of their corners
+		var corners polygon
+		if latest.Type == "Point" {
+			var position []float64
+			if err := json.Unmarshal(latest.Coordinates, &position); err != nil || len(position) < 2 {
+				continue
+			}
+			corners = polygon{{Lat: position[1], Lon: position[0]}}
+		} else {
+			areas, err := geoJSONPolygons(latest.Type, latest.Coordinates)
+			if err != nil || len(areas) == 0 || len(areas[0]) == 0 {
+				continue
+			}
+			corners = areas[0]
+		}
+
+		var middle coordinate
+		for _, corner := range corners {
+			middle.Lat += corner.Lat / float64(len(corners))
+			middle.Lon += corner.Lon / float64(len(corners))
+		}
+
+		events = append(events, hazard{Kind: event.Categories[0].Title, Title: event.Title, Time: latest.Date, At: middle})
+	}
+
+	return events, nil
+}
+
+// Implements `weather hazards`, the earthquakes, wildfires, floods and
+// eruptions of the past days within a distance of the place, nearest first
+func runHazards(ctx context.Context, target location, radius float64, days int, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	// The radius is given in miles with imperial units
+	radiusKm := radius
+	if options.Units == IMPERIAL {
+		radiusKm *= 1.609344
+	}
+
+	// Either feed alone is still worth showing
+	var hazards []hazard
+	failed := 0
+	quakes, err := fetchEarthquakes(ctx, target.Coord, radiusKm, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.Debug("earthquake lookup failed", "error", err)
+		failed++
+	}
+	events, eventsErr := fetchNaturalEvents(ctx, target.Coord, radiusKm, days)
+	if eventsErr != nil {
+		logger.Debug("natural event lookup failed", "error", eventsErr)
+		failed++
+	}
+	if failed == 2 {
+		return errors.Join(err, eventsErr)
+	}
+
+	for _, found := range append(quakes, events...) {
+		if distanceKm(target.Coord, found.At) <= radiusKm {
+			hazards = append(hazards, found)
+		}
+	}
+
+	if len(hazards) == 0 {
+		fmt.Printf(tr("No earthquakes, wildfires, floods or eruptions within %s in the past %d days.")+"\n", formatDistance(radiusKm, options.Units), days)
+		return nil
+	}
+
+	sort.Slice(hazards, func(i, j int) bool {
+		return distanceKm(target.Coord, hazards[i].At) < distanceKm(target.Coord, hazards[j].At)
+	})
+
+	rows := [][]string{translated("Hazard", "Event", "When", "Distance")}
+	for _, found := range hazards {
+		rows = append(rows, []string{
+			tr(found.Kind),
+			found.Title,
+			options.in(found.Time).Format("Mon Jan 2 " + options.clockFormat()),
+			formatDistance(distanceKm(target.Coord, found.At), options.Units) + " " + compassDirection(int64(bearingDegrees(target.Coord, found.At))),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
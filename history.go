@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Name of the observation log inside the data directory. It is JSON lines
+// rather than SQLite, so the binary builds without cgo and the log can be
+// read with any tool.
+const HISTORY_FILE = "history.jsonl"
+
+// Size a JSON lines log may grow to before its oldest lines are dropped, and
+// the size it is cut back to, since every report reads the history in full
+const (
+	MAX_RECORDS_BYTES  = 8 << 20
+	KEPT_RECORDS_BYTES = 6 << 20
+)
+
+// Whether fetched observations are stored, turned off with -no-history
+var recordHistory = true
+
+// Serializes appends from parallel fetches
+var historyMu sync.Mutex
+
+// One stored observation. Values are always metric so entries fetched with
+// different units can be compared.
+type observation struct {
+	Time        int64         `json:"time"`
+	Fetched     int64         `json:"fetched"`
+	Provider    string        `json:"provider"`
+	Location    string        `json:"location"`
+	Lat         float64       `json:"lat"`
+	Lon         float64       `json:"lon"`
+	Temp        float64       `json:"temp"`
+	FeelsLike   float64       `json:"feels_like"`
+	DewPoint    float64       `json:"dew_point"`
+	Pressure    int64         `json:"pressure"`
+	Humidity    int64         `json:"humidity"`
+	Clouds      int64         `json:"clouds"`
+	UVI         float64       `json:"uvi"`
+	Visibility  int64         `json:"visibility"`
+	WindSpeed   float64       `json:"wind_speed"`
+	WindDeg     int64         `json:"wind_deg"`
+	WindGust    float64       `json:"wind_gust"`
+	Condition   string        `json:"condition"`
+	Kind        conditionKind `json:"kind,omitempty"`
+	ConditionID int64         `json:"condition_id,omitempty"` // Only in entries from older versions
+	TzOffset    int           `json:"tz_offset"`
+}
+
+// Directory for persistent data such as the history log
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "weather"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "share", "weather"), nil
+}
+
+// Path of a file inside the data directory
+func dataPath(name string) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", fmt.Errorf("locating data directory: %w", err)
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// Converts a report into a metric observation
+func newObservation(result report) observation {
+	current := result.Weather.Current
+	units := result.Options.Units
+
+	name := result.Location.CompactName
+	if name == "" {
+		name = result.Location.Name
+	}
+	if name == "" {
+		name = fmt.Sprintf("%.4f,%.4f", result.Weather.Coord.Lat, result.Weather.Coord.Lon)
+	}
+
+	return observation{
+		Time:       current.Time.Unix(),
+		Fetched:    time.Now().Unix(),
+		Provider:   result.Provider,
+		Location:   name,
+		Lat:        result.Weather.Coord.Lat,
+		Lon:        result.Weather.Coord.Lon,
+		Temp:       units.toCelsius(current.Temp),
+		FeelsLike:  units.toCelsius(current.FeelsLike),
+		DewPoint:   units.toCelsius(current.DewPoint),
+		Pressure:   current.Pressure,
+		Humidity:   current.Humidity,
+		Clouds:     current.Clouds,
+		UVI:        current.UVI,
+		Visibility: current.Visibility,
+		WindSpeed:  units.toMetersPerSecond(current.WindSpeed),
+		WindDeg:    current.WindDeg,
+		WindGust:   units.toMetersPerSecond(current.WindGust),
+		Condition:  current.Condition.Description,
+		Kind:       current.Condition.Kind,
+		TzOffset:   result.Weather.Offset,
+	}
+}
+
+// Appends records as JSON lines to a file in the data directory
+func appendRecords[T any](name string, records ...T) error {
+	path, err := dataPath(name)
+	if err != nil {
+		return err
+	}
+
+	var lines []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		lines = append(append(lines, line...), '\n')
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", name, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(lines); err != nil {
+		return err
+	}
+
+	if info, err := file.Stat(); err == nil && info.Size() > MAX_RECORDS_BYTES {
+		file.Close()
+		if err := trimRecords(path); err != nil {
+			logger.Debug("could not trim records", "file", name, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Keeps only the newest lines of a JSON lines file that fit in
+// KEPT_RECORDS_BYTES, replacing the file at once so readers never see half
+func trimRecords(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	cut := len(data) - KEPT_RECORDS_BYTES
+	if cut <= 0 {
+		return nil
+	}
+	if newline := bytes.IndexByte(data[cut:], '\n'); newline >= 0 {
+		cut += newline + 1
+	} else {
+		cut = len(data)
+	}
+
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, data[cut:], 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, path)
+}
+
+// Reads every record from a JSON lines file in the data directory
+func readRecords[T any](name string) ([]T, error) {
+	path, err := dataPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+	defer file.Close()
+
+	var records []T
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// Skip lines cut short by an interrupted write
+			logger.Debug("skipping malformed line", "file", name, "error", err)
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+// Stores a fetched report unless history is turned off. Failing to write
+// history never fails the lookup itself.
+func recordReport(result report) {
+	if !recordHistory {
+		return
+	}
+
+	if err := appendRecords(HISTORY_FILE, newObservation(result)); err != nil {
+		logger.Debug("could not record history", "error", err)
+	}
+
+	if err := appendRecords(FORECASTS_FILE, newForecastRecords(result)...); err != nil {
+		logger.Debug("could not record forecasts", "error", err)
+	}
+}
+
+// Reads every stored observation, oldest first
+func loadHistory() ([]observation, error) {
+	return readRecords[observation](HISTORY_FILE)
+}
+
+// Implements `weather history`, listing the most recent observations
+func runHistory(limit int, place string, units string) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	options, err := resolveDisplay(units, "")
+	if err != nil {
+		return err
+	}
+
+	var matching []observation
+	for _, entry := range entries {
+		if place == "" || strings.Contains(strings.ToLower(entry.Location), strings.ToLower(place)) {
+			matching = append(matching, entry)
+		}
+	}
+
+	if len(matching) == 0 {
+		fmt.Println(tr("No observations recorded yet."))
+		return nil
+	}
+
+	if limit > 0 && len(matching) > limit {
+		matching = matching[len(matching)-limit:]
+	}
+
+	rows := [][]string{translated("Time", "Location", "Temp", "Pressure", "Humidity", "Wind", "Condition")}
+	for _, entry := range matching {
+		rows = append(rows, []string{
+			options.in(time.Unix(entry.Time, 0)).Format("2006-01-02 " + options.clockFormat()),
+			entry.Location,
+			fmt.Sprintf("%.1f%s", options.Units.fromCelsius(entry.Temp), options.Units.temperature()),
+			options.Pressure.format(float64(entry.Pressure)),
+			fmt.Sprintf("%d%%", entry.Humidity),
+			options.Wind.format(entry.WindSpeed, 1) + " " + compassDirection(entry.WindDeg),
+			entry.Condition,
+		})
+	}
+
+	printTable(os.Stdout, rows)
+
+	return nil
+}
+
+// Implements `weather history -date`, what the weather was on a past day
+// at a place, from the Open-Meteo archive
+func runHistoryDate(ctx context.Context, target location, date string, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	day, err := parseDay(date, time.Now())
+	if err != nil {
+		return err
+	}
+	if day.After(time.Now()) {
+		return fmt.Errorf("%s hasn't happened yet, try `weather forecast -when %s`", date, date)
+	}
+
+	past, err := fetchOpenMeteoDays(ctx, target.Coord, options.Units, day, day)
+	if err != nil {
+		return err
+	}
+	past = past.in(options)
+
+	clock := options.clockFormat()
+	temperature := options.Units.temperature()
+	summary := past.Daily[0]
+
+	place := firstNonEmpty(target.CompactName, target.Name, fmt.Sprintf("%.4f, %.4f", past.Coord.Lat, past.Coord.Lon))
+	fmt.Printf("\n%s, %s\n", place, summary.Date.Format("Monday January 2 2006"))
+	fmt.Printf("%s %s, %.0f–%.0f%s, %s, %s %s, %s\n", summary.Condition.emoji(), summary.Condition.Description,
+		summary.TempMin, summary.TempMax, temperature, options.Units.formatPrecipitation(summary.Precipitation),
+		tr("wind"), options.wind(summary.WindSpeed, 1), fmt.Sprintf(tr("gusts %s"), options.wind(summary.WindGust, 1)))
+	if !summary.Sunrise.IsZero() && !summary.Sunset.IsZero() {
+		fmt.Printf("%s: %s %s, %s %s\n", tr("Sun"), tr("rises"), summary.Sunrise.Format(clock), tr("sets"), summary.Sunset.Format(clock))
+	}
+	fmt.Println()
+
+	rows := [][]string{translated("Time", "Condition", "Temp", "Feels Like", "Humidity", "Precipitation", "Wind")}
+	for _, hour := range past.Hourly {
+		rows = append(rows, []string{
+			hour.Time.Format(clock),
+			hour.Condition.emoji() + " " + hour.Condition.Description,
+			fmt.Sprintf("%.1f%s", hour.Temp, temperature),
+			fmt.Sprintf("%.1f%s", hour.FeelsLike, temperature),
+			fmt.Sprintf("%d%%", hour.Humidity),
+			options.Units.formatPrecipitation(hour.Precipitation),
+			options.wind(hour.WindSpeed, 1) + " " + compassDirection(hour.WindDeg),
+		})
+	}
+	printTable(os.Stdout, rows)
+
+	return nil
+}
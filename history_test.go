@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// A log past MAX_RECORDS_BYTES is cut back to its newest whole lines
+func TestAppendRecordsTrims(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	padding := strings.Repeat("x", 1000)
+	var batch []observation
+	for index := range MAX_RECORDS_BYTES/1100 + 100 {
+		batch = append(batch, observation{Time: int64(index), Location: padding})
+	}
+	if err := appendRecords(HISTORY_FILE, batch...); err != nil {
+		t.Fatal(err)
+	}
+
+	path, _ := dataPath(HISTORY_FILE)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > KEPT_RECORDS_BYTES {
+		t.Errorf("log is %d bytes after trimming, want at most %d", info.Size(), KEPT_RECORDS_BYTES)
+	}
+
+	kept, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) == 0 || kept[len(kept)-1].Time != batch[len(batch)-1].Time {
+		t.Fatalf("kept %d observations, want the newest last", len(kept))
+	}
+	for index := 1; index < len(kept); index++ {
+		if kept[index].Time != kept[index-1].Time+1 || kept[index].Location != padding {
+			t.Fatalf("observation %d is %+v, want whole lines in order", index, kept[index])
+		}
+	}
+}
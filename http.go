@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Identifies the program to upstream services, which met.no requires
+const USER_AGENT = "weather-cli (+https://github.com/rohitaryal/weather-cli)"
+
+// Default time allowed for a single request
+const DEFAULT_TIMEOUT = 10 * time.Second
+
+// Default number of extra attempts after a transient failure
+const DEFAULT_RETRIES = 2
+
+// Delay before the first retry; doubled on every further attempt
+const RETRY_BASE_DELAY = 500 * time.Millisecond
+
+// Upper bound for the delay between two attempts
+const RETRY_MAX_DELAY = 8 * time.Second
+
+// Time allowed for a single request, set with -timeout (0 disables it)
+var requestTimeout = DEFAULT_TIMEOUT
+
+// Extra attempts made after a transient failure, set with -retries
+var maxRetries = DEFAULT_RETRIES
+
+// Client shared by every request, built by setupHTTP
+var httpClient = &http.Client{}
+
+// Network settings given on the command line
+type clientOptions struct {
+	Proxy    string // Proxy URL, overrides the environment
+	CACert   string // PEM bundle trusted in addition to the system roots
+	Insecure bool   // Skip TLS certificate verification
+}
+
+// Builds the shared client. A -proxy value wins over the environment, where
+// HTTPS_PROXY/HTTP_PROXY are honored first and ALL_PROXY is the fallback.
+// Proxies may be http://, https:// or socks5:// URLs.
+func setupHTTP(options clientOptions) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig, err := buildTLSConfig(options)
+	if err != nil {
+		return err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if options.Proxy != "" {
+		proxyURL, err := parseProxy(options.Proxy)
+		if err != nil {
+			return err
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		// Go only reads the scheme specific variables, so fill them in from
+		// ALL_PROXY to keep NO_PROXY handling intact
+		allProxy := firstEnv("ALL_PROXY", "all_proxy")
+		if allProxy != "" {
+			if _, err := parseProxy(allProxy); err != nil {
+				return fmt.Errorf("ALL_PROXY: %w", err)
+			}
+
+			for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY"} {
+				if firstEnv(name, strings.ToLower(name)) == "" {
+					os.Setenv(name, strings.Replace(allProxy, "socks5h://", "socks5://", 1))
+				}
+			}
+		}
+
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	httpClient = &http.Client{Transport: transport}
+
+	return nil
+}
+
+// TLS settings for TLS-intercepting proxies and self-hosted mirrors
+func buildTLSConfig(options clientOptions) (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if options.CACert != "" {
+		pem, err := os.ReadFile(options.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+
+		// Trust the bundle on top of the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", options.CACert)
+		}
+
+		config.RootCAs = pool
+	}
+
+	if options.Insecure {
+		fmt.Fprintln(os.Stderr, "Warning: TLS certificate verification is disabled")
+		config.InsecureSkipVerify = true
+	}
+
+	return config, nil
+}
+
+// Validates a proxy URL given by the user
+func parseProxy(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q, expected a URL like socks5://127.0.0.1:9050", raw)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	case "socks5h":
+		// Go always resolves host names through SOCKS proxies
+		proxyURL.Scheme = "socks5"
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https or socks5", proxyURL.Scheme)
+	}
+
+	return proxyURL, nil
+}
+
+// Returns the value of the first environment variable that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Non-200 response from a server
+type statusError struct {
+	Host       string
+	Code       int
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s responded with %s", e.Host, e.Status)
+}
+
+// Fetches a URL, retrying timeouts, network errors, 429 and 5xx responses
+// with exponential backoff and jitter
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := fetchOnce(ctx, url)
+		if err == nil || attempt >= maxRetries || !isTransient(ctx, err) {
+			return body, err
+		}
+
+		delay := backoff(attempt, err)
+		logger.Debug("retrying request", "url", redactURL(url), "attempt", attempt+1, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Reports whether an error is worth another attempt
+func isTransient(ctx context.Context, err error) bool {
+	// The caller gave up (Ctrl+C), so don't try again
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+	}
+
+	// An unknown host won't appear by asking again
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+
+	// Everything else is a timeout or a network level failure
+	return true
+}
+
+// Delay before the next attempt, honoring Retry-After when the server sends it
+func backoff(attempt int, err error) time.Duration {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, RETRY_MAX_DELAY)
+	}
+
+	delay := min(RETRY_BASE_DELAY<<attempt, RETRY_MAX_DELAY)
+
+	// Jitter keeps parallel requests from retrying in lockstep
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func fetchOnce(ctx context.Context, url string) ([]byte, error) {
+	// Bound this request without affecting the caller's context
+	if requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+	}
+
+	// Create a request
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", redactError(err))
+	}
+	req.Header.Set("User-Agent", USER_AGENT)
+
+	// Wait for the provider's rate limit before going out
+	waitStart := time.Now()
+	if err := limiterFor(req.URL.Host).wait(ctx); err != nil {
+		return nil, fmt.Errorf("waiting for rate limit of %s: %w", req.URL.Host, err)
+	}
+	if waited := time.Since(waitStart); waited > time.Millisecond {
+		logger.Debug("rate limited request", "host", req.URL.Host, "waited", waited)
+	}
+
+	logger.Debug("sending request", "url", redactURL(url))
+	start := time.Now()
+
+	// Make the request
+	res, err := httpClient.Do(req)
+	if err != nil {
+		err = redactError(err)
+		logger.Debug("request failed", "url", redactURL(url), "duration", time.Since(start), "error", err)
+		return nil, fmt.Errorf("sending request to %s: %w", req.URL.Host, err)
+	}
+
+	// Defer the body (stream) closing part
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from %s: %w", req.URL.Host, err)
+	}
+
+	logger.Debug("received response", "url", redactURL(url), "status", res.StatusCode, "bytes", len(body), "duration", time.Since(start))
+
+	if res.StatusCode != http.StatusOK {
+		retryAfter, _ := strconv.Atoi(res.Header.Get("Retry-After"))
+
+		return nil, &statusError{
+			Host:       req.URL.Host,
+			Code:       res.StatusCode,
+			Status:     res.Status,
+			RetryAfter: time.Duration(retryAfter) * time.Second,
+		}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Translations of the output labels, one JSON file per language mapping the
+// English text to its translation. Adding a language only takes a new file.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Language of the built in labels
+const DEFAULT_LANGUAGE = "en"
+
+// Language chosen with -lang, the environment, the profile or the locale
+var language = DEFAULT_LANGUAGE
+
+// Translations for the chosen language, nil for English
+var messages map[string]string
+
+// The label in the chosen language, or as given when it has no translation
+func tr(text string) string {
+	if translation, found := messages[text]; found && translation != "" {
+		return translation
+	}
+
+	return text
+}
+
+// Translates each label, for table headers
+func translated(labels ...string) []string {
+	row := make([]string, len(labels))
+	for index, label := range labels {
+		row[index] = tr(label)
+	}
+
+	return row
+}
+
+// Codes of the languages labels can be shown in
+func languages() []string {
+	names := []string{DEFAULT_LANGUAGE}
+
+	entries, _ := localeFiles.ReadDir("locales")
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Reduces a language tag or POSIX locale such as "pt-BR" or "de_DE.UTF-8"
+// to the code of a bundled language, preferring the regional variant
+func matchLanguage(tag string) (string, bool) {
+	tag = strings.ToLower(tag)
+	tag, _, _ = strings.Cut(tag, ".")
+	tag, _, _ = strings.Cut(tag, "@")
+	tag = strings.ReplaceAll(tag, "_", "-")
+
+	available := languages()
+	base, _, _ := strings.Cut(tag, "-")
+
+	for _, candidate := range []string{tag, base} {
+		for _, name := range available {
+			if candidate == name {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// The user's language from the POSIX locale variables, English when they
+// name none of the bundled ones
+func systemLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		if found, ok := matchLanguage(value); ok {
+			return found
+		}
+
+		// The first variable set decides, like it does for other programs
+		break
+	}
+
+	return DEFAULT_LANGUAGE
+}
+
+// Switches the labels to a bundled language
+func setLanguage(tag string) error {
+	name, ok := matchLanguage(tag)
+	if !ok {
+		return fmt.Errorf("unknown language %q, expected one of %s", tag, strings.Join(languages(), ", "))
+	}
+
+	language = name
+	messages = nil
+
+	if name == DEFAULT_LANGUAGE {
+		return nil
+	}
+
+	data, err := localeFiles.ReadFile(path.Join("locales", name+".json"))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("parsing the %s translations: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Daily values `weather irrigate` fetches from Open-Meteo, always in mm:
+// the FAO-56 reference evapotranspiration and the rain expected
+const OPEN_METEO_IRRIGATION_DAILY = "et0_fao_evapotranspiration,precipitation_sum,precipitation_probability_max"
+
+// Days of past rain that still count as soil moisture, and days of forecast
+// watering is planned for
+const (
+	IRRIGATION_PAST_DAYS     = 3
+	IRRIGATION_FORECAST_DAYS = 7
+)
+
+// Default crop coefficient, the share of the reference evapotranspiration a
+// cool season lawn uses
+const DEFAULT_CROP_COEFFICIENT = 0.8
+
+// Share of the rain that soaks into the root zone rather than running off
+const EFFECTIVE_RAIN = 0.8
+
+// Water shortfall in mm over the week below which watering can wait
+const IRRIGATION_THRESHOLD_MM = 10.0
+
+// Exit status of `weather irrigate` when no watering is needed, kept apart
+// from the 1 of errors
+const NO_IRRIGATION_EXIT_STATUS = 2
+
+// Daily evapotranspiration and rain from Open-Meteo
+type openMeteoIrrigation struct {
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+	Daily            struct {
+		Time          []int64    `json:"time"`
+		ET0           []*float64 `json:"et0_fao_evapotranspiration"`
+		Precipitation []*float64 `json:"precipitation_sum"`
+		RainChance    []*float64 `json:"precipitation_probability_max"`
+	} `json:"daily"`
+}
+
+// Fetches the evapotranspiration and rain of the past days and the week ahead
+func fetchIrrigation(ctx context.Context, at coordinate) (openMeteoIrrigation, error) {
+	status("[@] Fetching evapotranspiration from Open-Meteo")
+
+	query := openMeteoQuery(at, METRIC)
+	query.Set("daily", OPEN_METEO_IRRIGATION_DAILY)
+	query.Set("past_days", fmt.Sprint(IRRIGATION_PAST_DAYS))
+	query.Set("forecast_days", fmt.Sprint(IRRIGATION_FORECAST_DAYS))
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return openMeteoIrrigation{}, fmt.Errorf("fetching evapotranspiration from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoIrrigation
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return openMeteoIrrigation{}, fmt.Errorf("parsing the Open-Meteo evapotranspiration: %w", err)
+	}
+	if len(parsed.Daily.Time) == 0 {
+		return openMeteoIrrigation{}, errors.New("Open-Meteo has no evapotranspiration for this place")
+	}
+
+	return parsed, nil
+}
+
+// Implements `weather irrigate`, whether the week ahead needs watering: the
+// reference evapotranspiration scaled by the crop coefficient against the
+// rain of the past days and the rain expected. Exits with
+// NO_IRRIGATION_EXIT_STATUS when it doesn't, so sprinkler scripts can ask
+// with `weather irrigate && ...`.
+func runIrrigate(ctx context.Context, target location, coefficient float64, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	water, err := fetchIrrigation(ctx, target.Coord)
+	if err != nil {
+		return err
+	}
+
+	zone := time.FixedZone(water.Timezone, water.UTCOffsetSeconds)
+	now := time.Now().In(zone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, zone)
+	amount := func(millimeters float64) string {
+		return options.Units.formatPrecipitation(options.Units.fromMillimeters(millimeters))
+	}
+	signed := func(millimeters float64) string {
+		if millimeters < 0 {
+			return "-" + amount(-millimeters)
+		}
+		return "+" + amount(millimeters)
+	}
+	daily := water.Daily
+
+	// The past days dried the soil as their rain wet it; the forecast's rain
+	// is weighed by its chance
+	need, supply := 0.0, 0.0
+	balance := 0.0
+	rows := [][]string{translated("Day", "ET0", "Crop use", "Rain", "Balance")}
+	for index, unix := range daily.Time {
+		date := unixIn(unix, zone)
+		past := date.Before(today)
+
+		rain := 0.0
+		if value := valueAt(daily.Precipitation, index); value != nil {
+			rain = *value * EFFECTIVE_RAIN
+		}
+		if chance := valueAt(daily.RainChance, index); !past && chance != nil {
+			rain *= *chance / 100
+		}
+
+		use := 0.0
+		et0 := "-"
+		if value := valueAt(daily.ET0, index); value != nil {
+			use = *value * coefficient
+			et0 = amount(*value)
+		}
+
+		need += use
+		supply += rain
+		balance += rain - use
+
+		label := date.Format("Mon Jan 2")
+		if past {
+			label += " (" + tr("past") + ")"
+		}
+		rows = append(rows, []string{label, et0, amount(use), amount(rain), signed(balance)})
+	}
+
+	fmt.Printf(tr("Plants use %s of water over these %d days, rain brings %s")+"\n", amount(need), len(daily.Time), amount(supply))
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+	fmt.Println()
+
+	if shortfall := need - supply; shortfall >= IRRIGATION_THRESHOLD_MM {
+		fmt.Printf(tr("Water this week, about %s in all")+"\n", amount(shortfall))
+		return nil
+	}
+
+	fmt.Println(tr("No watering needed this week."))
+
+	return exitStatus(NO_IRRIGATION_EXIT_STATUS)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service name API keys are filed under in the system keyring
+const KEYRING_SERVICE = "weather-cli"
+
+// Returned when the keyring holds no key for a provider
+var errNoKey = errors.New("no key stored")
+
+// Credential Manager helpers for PowerShell. Windows has no command that
+// prints a stored secret, so CredRead and friends are called directly.
+const CREDMAN_SCRIPT = `
+Add-Type -Namespace Weather -Name CredMan -MemberDefinition @'
+[StructLayout(LayoutKind.Sequential, CharSet = CharSet.Unicode)]
+public struct Credential {
+	public int Flags; public int Type; public string TargetName; public string Comment;
+	public long LastWritten; public int CredentialBlobSize; public IntPtr CredentialBlob;
+	public int Persist; public int AttributeCount; public IntPtr Attributes;
+	public string TargetAlias; public string UserName;
+}
+[DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+public static extern bool CredRead(string target, int type, int flags, out IntPtr credential);
+[DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+public static extern bool CredWrite(ref Credential credential, int flags);
+[DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+public static extern bool CredDelete(string target, int type, int flags);
+[DllImport("advapi32.dll")]
+public static extern void CredFree(IntPtr buffer);
+'@
+$target = $args[1]
+switch ($args[0]) {
+	'get' {
+		$pointer = [IntPtr]::Zero
+		if (-not [Weather.CredMan]::CredRead($target, 1, 0, [ref]$pointer)) { exit 2 }
+		$credential = [Runtime.InteropServices.Marshal]::PtrToStructure($pointer, [type][Weather.CredMan+Credential])
+		[Console]::Out.Write([Runtime.InteropServices.Marshal]::PtrToStringUni($credential.CredentialBlob, $credential.CredentialBlobSize / 2))
+		[Weather.CredMan]::CredFree($pointer)
+	}
+	'set' {
+		$secret = [Console]::In.ReadToEnd()
+		$credential = New-Object Weather.CredMan+Credential
+		$credential.Type = 1
+		$credential.TargetName = $target
+		$credential.Persist = 2
+		$credential.UserName = $env:USERNAME
+		$credential.CredentialBlobSize = $secret.Length * 2
+		$credential.CredentialBlob = [Runtime.InteropServices.Marshal]::StringToCoTaskMemUni($secret)
+		if (-not [Weather.CredMan]::CredWrite([ref]$credential, 0)) { exit 1 }
+	}
+	'delete' {
+		if (-not [Weather.CredMan]::CredDelete($target, 1, 0)) { exit 2 }
+	}
+}
+`
+
+// Reads a provider's key from the system keyring
+func keyringGet(provider string) (string, error) {
+	var output []byte
+	var err error
+
+	switch runtime.GOOS {
+	case "darwin":
+		output, err = keyringCommand(nil, "security", "find-generic-password", "-s", KEYRING_SERVICE, "-a", provider, "-w")
+		if exitCode(err) == 44 {
+			return "", errNoKey
+		}
+	case "windows":
+		output, err = keyringCommand(nil, "powershell", powershellArgs(CREDMAN_SCRIPT, "get", credentialTarget(provider))...)
+		if exitCode(err) == 2 {
+			return "", errNoKey
+		}
+	default:
+		// secret-tool exits 1 with no output when nothing matches
+		output, err = keyringCommand(nil, "secret-tool", "lookup", "service", KEYRING_SERVICE, "provider", provider)
+		if exitCode(err) == 1 && len(output) == 0 {
+			return "", errNoKey
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	key := strings.TrimSpace(string(output))
+	if key == "" {
+		return "", errNoKey
+	}
+
+	return key, nil
+}
+
+// Stores a provider's key in the system keyring, replacing any earlier one.
+// The key is passed on stdin where the tool allows it, so it doesn't show up
+// in the process list.
+func keyringSet(provider string, key string) error {
+	var err error
+
+	switch runtime.GOOS {
+	case "darwin":
+		// security only takes the password as an argument
+		_, err = keyringCommand(nil, "security", "add-generic-password", "-U", "-s", KEYRING_SERVICE, "-a", provider, "-w", key)
+	case "windows":
+		_, err = keyringCommand(strings.NewReader(key), "powershell", powershellArgs(CREDMAN_SCRIPT, "set", credentialTarget(provider))...)
+	default:
+		_, err = keyringCommand(strings.NewReader(key), "secret-tool", "store", "--label", KEYRING_SERVICE+" "+provider+" API key", "service", KEYRING_SERVICE, "provider", provider)
+	}
+
+	return err
+}
+
+// Removes a provider's key from the system keyring
+func keyringDelete(provider string) error {
+	var err error
+
+	switch runtime.GOOS {
+	case "darwin":
+		_, err = keyringCommand(nil, "security", "delete-generic-password", "-s", KEYRING_SERVICE, "-a", provider)
+		if exitCode(err) == 44 {
+			return errNoKey
+		}
+	case "windows":
+		_, err = keyringCommand(nil, "powershell", powershellArgs(CREDMAN_SCRIPT, "delete", credentialTarget(provider))...)
+		if exitCode(err) == 2 {
+			return errNoKey
+		}
+	default:
+		_, err = keyringCommand(nil, "secret-tool", "clear", "service", KEYRING_SERVICE, "provider", provider)
+	}
+
+	return err
+}
+
+// Name of a provider's entry in the Windows Credential Manager
+func credentialTarget(provider string) string {
+	return KEYRING_SERVICE + ":" + provider
+}
+
+// Arguments of powershell that run a script with its own arguments. Words
+// after -Command are joined into the command text rather than passed as
+// $args, so the script is called as a block with each argument quoted.
+func powershellArgs(script string, args ...string) []string {
+	command := "& {" + script + "}"
+	for _, arg := range args {
+		command += " '" + strings.ReplaceAll(arg, "'", "''") + "'"
+	}
+
+	return []string{"-NoProfile", "-NonInteractive", "-Command", command}
+}
+
+// Runs a keyring tool, returning its output. Errors carry whatever the tool
+// printed on stderr.
+func keyringCommand(input io.Reader, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("no system keyring available, %s was not found", name)
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = input
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return output, fmt.Errorf("%s: %w: %s", name, err, message)
+		}
+
+		return output, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return output, nil
+}
+
+// Exit code of a command that failed, -1 for anything else
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// Provider names accepted by `weather auth`, including long forms
+var keyProviderNames = map[string]string{
+	"owm":            "owm",
+	"openweathermap": "owm",
+	"what3words":     "what3words",
+	"w3w":            "what3words",
+}
+
+// Reads an API key without echoing it when typed at a terminal, or the first
+// line of stdin when it is piped in
+func readSecret(ctx context.Context, prompt string) (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, prompt)
+
+		if runtime.GOOS != "windows" {
+			if _, err := stty("-echo"); err == nil {
+				defer func() {
+					stty("echo")
+					fmt.Fprintln(os.Stderr)
+				}()
+			}
+		}
+	}
+
+	// Read in the background so Ctrl+C still interrupts the prompt
+	type line struct {
+		text string
+		err  error
+	}
+	answer := make(chan line, 1)
+
+	go func() {
+		text, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer <- line{text, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case read := <-answer:
+		text := strings.TrimSpace(read.text)
+		if read.err != nil && !(errors.Is(read.err, io.EOF) && text != "") {
+			return "", fmt.Errorf("reading the key from stdin: %w", read.err)
+		}
+
+		if text == "" {
+			return "", errors.New("no key given")
+		}
+
+		return text, nil
+	}
+}
+
+// Implements `weather auth`
+func runAuth(ctx context.Context, configPath string, args []string) error {
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+
+	action := args[0]
+	if action == "status" {
+		settings, err := loadConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		for _, provider := range sortedKeys(keyedProviders) {
+			source := "not set"
+			if provider == "owm" {
+				source = "shared app key"
+			}
+
+			if provider == "owm" && os.Getenv("WEATHER_API_KEY") != "" {
+				source = "WEATHER_API_KEY"
+			} else if settings.APIKeys[provider] != "" {
+				source = "config file " + configPath
+			} else if _, err := keyringGet(provider); err == nil {
+				source = "system keyring"
+			} else if !errors.Is(err, errNoKey) {
+				source = "unknown, " + err.Error()
+			}
+
+			fmt.Printf("%s: %s\n", provider, source)
+		}
+
+		return nil
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("usage: weather auth %s <provider>", action)
+	}
+
+	provider, found := keyProviderNames[strings.ToLower(args[1])]
+	if !found {
+		return fmt.Errorf("unknown provider %q, only openweathermap (owm) and what3words (w3w) take a key", args[1])
+	}
+
+	switch action {
+	case "set":
+		key, err := readSecret(ctx, "API key for "+args[1]+": ")
+		if err != nil {
+			return err
+		}
+
+		if err := keyringSet(provider, key); err != nil {
+			return fmt.Errorf("storing the key: %w", err)
+		}
+
+		status("[+] Stored the " + provider + " key in the system keyring")
+	case "delete":
+		err := keyringDelete(provider)
+		if errors.Is(err, errNoKey) {
+			return fmt.Errorf("no %s key is stored in the system keyring", provider)
+		}
+		if err != nil {
+			return fmt.Errorf("removing the key: %w", err)
+		}
+
+		status("[+] Removed the " + provider + " key from the system keyring")
+	default:
+		return fmt.Errorf("unknown auth action %q, expected set, delete or status", action)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// The Credential Manager script gets its action and target as $args, quoted
+// so PowerShell doesn't read them as part of the command
+func TestPowershellArgs(t *testing.T) {
+	args := powershellArgs(CREDMAN_SCRIPT, "get", credentialTarget("owm"))
+	if len(args) != 4 || args[2] != "-Command" {
+		t.Fatalf("powershellArgs = %q, want -NoProfile -NonInteractive -Command <command>", args)
+	}
+
+	command := args[3]
+	if !strings.HasPrefix(command, "& {"+CREDMAN_SCRIPT+"}") {
+		t.Errorf("command doesn't call the script as a block: %q", command[:min(len(command), 40)])
+	}
+	if want := "} 'get' 'weather-cli:owm'"; !strings.HasSuffix(command, want) {
+		t.Errorf("command ends with %q, want %q", command[len(command)-min(len(command), 30):], want)
+	}
+
+	quoted := powershellArgs("", "set", "it's")[3]
+	if want := "& {} 'set' 'it''s'"; quoted != want {
+		t.Errorf("powershellArgs quoted %q, want %q", quoted, want)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File in the data directory holding the most recently used location
+const LAST_FILE = "last.json"
+
+// Location argument that stands for the most recently used location
+const LAST_LOCATION = "last"
+
+// Stores a location as the one `last` refers to. Like the history it is
+// skipped with -no-history, and failing to save never fails the lookup.
+func rememberLocation(place location) {
+	if !recordHistory {
+		return
+	}
+
+	if err := saveLastLocation(place); err != nil {
+		logger.Debug("could not remember location", "error", err)
+	}
+}
+
+func saveLastLocation(place location) error {
+	path, err := dataPath(LAST_FILE)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+
+	data, err := json.Marshal(place)
+	if err != nil {
+		return err
+	}
+
+	// Write beside the old file and rename, so a crash never leaves half a file
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", LAST_FILE, err)
+	}
+
+	return os.Rename(temporary, path)
+}
+
+// Reads the most recently used location
+func loadLastLocation() (location, error) {
+	path, err := dataPath(LAST_FILE)
+	if err != nil {
+		return location{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return location{}, errors.New("no location used yet, look one up first")
+	}
+	if err != nil {
+		return location{}, fmt.Errorf("reading %s: %w", LAST_FILE, err)
+	}
+
+	var place location
+	if err := json.Unmarshal(data, &place); err != nil {
+		return location{}, fmt.Errorf("parsing %s: %w", LAST_FILE, err)
+	}
+
+	return place, nil
+}
+
+// Whether a location argument asks for the most recently used location
+func isLastLocation(query string) bool {
+	return strings.EqualFold(strings.TrimSpace(query), LAST_LOCATION)
+}
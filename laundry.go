@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Hours of forecast the laundry score looks at
+const LAUNDRY_HOURS = 6
+
+// Vapor pressure deficit in kPa and wind in km/h from which drying doesn't
+// get faster, the wind just tugs at the pegs
+const (
+	BEST_DRYING_DEFICIT = 2.0
+	BEST_DRYING_KMH     = 20.0
+)
+
+// Verdicts of the laundry score from their lower bound, best first
+var laundryVerdicts = []struct {
+	From    float64
+	Verdict string
+}{
+	{75, "Perfect drying weather, hang it all out"},
+	{50, "Good enough, hang it out"},
+	{30, "Slow drying, only thin things will get there"},
+	{0, "Dry it indoors"},
+}
+
+// How dry the air is, as the difference in kPa between the water vapor it
+// could hold and what it holds. Clothes dry by it, not by the temperature.
+func vaporPressureDeficit(tempC float64, humidity int64) float64 {
+	saturation := 0.6108 * math.Exp(17.27*tempC/(tempC+237.3))
+
+	return saturation * (1 - float64(humidity)/100)
+}
+
+// How well laundry dries outside in the hour, from 0 to 100
+func laundryScore(hour conditions, units unitSystem) float64 {
+	tempC := units.toCelsius(hour.Temp)
+
+	score := math.Min(vaporPressureDeficit(tempC, hour.Humidity)/BEST_DRYING_DEFICIT, 1) * 60
+
+	wind := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(hour.WindSpeed))
+	score += math.Min(wind/BEST_DRYING_KMH, 1) * 25
+
+	// Sunshine warms the fabric, which the air temperature doesn't show
+	if !hour.Condition.Night {
+		score += float64(100-hour.Clouds) * 0.15
+	}
+
+	// Frozen laundry does dry, just very slowly
+	if tempC < 0 {
+		score /= 2
+	}
+
+	score -= hour.Pop * 60
+	if hour.Condition.Kind.precipitating() {
+		score = 0
+	}
+
+	return math.Max(0, math.Min(100, score))
+}
+
+// Implements `weather laundry`, judging from the next LAUNDRY_HOURS whether
+// hanging clothes outside makes sense
+func runLaundry(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	options := result.Options
+	temperature := options.Units.temperature()
+	moments := result.Weather.upcoming(LAUNDRY_HOURS)
+
+	total := 0.0
+	var rain *conditions
+	rows := [][]string{translated("Time", "Score", "Temp", "Humidity", "Wind", "Rain chance")}
+	for index, moment := range moments {
+		score := laundryScore(moment, options.Units)
+		total += score
+
+		if rain == nil && (moment.Pop >= RAIN_LIKELY || moment.Condition.Kind.precipitating()) {
+			rain = &moments[index]
+		}
+
+		rows = append(rows, []string{
+			moment.Time.Format(options.clockFormat()),
+			fmt.Sprintf("%.0f", score),
+			fmt.Sprintf("%.1f%s", moment.Temp, temperature),
+			fmt.Sprintf("%d%%", moment.Humidity),
+			options.wind(moment.WindSpeed, 1),
+			fmt.Sprintf("%.0f%%", moment.Pop*100),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+	fmt.Println()
+
+	score := total / float64(len(moments))
+
+	// Rain on the way overrides however well it dries until then
+	if rain != nil {
+		fmt.Printf("👕 "+tr("Laundry score %.0f/100: keep it indoors, rain is likely from %s")+"\n", score, rain.Time.Format(options.clockFormat()))
+		return nil
+	}
+
+	for _, verdict := range laundryVerdicts {
+		if score >= verdict.From {
+			fmt.Printf("👕 "+tr("Laundry score %.0f/100: %s")+"\n", score, tr(verdict.Verdict))
+			break
+		}
+	}
+
+	return nil
+}
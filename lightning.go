@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Blitzortung's live strike feeds, tried in turn
+var blitzortungServers = []string{"wss://ws1.blitzortung.org/", "wss://ws7.blitzortung.org/", "wss://ws8.blitzortung.org/"}
+
+// Message that subscribes to the worldwide feed
+const BLITZORTUNG_SUBSCRIBE = `{"a":111}`
+
+// Default radius in kilometers, or miles with imperial units
+const DEFAULT_LIGHTNING_RADIUS = 100
+
+// Default time spent listening for strikes; the feed only carries new ones
+const DEFAULT_LIGHTNING_LISTEN = 2 * time.Minute
+
+// Pause before a lost feed is joined again while watching
+const LIGHTNING_RECONNECT_DELAY = 5 * time.Second
+
+// One located strike
+type lightningStrike struct {
+	Time int64   `json:"time"` // Unix nanoseconds
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// Undoes the LZW-like compression Blitzortung applies to every message.
+// Characters from 256 up stand for strings seen earlier in the message.
+func decodeBlitzortung(data string) string {
+	chars := []rune(data)
+	if len(chars) == 0 {
+		return ""
+	}
+
+	dictionary := map[rune]string{}
+	current := string(chars[0])
+	previous := current
+	next := rune(256)
+
+	var decoded strings.Builder
+	decoded.WriteString(current)
+	for _, char := range chars[1:] {
+		entry, known := dictionary[char]
+		switch {
+		case char < 256:
+			entry = string(char)
+		case !known:
+			entry = previous + current
+		}
+
+		decoded.WriteString(entry)
+		current = string([]rune(entry)[0])
+		dictionary[next] = previous + current
+		next++
+		previous = entry
+	}
+
+	return decoded.String()
+}
+
+// Listens to the live feed until the context ends, handing every strike to
+// found. Servers are tried in turn until one answers.
+func listenForStrikes(ctx context.Context, found func(lightningStrike)) error {
+	var feed *webSocket
+	var err error
+	for _, server := range blitzortungServers {
+		if feed, err = dialWebSocket(ctx, server); err == nil {
+			break
+		}
+		logger.Debug("lightning feed unavailable", "server", server, "error", err)
+	}
+	if err != nil {
+		return fmt.Errorf("joining the Blitzortung lightning feed: %w", err)
+	}
+	defer feed.close()
+
+	if err := feed.send(BLITZORTUNG_SUBSCRIBE); err != nil {
+		return fmt.Errorf("subscribing to the Blitzortung lightning feed: %w", err)
+	}
+
+	for {
+		message, err := feed.read()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading the Blitzortung lightning feed: %w", err)
+		}
+
+		var strike lightningStrike
+		if err := json.Unmarshal([]byte(decodeBlitzortung(message)), &strike); err != nil {
+			logger.Debug("unreadable lightning message", "error", err)
+			continue
+		}
+
+		found(strike)
+	}
+}
+
+// Implements `weather lightning`, the strikes located within the radius of
+// the place while listening. Watching keeps listening until interrupted and
+// rings the terminal bell for strikes within the alert distance.
+func runLightning(ctx context.Context, target location, radius float64, alert float64, listen time.Duration, watch bool, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	// Distances are given in miles with imperial units
+	toKm := func(distance float64) float64 {
+		if options.Units == IMPERIAL {
+			return distance * 1.609344
+		}
+		return distance
+	}
+	radiusKm, alertKm := toKm(radius), toKm(alert)
+	clock := "15:04:05"
+	if options.Clock12 {
+		clock = "3:04:05 PM"
+	}
+
+	var strikes []lightningStrike
+	nearby := func(strike lightningStrike) {
+		at := coordinate{Lat: strike.Lat, Lon: strike.Lon}
+		distance := distanceKm(target.Coord, at)
+		if distance > radiusKm {
+			return
+		}
+
+		if !watch {
+			strikes = append(strikes, strike)
+			return
+		}
+
+		line := fmt.Sprintf("%s  ⚡ %s %s", options.in(time.Unix(0, strike.Time)).Format(clock),
+			formatDistance(distance, options.Units), compassDirection(int64(bearingDegrees(target.Coord, at))))
+		if alert > 0 && distance <= alertKm {
+			line = "\a⚠️  " + line + " — " + tr("lightning close by")
+		}
+		fmt.Println(line)
+	}
+
+	if watch {
+		status(fmt.Sprintf("[@] Watching for lightning within %s, Ctrl+C to stop", formatDistance(radiusKm, options.Units)))
+		for ctx.Err() == nil {
+			if err := listenForStrikes(ctx, nearby); err != nil {
+				status("[!] " + err.Error())
+				select {
+				case <-ctx.Done():
+				case <-time.After(LIGHTNING_RECONNECT_DELAY):
+				}
+			}
+		}
+		return nil
+	}
+
+	status(fmt.Sprintf("[@] Listening for lightning within %s for %s", formatDistance(radiusKm, options.Units), listen))
+	listening, cancel := context.WithTimeout(ctx, listen)
+	defer cancel()
+
+	if err := listenForStrikes(listening, nearby); err != nil {
+		return err
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ctx.Err()
+	}
+
+	if len(strikes) == 0 {
+		fmt.Printf(tr("No lightning within %s in the last %s.")+"\n", formatDistance(radiusKm, options.Units), listen)
+		return nil
+	}
+
+	closest := strikes[0]
+	rows := [][]string{translated("Time", "Distance", "Direction")}
+	for _, strike := range strikes {
+		at := coordinate{Lat: strike.Lat, Lon: strike.Lon}
+		if distanceKm(target.Coord, at) < distanceKm(target.Coord, coordinate{Lat: closest.Lat, Lon: closest.Lon}) {
+			closest = strike
+		}
+
+		rows = append(rows, []string{
+			options.in(time.Unix(0, strike.Time)).Format(clock),
+			formatDistance(distanceKm(target.Coord, at), options.Units),
+			compassDirection(int64(bearingDegrees(target.Coord, at))),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	at := coordinate{Lat: closest.Lat, Lon: closest.Lon}
+	fmt.Printf("\n"+tr("%d strikes, the closest %s %s at %s")+"\n", len(strikes),
+		formatDistance(distanceKm(target.Coord, at), options.Units), compassDirection(int64(bearingDegrees(target.Coord, at))),
+		options.in(time.Unix(0, closest.Time)).Format(clock))
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// One half of a coordinate: decimal degrees, or degrees with minutes and
+// seconds, with the hemisphere before or after
+const COORDINATE_PART = `([NSEW])?\s*(-?\d+(?:\.\d+)?)\s*(?:°\s*(?:(\d+(?:\.\d+)?)\s*'\s*(?:(\d+(?:\.\d+)?)\s*")?)?)?\s*([NSEW])?`
+
+// Two halves separated by a comma or a space
+var coordinatePattern = regexp.MustCompile(`(?i)^\s*` + COORDINATE_PART + `\s*(?:,|\s)\s*` + COORDINATE_PART + `\s*$`)
+
+// Typographic marks people paste from maps and web pages, and their plain
+// equivalents
+var coordinateMarks = strings.NewReplacer("′", "'", "’", "'", "‘", "'", "´", "'", "″", `"`, "”", `"`, "“", `"`, "''", `"`, "º", "°", "˚", "°")
+
+// Parses coordinates such as "40.4461,-79.9822", "40.4461 -79.9822",
+// "40.4461N 79.9822W" or 40°26'46"N 79°58'56"W into a coordinate. With
+// hemispheres given the longitude may come first.
+func parseCoordinate(text string) (coordinate, bool) {
+	match := coordinatePattern.FindStringSubmatch(coordinateMarks.Replace(text))
+	if match == nil {
+		return coordinate{}, false
+	}
+
+	first, firstHemisphere, ok := coordinateValue(match[1:6])
+	if !ok {
+		return coordinate{}, false
+	}
+
+	second, secondHemisphere, ok := coordinateValue(match[6:11])
+	if !ok {
+		return coordinate{}, false
+	}
+
+	longitudeFirst := func() bool {
+		return firstHemisphere == "E" || firstHemisphere == "W" || secondHemisphere == "N" || secondHemisphere == "S"
+	}
+
+	if longitudeFirst() {
+		first, second = second, first
+		firstHemisphere, secondHemisphere = secondHemisphere, firstHemisphere
+	}
+
+	// Still mixed up, as in "40N 79S"
+	if longitudeFirst() {
+		return coordinate{}, false
+	}
+
+	if first < -90 || first > 90 || second < -180 || second > 180 {
+		return coordinate{}, false
+	}
+
+	return coordinate{Lat: first, Lon: second}, true
+}
+
+// Converts the hemisphere, degrees, minutes, seconds and hemisphere captured
+// for one half into signed degrees and the upper case hemisphere, if any
+func coordinateValue(parts []string) (float64, string, bool) {
+	prefix, degreesText, minutesText, secondsText, suffix := parts[0], parts[1], parts[2], parts[3], parts[4]
+	if prefix != "" && suffix != "" {
+		return 0, "", false
+	}
+
+	negative := strings.HasPrefix(degreesText, "-")
+	hemisphere := strings.ToUpper(prefix + suffix)
+	if negative && hemisphere != "" {
+		return 0, "", false
+	}
+
+	degrees, err := strconv.ParseFloat(strings.TrimPrefix(degreesText, "-"), 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	for index, text := range []string{minutesText, secondsText} {
+		if text == "" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || value >= 60 {
+			return 0, "", false
+		}
+
+		degrees += value / math.Pow(60, float64(index+1))
+	}
+
+	if negative || hemisphere == "S" || hemisphere == "W" {
+		degrees = -degrees
+	}
+
+	return degrees, hemisphere, true
+}
+
+// Nicknames for places from the config file, keyed in lower case
+var aliases = map[string]string{}
+
+// Looks up a configured alias, returning the query it stands for
+func lookupAlias(query string) (string, bool) {
+	target, found := aliases[strings.ToLower(strings.TrimSpace(query))]
+
+	return target, found
+}
+
+// Turns a location argument into a place without prompting: aliases are
+// expanded, "last" is the most recently used place, coordinates are used as
+// they are and names, airport and postal codes resolve to the best match
+func resolveLocation(ctx context.Context, query string) (location, error) {
+	if target, found := lookupAlias(query); found {
+		place, err := resolveQuery(ctx, target)
+		if err != nil {
+			return place, fmt.Errorf("alias %q: %w", query, err)
+		}
+
+		// Keep the nickname as the displayed name for coordinates
+		if place.CompactName == "" {
+			place.CompactName = query
+		}
+
+		return place, nil
+	}
+
+	if isLastLocation(query) {
+		return loadLastLocation()
+	}
+
+	return resolveQuery(ctx, query)
+}
+
+// Resolves coordinates, an airport or postal code or a place name, without
+// alias expansion
+func resolveQuery(ctx context.Context, query string) (location, error) {
+	if coord, ok := parseCoordinate(query); ok {
+		return location{Coord: coord, Name: query}, nil
+	}
+
+	searched, err := searchLocations(ctx, query)
+	if err != nil {
+		return location{}, err
+	}
+
+	if len(searched.Lists) == 0 {
+		return location{}, fmt.Errorf("no location found for %q", query)
+	}
+
+	return searched.Lists[0], nil
+}
+
+// Searches for places by name, or by airport code, postal code, Plus Code or
+// what3words address when the query is one. Names are looked up in the offline city list first.
+func searchLocations(ctx context.Context, query string) (locationSearchResult, error) {
+	if found, ok := findAirport(query); ok {
+		return locationSearchResult{Count: 1, Lists: []location{found.location()}}, nil
+	}
+
+	if code, country, ok := parsePostalCode(query); ok {
+		return findPostalCode(ctx, code, country)
+	}
+
+	if code, locality, ok := parsePlusCode(query); ok {
+		return findPlusCode(ctx, code, locality)
+	}
+
+	if words, ok := parseThreeWords(query); ok {
+		return findThreeWords(ctx, words)
+	}
+
+	if offlineCities {
+		if found := findCities(query); len(found) > 0 {
+			logger.Debug("found in the offline city list", "query", query, "matches", len(found))
+			return locationSearchResult{Count: len(found), Lists: found}, nil
+		}
+	}
+
+	return locationName(query).findCoordinate(ctx)
+}
+
+// Orders search results can be listed in
+const (
+	RELEVANCE_ORDER  = "relevance"
+	DISTANCE_ORDER   = "distance"
+	POPULATION_ORDER = "population"
+)
+
+// Values accepted by search -sort
+var searchOrders = []string{RELEVANCE_ORDER, DISTANCE_ORDER, POPULATION_ORDER}
+
+// Narrows search results to a country, drops repeated entries and keeps at
+// most limit of them, all when limit is 0
+func (l locationSearchResult) refine(country string, limit int) locationSearchResult {
+	var kept []location
+	seen := map[string]bool{}
+
+	for _, match := range l.Lists {
+		if country != "" && !strings.EqualFold(match.Country, country) {
+			continue
+		}
+
+		// The online search lists some places twice, a few metres apart
+		key := fmt.Sprintf("%s|%.2f|%.2f", strings.ToLower(match.FullName), match.Coord.Lat, match.Coord.Lon)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		kept = append(kept, match)
+	}
+
+	if limit > 0 && len(kept) > limit {
+		kept = kept[:limit]
+	}
+
+	l.Lists = kept
+	l.Count = len(kept)
+
+	return l
+}
+
+// Implements `weather search`, listing matches or letting the user pick one.
+// Matches can be limited to a country and to a number of entries, and sorted
+// by distance from the user or by population instead of the geocoder's order.
+func runSearch(ctx context.Context, query string, pick bool, country string, order string, limit int, units string) error {
+	// Aliases resolve without a prompt
+	if _, found := lookupAlias(query); found && pick {
+		target, err := resolveLocation(ctx, query)
+		if err != nil {
+			return err
+		}
+		rememberLocation(target)
+
+		result, err := fetchReport(ctx, target, units)
+		if err != nil {
+			return err
+		}
+
+		result.show()
+		return nil
+	}
+
+	if country != "" && (len(country) != 2 || strings.ContainsFunc(country, func(char rune) bool { return !unicode.IsLetter(char) })) {
+		return fmt.Errorf("-country takes a two letter code such as NP, not %q", country)
+	}
+
+	if !slices.Contains(searchOrders, order) {
+		return fmt.Errorf("unknown order %q, expected one of %s", order, strings.Join(searchOrders, ", "))
+	}
+
+	matches, err := searchLocations(ctx, query)
+	if err != nil {
+		return err
+	}
+	matches = matches.refine(country, 0)
+
+	// The best matches may all be elsewhere, so ask again within the country
+	if len(matches.Lists) == 0 && country != "" && !strings.Contains(query, ",") {
+		matches, err = searchLocations(ctx, query+","+country)
+		if err != nil {
+			return err
+		}
+		matches = matches.refine(country, 0)
+	}
+
+	var origin location
+	switch order {
+	case DISTANCE_ORDER:
+		origin, err = ownLocation(ctx)
+		if err != nil {
+			return fmt.Errorf("sorting by distance needs your location: %w", err)
+		}
+
+		sort.SliceStable(matches.Lists, func(i, j int) bool {
+			return distanceKm(origin.Coord, matches.Lists[i].Coord) < distanceKm(origin.Coord, matches.Lists[j].Coord)
+		})
+	case POPULATION_ORDER:
+		// Places of unknown size, from the online geocoder, come last
+		sort.SliceStable(matches.Lists, func(i, j int) bool {
+			return matches.Lists[i].Population > matches.Lists[j].Population
+		})
+	}
+
+	// Only now, so the limit keeps the nearest or largest places
+	matches = matches.refine("", limit)
+
+	if pick {
+		chosen, err := matches.choose(ctx)
+		if err != nil {
+			return err
+		}
+		rememberLocation(chosen)
+
+		result, err := fetchReport(ctx, chosen, units)
+		if err != nil {
+			return err
+		}
+
+		result.show()
+		return nil
+	}
+
+	if len(matches.Lists) == 0 {
+		return errors.New("no matching locations found")
+	}
+
+	header := translated("#", "Location", "Country", "Latitude", "Longitude")
+	switch order {
+	case DISTANCE_ORDER:
+		header = append(header, tr("Distance"))
+	case POPULATION_ORDER:
+		header = append(header, tr("Population"))
+	}
+
+	display, err := resolveDisplay(units, origin.Country)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{header}
+	for index, match := range matches.Lists {
+		row := []string{
+			strconv.Itoa(index + 1),
+			match.FullName,
+			match.Country,
+			fmt.Sprintf("%.4f", match.Coord.Lat),
+			fmt.Sprintf("%.4f", match.Coord.Lon),
+		}
+
+		switch {
+		case order == DISTANCE_ORDER:
+			row = append(row, formatDistance(distanceKm(origin.Coord, match.Coord), display.Units))
+		case order == POPULATION_ORDER && match.Population > 0:
+			row = append(row, formatThousands(int(match.Population)))
+		case order == POPULATION_ORDER:
+			row = append(row, "")
+		}
+
+		rows = append(rows, row)
+	}
+
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+)
+
+// Query parameters that carry credentials and must never reach the logs
+var secretParams = []string{"appid", "deviceid", "token", "key", "apikey"}
+
+// Structured debug logger; discards everything unless --debug is given
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Switches the logger to verbose output on stderr
+func enableDebug() {
+	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// Replaces credential values in a URL so it can be logged safely
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "<unparsable url>"
+	}
+
+	query := parsed.Query()
+	for _, param := range secretParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+	parsed.RawQuery = query.Encode()
+
+	if parsed.User != nil {
+		parsed.User = url.User("REDACTED")
+	}
+
+	return parsed.String()
+}
+
+// Redacts the URL embedded in errors returned by the HTTP client
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(urlErr.URL)
+	}
+
+	return err
+}
@@ -2,14 +2,16 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -29,102 +31,7 @@ type location struct {
 	FullName    string     `json:"full_name"`
 	CompactName string     `json:"compact_name"`
 	Country     string     `json:"country"`
-}
-
-// These define schema for a searched response for a location
-type locationSearchResult struct {
-	Message string     `json:"message"`
-	Cod     string     `json:"cod"`
-	Count   int        `json:"count"`
-	Lists   []location `json:"list"`
-}
-
-type weatherCondition struct {
-	ID          int64  `json:"id"`
-	Main        string `json:"main"`
-	Description string `json:"description"`
-	Icon        string `json:"icon"`
-}
-
-type forecast struct {
-	Dt            int64   `json:"dt"`
-	Temp          float64 `json:"temp"`
-	Precipitation float64 `json:"precipitation"`
-}
-
-type rainInfo struct {
-	OneH float64 `json:"1h"`
-}
-
-type currentWeather struct {
-	Dt         int64              `json:"dt"`
-	Sunrise    int64              `json:"sunrise"`
-	Sunset     int64              `json:"sunset"`
-	Temp       float64            `json:"temp"`
-	FeelsLike  float64            `json:"feels_like"`
-	Pressure   int64              `json:"pressure"`
-	Humidity   int64              `json:"humidity"`
-	DewPoint   float64            `json:"dew_point"`
-	UVI        float64            `json:"uvi"`
-	Clouds     int64              `json:"clouds"`
-	Visibility int64              `json:"visibility"`
-	WindSpeed  float64            `json:"wind_speed"`
-	WindDeg    int64              `json:"wind_deg"`
-	WindGust   float64            `json:"wind_gust"`
-	Weather    []weatherCondition `json:"weather"`
-}
-
-type minutelyForecast struct {
-	Dt            int64   `json:"dt"`
-	Precipitation float64 `json:"precipitation"`
-}
-
-type hourlyForecast struct {
-	Dt         int64              `json:"dt"`
-	Temp       float64            `json:"temp"`
-	FeelsLike  float64            `json:"feels_like"`
-	Pressure   int64              `json:"pressure"`
-	Humidity   int64              `json:"humidity"`
-	DewPoint   float64            `json:"dew_point"`
-	UVI        float64            `json:"uvi"`
-	Clouds     int64              `json:"clouds"`
-	Visibility int64              `json:"visibility"`
-	WindSpeed  float64            `json:"wind_speed"`
-	WindDeg    int64              `json:"wind_deg"`
-	WindGust   float64            `json:"wind_gust"`
-	Weather    []weatherCondition `json:"weather"`
-	Pop        float64            `json:"pop"`
-	Rain       *rainInfo          `json:"rain,omitempty"`
-}
-
-type dailyForecast struct {
-	Dt            int64              `json:"dt"`
-	Sunrise       int64              `json:"sunrise"`
-	Sunset        int64              `json:"sunset"`
-	TempMax       float64            `json:"temp_max"`
-	TempMin       float64            `json:"temp_min"`
-	Pressure      int64              `json:"pressure"`
-	Humidity      int64              `json:"humidity"`
-	WindSpeed     float64            `json:"wind_speed"`
-	WindDeg       int64              `json:"wind_deg"`
-	WindGust      float64            `json:"wind_gust"`
-	Weather       []weatherCondition `json:"weather"`
-	Clouds        int64              `json:"clouds"`
-	Precipitation float64            `json:"precipitation"`
-	Pop           float64            `json:"pop"`
-	UVI           float64            `json:"uvi"`
-	Forecast      []forecast         `json:"forecast"`
-}
-
-type weatherData struct {
-	Lat            float64            `json:"lat"`
-	Lon            float64            `json:"lon"`
-	Timezone       string             `json:"timezone"`
-	TimezoneOffset float64            `json:"timezone_offset"`
-	Current        currentWeather     `json:"current"`
-	Minutely       []minutelyForecast `json:"minutely"`
-	Hourly         []hourlyForecast   `json:"hourly"`
-	Daily          []dailyForecast    `json:"daily"`
+	Population  int64      `json:"population,omitempty"` // Only known for the offline city list
 }
 
 type IPInfo struct {
@@ -143,221 +50,605 @@ type IPInfo struct {
 	Protected   bool    `json:"protected"`
 }
 
-const URL = "https://app.owm.io/app"
-
-// These are specific API keys
-const DEVICE_ID = "e13401912dbaf7cc"
-const APP_ID = "e0c56f6c3cee94d1a83f36043ff1ce5b"
-const TOKEN = DEVICE_ID + ":APA91bGAmF46L0bGb2jVYVfVKNpWePUqWdgoo4hz8_LLkfECQ8qw8JdcA-8hsJ6WSgjfEY5CvgjNoYMYF8PLvGlJ9GFM2ERKnKWjBR_Hq2tjsuZABJ_io3c"
-
-var weatherIconEmojis = map[string]string{
-	"01d": "☀️",
-	"01n": "🌙",
-	"02d": "🌤️",
-	"02n": "🌥️",
-	"03d": "🌥️",
-	"03n": "☁️",
-	"04d": "☁️",
-	"04n": "☁️",
-	"09d": "🌦️",
-	"09n": "🌧️",
-	"10d": "🌦️",
-	"10n": "🌧️",
-	"11d": "⛈️",
-	"11n": "⛈️",
-	"13d": "🌨️",
-	"13n": "🌨️",
-	"50d": "🌫️",
-	"50n": "🌫️",
+// Suppresses progress messages when set with -q/--quiet
+var quiet bool
+
+// Prints a progress message to stderr, keeping stdout for data only
+func status(message string) {
+	// The progress bar stands in for per-item messages in bulk operations
+	if quiet || progressShown.Load() {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, message)
 }
 
-func fetch(url string) []byte {
-	// Create a client
-	client := http.Client{Timeout: time.Second * 10}
+// Search results are part of the interactive prompt, so they go to stderr
+func (l locationSearchResult) print() {
+	fmt.Fprintf(os.Stderr, "Total available locations: %d\n", l.Count)
+	for index, value := range l.Lists {
+		fmt.Fprintf(os.Stderr, "---------------[%d]----------------\n", index+1)
+
+		fmt.Fprintln(os.Stderr, "Country: "+value.Country)
+		fmt.Fprintln(os.Stderr, "Location: "+value.CompactName)
+		fmt.Fprintf(os.Stderr, "Latitude: %f\n", value.Coord.Lat)
+		fmt.Fprintf(os.Stderr, "Longitude: %f\n\n", value.Coord.Lon)
+	}
+}
 
-	// Defer the connections closing part
-	defer client.CloseIdleConnections()
+// Asks the user to pick one of the searched locations, with the fuzzy
+// picker on a terminal and a numbered prompt otherwise
+func (l locationSearchResult) choose(ctx context.Context) (location, error) {
+	if len(l.Lists) == 0 {
+		return location{}, errors.New("no matching locations found")
+	}
 
-	// Create a request
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		fmt.Println("Failed to create a new request.")
-		fmt.Println(err)
-		os.Exit(1)
+	if canPick() {
+		return l.pick(ctx)
 	}
 
-	// Make the request
-	res, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Failed to send request to " + URL)
-		fmt.Println(err)
-		os.Exit(2)
+	return l.chooseByIndex(ctx)
+}
+
+// Prints every match and reads the chosen number from stdin
+func (l locationSearchResult) chooseByIndex(ctx context.Context) (location, error) {
+	l.print()
+
+	fmt.Fprint(os.Stderr, "\nChoose searched index: ")
+
+	// Read in the background so Ctrl+C still interrupts the prompt
+	type line struct {
+		text string
+		err  error
 	}
+	answer := make(chan line, 1)
 
-	// Defer the body (stream) closing part
-	defer res.Body.Close()
+	go func() {
+		text, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer <- line{text, err}
+	}()
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		fmt.Println("Failed to read response body")
-		fmt.Println(err)
-		os.Exit(3)
+	var text string
+
+	select {
+	case <-ctx.Done():
+		return location{}, ctx.Err()
+	case read := <-answer:
+		if read.err != nil {
+			return location{}, fmt.Errorf("reading chosen index from stdin: %w", read.err)
+		}
+
+		text = strings.TrimSpace(read.text)
+	}
+
+	chosenIndex, err := strconv.Atoi(text)
+	if err != nil || chosenIndex > len(l.Lists) || chosenIndex <= 0 {
+		return location{}, fmt.Errorf("provided index %q is invalid or out of bounds", text)
 	}
 
-	return body
+	return l.Lists[chosenIndex-1], nil
 }
 
-func (l locationName) findCoordinate() locationSearchResult {
-	fmt.Println("[@] Searching for " + string(l))
+func (r report) print() {
+	w := r.Weather
+	options := r.Options
 
-	// URL to be used to make request
-	TARGET_URL := fmt.Sprintf("%s/1.1/find/?q=%s&appid=%s&deviceid=%s", URL, string(l), APP_ID, DEVICE_ID)
+	// Coordinates named by reverse geocoding say which place they are near
+	if name := firstNonEmpty(r.Location.FullName, r.Location.CompactName); name != "" {
+		fmt.Printf("\n%s: %s (Lat: %.4f, Lon: %.4f)\n", tr("Location"), name, w.Coord.Lat, w.Coord.Lon)
+		fmt.Printf("%s: %s\n", tr("Timezone"), w.Timezone)
+	} else {
+		fmt.Printf("\n%s: %s (Lat: %.4f, Lon: %.4f)\n", tr("Location"), w.Timezone, w.Coord.Lat, w.Coord.Lon)
+	}
+	fmt.Printf("%s: %d %s\n", tr("Timezone Offset"), w.Offset, tr("seconds"))
+	if options.Zone == time.Local {
+		fmt.Printf("%s: %s\n", tr("Times shown in"), tr("this machine's zone"))
+	} else if options.Zone != nil {
+		fmt.Printf("%s: %s\n", tr("Times shown in"), options.Zone)
+	}
+	if len(r.FailedProviders) > 0 {
+		fmt.Printf("%s: %s (%s)\n\n", tr("Source"), r.Provider, fmt.Sprintf(tr("after %s failed"), strings.Join(r.FailedProviders, ", ")))
+	} else {
+		fmt.Printf("%s: %s\n\n", tr("Source"), r.Provider)
+	}
 
-	body := fetch(TARGET_URL)
+	timeFormat := options.timeFormat()
+	dateFormat := "2006-01-02" // YYYY-MM-DD
 
-	// Parse the response to json
-	var parsedResponse locationSearchResult
-	err := json.Unmarshal(body, &parsedResponse)
-	if err != nil {
-		fmt.Println("Failed to marshal response to JSON")
-		fmt.Println(err)
-		fmt.Println(string(body))
-		os.Exit(4)
+	current := w.Current
+	temperature := options.Units.temperature()
+
+	fmt.Printf("%s  %s: \n", current.Condition.emoji(), tr("Current Weather"))
+	printField("Time", current.Time.Format(dateFormat)+" "+current.Time.Format(timeFormat))
+	printField("Temperature", fmt.Sprintf("%.2f%s", current.Temp, temperature))
+	printField("Feels Like", fmt.Sprintf("%.2f%s", current.FeelsLike, temperature))
+	if r.Yesterday != "" {
+		printField("Vs Yesterday", r.Yesterday)
+	}
+	// Computed here, so they can differ from the provider's feels like
+	if index, ok := current.heatIndex(options.Units); ok {
+		printField("Heat Index", index.temperature(options.Units))
+	}
+	if chill, ok := current.windChill(options.Units); ok {
+		printField("Wind Chill", chill.temperature(options.Units))
 	}
+	if r.PressureTrend != "" {
+		printField("Pressure", options.Pressure.format(float64(current.Pressure))+" "+r.PressureTrend)
+	} else {
+		printField("Pressure", options.Pressure.format(float64(current.Pressure)))
+	}
+	printField("Humidity", fmt.Sprintf("%d%%", current.Humidity))
+	if showComfort {
+		printField("Comfort", tr(current.humidityComfort(options.Units)))
+	}
+	printField("Dew Point", fmt.Sprintf("%.2f%s (%s)", current.DewPoint, temperature, tr(current.dewPointComfort(options.Units))))
+	printField("UV Index", fmt.Sprintf("%.2f (%s)", current.UVI, uvAdvice(current.UVI)))
+	printField("Clouds", fmt.Sprintf("%d%%", current.Clouds))
+	if amount, ok := w.nextHourPrecipitation(); ok {
+		printField("Precipitation", fmt.Sprintf(tr("%s in the next hour"), options.Units.formatPrecipitation(amount)))
+	}
+	if nowcast := w.nowcast(options); nowcast != "" {
+		printField("Next Hour", nowcast)
+	}
+	if current.Visibility > 0 {
+		printField("Visibility", fmt.Sprintf("%d m", current.Visibility))
+	}
+	printField("Wind Speed", options.wind(current.WindSpeed, 2))
+	printField("Wind Degrees", fmt.Sprintf("%d°", current.WindDeg))
+	if current.WindGust > 0 {
+		printField("Wind Gust", options.wind(current.WindGust, 2))
+	}
+	if index := current.fireIndex(options.Units); index >= FIRE_DANGER_SHOWN {
+		printField("Fire Danger", fmt.Sprintf("%s (%s %.0f)", tr(fireDanger(index, false)), tr("Fosberg index"), index))
+	}
+	if w.wintry(TIPS_HOURS, options.Units) {
+		if summary := roadSummary(w.roadForecast(TIPS_HOURS, nil, options.Units), options.clockFormat()); summary != "" {
+			printField("Road Warnings", summary)
+		}
+	}
+	if spells := w.fogSpells(TIPS_HOURS, options.Units); len(spells) > 0 {
+		printField("Fog Warning", "⚠️  "+spells[0].describe(current.Time, options))
+	}
+	w.printAstronomy(options)
+	printTips(w.tips(options.Units))
 
-	return parsedResponse
+	fmt.Println("-----------------------")
 }
 
-func (l locationSearchResult) print() {
-	fmt.Printf("Total available locations: %d\n", l.Count)
-	for index, value := range l.Lists {
-		fmt.Printf("---------------[%d]----------------\n", index+1)
+// Prints one line of the full report with its translated label, lining up
+// the values however long the label turns out
+func printField(label string, value string) {
+	fmt.Printf("%s %s\n", padRight(tr(label)+":", 20), value)
+}
+
+// Output styles for current conditions
+const (
+	FULL_STYLE    = "full"
+	COMPACT_STYLE = "compact"
+)
 
-		fmt.Println("Country: " + value.Country)
-		fmt.Println("Location: " + value.CompactName)
-		fmt.Printf("Latitude: %f\n", value.Coord.Lat)
-		fmt.Printf("Longitude: %f\n\n", value.Coord.Lon)
+// Accepted values of -style
+var outputStyles = []string{FULL_STYLE, COMPACT_STYLE}
+
+// How current conditions are printed
+var outputStyle = FULL_STYLE
+
+// Prints the report in the chosen output style
+func (r report) show() {
+	if outputStyle == COMPACT_STYLE {
+		r.printCompact()
+		return
 	}
+
+	r.print()
 }
 
-func (c coordinate) findWeather() weatherData {
-	fmt.Println("[@] Searching for weather")
+// Prints the current conditions on a single line
+func (r report) printCompact() {
+	current := r.Weather.Current
+	units := r.Options.Units
 
-	UNIT := "metric" // or "imperial"
+	name := r.Location.CompactName
+	if name == "" {
+		name = r.Location.Name
+	}
+	if name == "" {
+		name = fmt.Sprintf("%.4f,%.4f", r.Weather.Coord.Lat, r.Weather.Coord.Lon)
+	}
 
-	TARGET_URL := fmt.Sprintf("%s/1.0/weather/?lat=%f&lon=%f&units=%s&appid=%s&deviceid=%s&token=%s", URL, c.Lat, c.Lon, UNIT, APP_ID, DEVICE_ID, TOKEN)
+	fmt.Printf("%s: %s %.1f%s (%s %.1f%s), %s, %s %s, %s %d%%\n",
+		name, current.Condition.emoji(), current.Temp, units.temperature(), tr("feels"), current.FeelsLike, units.temperature(),
+		current.Condition.Description, tr("wind"), r.Options.wind(current.WindSpeed, 1), tr("humidity"), current.Humidity)
+}
 
-	body := fetch(TARGET_URL)
+func main() {
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+
+		fmt.Fprintf(out, "🌤️  weather: Know the weather from your command-line\n")
+		fmt.Fprintf(out, "Usage: weather [flags] <command> [command flags] [arguments]\n")
+		fmt.Fprintf(out, "       weather [flags] [location ...]  (same as weather now)\n\n")
+		fmt.Fprintf(out, "Commands:\n")
+
+		width := 0
+		for _, cmd := range commands {
+			if !cmd.Hidden {
+				width = max(width, len(cmd.Name))
+			}
+		}
+		for _, cmd := range commands {
+			if !cmd.Hidden {
+				fmt.Fprintf(out, "  %-*s  %s\n", width, cmd.Name, cmd.Summary)
+			}
+		}
+
+		fmt.Fprintf(out, "\nLocations are place names, aliases or lat,lon pairs. Run `weather help <command>` for its flags.\n\n")
+		fmt.Fprintf(out, "Global flags, accepted before or after the command:\n")
+
+		flag.PrintDefaults()
+	}
+
+	globals.register(flag.CommandLine)
+	flag.Parse()
 
-	var parsedResponse weatherData
-	err := json.Unmarshal(body, &parsedResponse)
+	// Anything that isn't a command is a location for `weather now`
+	args := flag.Args()
+	cmd, found := findCommand(flag.Arg(0))
+	if found {
+		args = args[1:]
+	} else if flag.Arg(0) == "help" {
+		if topic, found := findCommand(flag.Arg(1)); found {
+			topic.usage(os.Stdout)
+		} else {
+			flag.CommandLine.SetOutput(os.Stdout)
+			flag.Usage()
+		}
+		return
+	} else {
+		cmd, _ = findCommand("now")
+	}
+
+	flags := flag.NewFlagSet("weather "+cmd.Name, flag.ContinueOnError)
+	flags.Usage = func() { cmd.usage(flags.Output()) }
+	globals.register(flags)
+	run := cmd.Setup(flags)
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		exit(err)
+	}
+
+	if globals.Debug {
+		enableDebug()
+	}
+
+	settings, err := loadConfig(globals.ConfigPath)
 	if err != nil {
-		fmt.Println("Failed to marshal response to JSON")
-		fmt.Println(err)
-		fmt.Println(string(body))
-		os.Exit(4)
+		exit(err)
 	}
 
-	return parsedResponse
-}
+	// Flags win over the environment, which wins over the config file
+	profileName := globals.Profile
+	if profileName == "" {
+		profileName = os.Getenv("WEATHER_PROFILE")
+	}
+
+	chosen, err := settings.effective(profileName)
+	if err != nil {
+		exit(err)
+	}
 
-func (w weatherData) print() {
-	// Create location from timezone info
-	location := time.FixedZone(w.Timezone, int(w.TimezoneOffset))
+	rateLimits = settings.RateLimits
+	updateNotice = settings.UpdateNotice
+	recordHistory = !globals.NoHistory
+	showComfort = !globals.NoComfort && !settings.HideComfort
+	aliases = settings.Aliases
+	defaultLocation = firstEnv("WEATHER_LOCATION")
+	if defaultLocation == "" {
+		defaultLocation = chosen.Location
+	}
+	if globals.Coords != "" {
+		if _, ok := parseCoordinate(globals.Coords); !ok {
+			exit(fmt.Errorf("invalid -coords %q, expected e.g. 40.4461,-79.9822 or 40°26'46\"N 79°58'56\"W", globals.Coords))
+		}
+		defaultLocation = globals.Coords
+	}
 
-	fmt.Printf("\nLocation: %s (Lat: %.4f, Lon: %.4f)\n", w.Timezone, w.Lat, w.Lon)
-	fmt.Printf("Timezone Offset: %d seconds\n\n", int(w.TimezoneOffset))
+	owmAPIKey = firstEnv("WEATHER_API_KEY")
+	if owmAPIKey == "" {
+		owmAPIKey = settings.APIKeys["owm"]
+	}
+	what3wordsAPIKey = settings.APIKeys["what3words"]
 
-	timeFormat := "15:04:05 MST" // HH:MM:SS Timezone
-	dateFormat := "2006-01-02"   // YYYY-MM-DD
+	if err := setupHTTP(clientOptions{Proxy: globals.Proxy, CACert: globals.CACert, Insecure: globals.Insecure}); err != nil {
+		exit(err)
+	}
 
-	current := w.Current
+	// Then the profile, then the top-level settings
+	chain := DEFAULT_PROVIDERS
+	if globals.Providers != "" {
+		chain = globals.Providers
+	} else if env := firstEnv("WEATHER_PROVIDERS", "WEATHER_PROVIDER"); env != "" {
+		chain = env
+	} else if len(chosen.Providers) > 0 {
+		chain = strings.Join(chosen.Providers, ",")
+	}
 
-	dtTime := time.Unix(current.Dt, 0).In(location)
-	sunriseTime := time.Unix(current.Sunrise, 0).In(location)
-	sunsetTime := time.Unix(current.Sunset, 0).In(location)
-
-	fmt.Printf("%s  Current Weather: \n", weatherIconEmojis[current.Weather[0].Icon])
-	fmt.Printf("Time:                %s %s\n", dtTime.Format(dateFormat), dtTime.Format(timeFormat))
-	fmt.Printf("Sunrise:             %s\n", sunriseTime.Format(timeFormat))
-	fmt.Printf("Sunset:              %s\n", sunsetTime.Format(timeFormat))
-	fmt.Printf("Temperature:         %.2f°C\n", current.Temp)
-	fmt.Printf("Feels Like:          %.2f°C\n", current.FeelsLike)
-	fmt.Printf("Pressure:            %d hPa\n", current.Pressure)
-	fmt.Printf("Humidity:            %d%%\n", current.Humidity)
-	fmt.Printf("Dew Point:           %.2f°C\n", current.DewPoint)
-	fmt.Printf("UV Index:            %.2f\n", current.UVI)
-	fmt.Printf("Clouds:              %d%%\n", current.Clouds)
-	fmt.Printf("Visibility:          %d m\n", current.Visibility)
-	fmt.Printf("Wind Speed:          %.2f m/s\n", current.WindSpeed)
-	fmt.Printf("Wind Degrees:        %d°\n", current.WindDeg)
-	if current.WindGust > 0 {
-		fmt.Printf("Wind Gust:           %.2f m/s\n", current.WindGust)
+	activeProviders, err = parseProviders(chain)
+	if err != nil {
+		exit(err)
 	}
 
-	fmt.Println("-----------------------")
-}
+	geoChain := DEFAULT_GEO_SOURCES
+	if globals.GeoSource != "" {
+		geoChain = globals.GeoSource
+	} else if env := firstEnv("WEATHER_GEO_SOURCE"); env != "" {
+		geoChain = env
+	}
+
+	activeGeoSources, err = parseGeoSources(geoChain)
+	if err != nil {
+		exit(err)
+	}
+
+	// -privacy can only turn privacy mode on
+	privacyMode = settings.Privacy
+	if env := firstEnv("WEATHER_PRIVACY"); env != "" {
+		if privacyMode, err = strconv.ParseBool(env); err != nil {
+			exit(fmt.Errorf("WEATHER_PRIVACY must be true or false, not %q", env))
+		}
+	}
+	privacyMode = privacyMode || globals.Privacy
+
+	if privacyMode {
+		if activeGeoSources, err = privateSources(activeGeoSources, geoChain != DEFAULT_GEO_SOURCES); err != nil {
+			exit(err)
+		}
+	}
+
+	// The keyring is only asked when nothing else supplies the key, as
+	// every lookup starts a helper program
+	if owmAPIKey == "" && slices.Contains(activeProviders, "owm") {
+		key, err := keyringGet("owm")
+		if err == nil {
+			owmAPIKey = key
+		} else if !errors.Is(err, errNoKey) {
+			logger.Debug("keyring lookup failed", "error", err)
+		}
+	}
+
+	units := globals.Units
+	if units == "" {
+		units = firstEnv("WEATHER_UNITS")
+	}
+	if units == "" {
+		units = chosen.Units
+	}
+
+	// Reject bad units before doing any network work
+	if _, err := resolveDisplay(units, ""); err != nil {
+		exit(err)
+	}
+
+	wind := globals.Wind
+	if wind == "" {
+		wind = firstEnv("WEATHER_WIND")
+	}
+	if wind == "" {
+		wind = chosen.Wind
+	}
+	if wind != "" {
+		var ok bool
+		if windChoice, ok = parseWindUnit(wind); !ok {
+			exit(fmt.Errorf("unknown wind unit %q, expected one of %s", wind, strings.Join(windUnits, ", ")))
+		}
+	}
 
-func fetchUserCoordinates() coordinate {
-	fmt.Println("[@] Fetching your coordinates")
+	pressure := globals.Pressure
+	if pressure == "" {
+		pressure = firstEnv("WEATHER_PRESSURE")
+	}
+	if pressure == "" {
+		pressure = chosen.Pressure
+	}
+	if pressure != "" {
+		var ok bool
+		if pressureChoice, ok = parsePressureUnit(pressure); !ok {
+			exit(fmt.Errorf("unknown pressure unit %q, expected one of %s", pressure, strings.Join(pressureUnits, ", ")))
+		}
+	}
+
+	if globals.Style != "" {
+		outputStyle = globals.Style
+	} else if env := firstEnv("WEATHER_STYLE"); env != "" {
+		outputStyle = env
+	} else if chosen.Style != "" {
+		outputStyle = chosen.Style
+	}
+	if !slices.Contains(outputStyles, outputStyle) {
+		exit(fmt.Errorf("unknown style %q, expected one of %s", outputStyle, strings.Join(outputStyles, ", ")))
+	}
 
-	body := fetch("https://web-api.nordvpn.com/v1/ips/info")
+	if globals.TimeFormat != "" {
+		hourClock = globals.TimeFormat
+	} else if env := firstEnv("WEATHER_TIME_FORMAT"); env != "" {
+		hourClock = env
+	} else if chosen.TimeFormat != "" {
+		hourClock = chosen.TimeFormat
+	}
+	// "12h" and "24h" are common enough to accept too
+	if clock := strings.TrimSuffix(strings.ToLower(hourClock), "h"); slices.Contains(timeFormats, clock) {
+		hourClock = clock
+	} else {
+		exit(fmt.Errorf("unknown time format %q, expected one of %s", hourClock, strings.Join(timeFormats, ", ")))
+	}
 
-	var parsedResponse IPInfo
-	err := json.Unmarshal(body, &parsedResponse)
+	// A locale without a translation quietly stays in English, unlike a
+	// language asked for by name
+	if globals.Language != "" {
+		err = setLanguage(globals.Language)
+	} else if env := firstEnv("WEATHER_LANG"); env != "" {
+		err = setLanguage(env)
+	} else if chosen.Language != "" {
+		err = setLanguage(chosen.Language)
+	} else {
+		err = setLanguage(systemLanguage())
+	}
 	if err != nil {
-		fmt.Println("Failed to parse IP info")
-		fmt.Println(err)
-		os.Exit(10)
+		exit(err)
 	}
 
-	return coordinate{Lat: parsedResponse.Latitude, Lon: parsedResponse.Longitude}
+	// weather wear -offset beats both
+	if raw := firstNonEmpty(firstEnv("WEATHER_WEAR_OFFSET"), chosen.WearOffset); raw != "" {
+		if wearOffset, err = strconv.ParseFloat(raw, 64); err != nil {
+			exit(fmt.Errorf("wear offset must be a number of degrees, not %q", raw))
+		}
+	}
+
+	commuteDuration = firstNonEmpty(firstEnv("WEATHER_COMMUTE_DURATION"), chosen.CommuteDuration, commuteDuration)
+	commuteBetween = firstNonEmpty(firstEnv("WEATHER_COMMUTE_BETWEEN"), chosen.CommuteBetween, commuteBetween)
+
+	// weather agri -base beats both
+	if raw := firstNonEmpty(firstEnv("WEATHER_GDD_BASE"), chosen.GDDBase); raw != "" {
+		if gddBase, err = strconv.ParseFloat(raw, 64); err != nil {
+			exit(fmt.Errorf("growing degree day base must be a number of degrees, not %q", raw))
+		}
+	}
+
+	// weather degreedays -base beats both
+	if raw := firstNonEmpty(firstEnv("WEATHER_DEGREE_DAY_BASE"), chosen.DegreeDayBase); raw != "" {
+		if degreeDayBase, err = strconv.ParseFloat(raw, 64); err != nil {
+			exit(fmt.Errorf("degree day base must be a number of degrees, not %q", raw))
+		}
+	}
+
+	zone := globals.Zone
+	if globals.Local {
+		if zone != "" && !strings.EqualFold(zone, "local") {
+			exit(fmt.Errorf("-local and -tz %s contradict each other", zone))
+		}
+		zone = "local"
+	}
+	if zone == "" {
+		zone = firstEnv("WEATHER_TZ")
+	}
+	if zone != "" {
+		if displayZone, err = parseZone(zone); err != nil {
+			exit(err)
+		}
+	}
+
+	// Cancel in-flight requests on Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, flags.Args(), units); err != nil {
+		exit(err)
+	}
+
+	// Commands about versions or meant for scripts skip the notice
+	if !cmd.Hidden && cmd.Name != "version" && cmd.Name != "self-update" && cmd.Name != "completion" {
+		noticeUpdate(ctx)
+	}
 }
 
-func main() {
-	flag.Usage = func() {
-		fmt.Printf("🌤️  weather: Know the weather from your command-line\n")
+// Weather fetched for one location, along with how to display it
+type report struct {
+	Location      location
+	Weather       weatherData
+	Options       displayOptions
+	Provider      string
+	PressureTrend string
+	Yesterday     string // How now compares with the same time yesterday
+
+	// Providers tried before Provider answered
+	FailedProviders []string
+}
 
-		flag.PrintDefaults()
+// Fetches weather using the display options inferred for the location
+func fetchReport(ctx context.Context, target location, units string) (report, error) {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return report{}, err
 	}
 
-	search := flag.String("search", "", "Search for a location")
-	lat := flag.Float64("lat", 0.0, "Latitude of the location")
-	lon := flag.Float64("lon", 0.0, "Longitude of the location")
-	auto := flag.Bool("auto", false, "Automatically fetch your weather")
+	return fetchReportWith(ctx, target, options)
+}
 
-	flag.Parse()
+// Fetches weather with fixed display options and records it in the history
+func fetchReportWith(ctx context.Context, target location, options displayOptions) (report, error) {
+	weather, provider, failed, err := fetchWithFallback(ctx, target.Coord, options.Units)
+	if err != nil {
+		return report{}, err
+	}
+
+	result := report{Location: target, Weather: weather.in(options), Options: options, Provider: provider, FailedProviders: failed}
 
-	if *auto {
-		fetchUserCoordinates().findWeather().print()
-	} else if *search != "" {
-		searchedLocations := locationName(*search).findCoordinate()
+	// Compare against what was stored before this reading is added
+	if entries, err := loadHistory(); err == nil {
+		result.PressureTrend = pressureTrend(entries, weather.Coord, weather.Current.Time.Unix(), weather.Current.Pressure, options.Pressure)
+		result.Yesterday = yesterdayChange(entries, weather.Coord, weather.Current, options.Units)
+	}
 
-		searchedLocations.print()
+	recordReport(result)
 
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("\nChoose searched index: ")
+	return result, nil
+}
 
-		text, err := reader.ReadString('\n')
+// Fetches several locations in parallel and prints them in the order given
+func printMany(ctx context.Context, queries []string, units string) error {
+	results := runPool("Fetching weather", queries, func(query string) string { return query }, func(query string) (report, error) {
+		target, err := resolveLocation(ctx, query)
 		if err != nil {
-			fmt.Println("Failed to read from stdin")
-			fmt.Println(err)
-			os.Exit(7)
+			return report{}, err
 		}
 
-		text = strings.TrimSpace(text)
+		return fetchReport(ctx, target, units)
+	})
 
-		chosenIndex, err := strconv.Atoi(text)
-		if err != nil || chosenIndex > len(searchedLocations.Lists) || chosenIndex <= 0 {
-			fmt.Println("Provided index is invalid or out of bounds.")
-			os.Exit(8)
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			continue
 		}
 
-		searchedLocations.Lists[chosenIndex-1].Coord.findWeather().print()
-	} else if *lat != 0.0 && *lon != 0.0 {
-		newCoordinate := coordinate{Lat: *lat, Lon: *lon}
-		newCoordinate.findWeather().print()
-	} else {
-		flag.Usage()
+		result.Value.show()
 	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d locations failed", failed, len(queries))
+	}
+
+	return nil
+}
+
+// Ends the program with a status and no message, for commands that answer
+// through their exit status
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// Reports a failure and terminates; the only place the program exits with an error
+func exit(err error) {
+	var status exitStatus
+	if errors.As(err, &status) {
+		os.Exit(int(status))
+	}
+
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Interrupted")
+		os.Exit(130)
+	}
+
+	fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+	os.Exit(1)
 }
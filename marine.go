@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Waves, swell and sea temperature from Open-Meteo, which serves them
+// without a key like its weather
+const OPEN_METEO_MARINE_URL = "https://marine-api.open-meteo.com/v1/marine"
+
+// Hourly values `weather marine` fetches, always in metric: heights in m,
+// periods in s and directions in degrees the waves come from
+const OPEN_METEO_MARINE_HOURLY = "wave_height,wave_direction,wave_period,swell_wave_height,swell_wave_direction,swell_wave_period,wind_wave_height,sea_surface_temperature"
+
+// Hours of the marine forecast, of which every MARINE_TABLE_STEP is listed
+const (
+	MARINE_FORECAST_HOURS = 48
+	MARINE_TABLE_STEP     = 3
+)
+
+// Hourly sea state of the nearest sea grid cell; land cells are null
+type openMeteoMarine struct {
+	Latitude         float64 `json:"latitude"`
+	Longitude        float64 `json:"longitude"`
+	Timezone         string  `json:"timezone"`
+	UTCOffsetSeconds int     `json:"utc_offset_seconds"`
+	Hourly           struct {
+		Time           []int64    `json:"time"`
+		WaveHeight     []*float64 `json:"wave_height"`
+		WaveDirection  []*float64 `json:"wave_direction"`
+		WavePeriod     []*float64 `json:"wave_period"`
+		SwellHeight    []*float64 `json:"swell_wave_height"`
+		SwellDirection []*float64 `json:"swell_wave_direction"`
+		SwellPeriod    []*float64 `json:"swell_wave_period"`
+		WindWaveHeight []*float64 `json:"wind_wave_height"`
+		SeaSurfaceTemp []*float64 `json:"sea_surface_temperature"`
+	} `json:"hourly"`
+}
+
+// Fetches the sea state off a coordinate for the coming days
+func fetchMarine(ctx context.Context, at coordinate) (openMeteoMarine, error) {
+	status("[@] Fetching the marine forecast from Open-Meteo")
+
+	query := url.Values{}
+	query.Set("latitude", fmt.Sprintf("%f", at.Lat))
+	query.Set("longitude", fmt.Sprintf("%f", at.Lon))
+	query.Set("timezone", "auto")
+	query.Set("timeformat", "unixtime")
+	query.Set("hourly", OPEN_METEO_MARINE_HOURLY)
+	query.Set("forecast_days", "3")
+
+	body, err := fetch(ctx, OPEN_METEO_MARINE_URL+"?"+query.Encode())
+	if err != nil {
+		return openMeteoMarine{}, fmt.Errorf("fetching the marine forecast from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoMarine
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return openMeteoMarine{}, fmt.Errorf("parsing the Open-Meteo marine forecast: %w", err)
+	}
+
+	// Inland places get a grid cell on land, with no sea to speak of
+	for _, height := range parsed.Hourly.WaveHeight {
+		if height != nil {
+			return parsed, nil
+		}
+	}
+
+	return openMeteoMarine{}, errors.New("no sea within reach, the marine forecast covers coasts and open water")
+}
+
+// Formats a wave height given in meters, in feet with imperial units
+func formatWaveHeight(meters float64, units unitSystem) string {
+	if units == IMPERIAL {
+		return fmt.Sprintf("%.1f ft", meters*3.28084)
+	}
+
+	return fmt.Sprintf("%.1f m", meters)
+}
+
+// A train of waves as "1.2 m WNW 9 s", or a dash when unknown
+func formatWaves(height *float64, direction *float64, period *float64, units unitSystem) string {
+	if height == nil {
+		return "-"
+	}
+
+	text := formatWaveHeight(*height, units)
+	if direction != nil {
+		text += " " + compassDirection(int64(*direction))
+	}
+	if period != nil {
+		text += fmt.Sprintf(" %.0f s", *period)
+	}
+
+	return text
+}
+
+// Implements `weather marine`, the waves, swell and sea temperature off the
+// location now and over the next two days, with today's tides
+func runMarine(ctx context.Context, target location, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	sea, err := fetchMarine(ctx, target.Coord)
+	if err != nil {
+		return err
+	}
+
+	zone := time.FixedZone(sea.Timezone, sea.UTCOffsetSeconds)
+	now := time.Now().In(zone).Truncate(time.Hour)
+	hourly := sea.Hourly
+	temperature := func(celsius *float64) string {
+		if celsius == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f%s", options.Units.fromCelsius(*celsius), options.Units.temperature())
+	}
+
+	current := -1
+	for index, unix := range hourly.Time {
+		if !time.Unix(unix, 0).After(now) {
+			current = index
+		}
+	}
+	if current < 0 {
+		return errors.New("the marine forecast starts later than now")
+	}
+
+	// The grid cell can lie some way offshore
+	cell := coordinate{Lat: sea.Latitude, Lon: sea.Longitude}
+	if km := distanceKm(target.Coord, cell); km >= 1 {
+		fmt.Printf(tr("Sea state %s %s of the location")+"\n", formatDistance(km, options.Units), compassDirection(int64(bearingDegrees(target.Coord, cell))))
+	}
+
+	printField("Waves", formatWaves(valueAt(hourly.WaveHeight, current), valueAt(hourly.WaveDirection, current), valueAt(hourly.WavePeriod, current), options.Units))
+	printField("Swell", formatWaves(valueAt(hourly.SwellHeight, current), valueAt(hourly.SwellDirection, current), valueAt(hourly.SwellPeriod, current), options.Units))
+	printField("Wind Waves", formatWaves(valueAt(hourly.WindWaveHeight, current), nil, nil, options.Units))
+	printField("Sea Temperature", temperature(valueAt(hourly.SeaSurfaceTemp, current)))
+
+	// Tides are a bonus, the sea state goes out without them
+	if tides, zone, err := fetchTides(ctx, target, options.Units); err != nil {
+		logger.Debug("tide lookup failed", "error", err)
+	} else {
+		printField("Tides", tides.summary(zone, options))
+	}
+
+	rows := [][]string{translated("Time", "Waves", "Swell", "Wind waves", "Sea")}
+	for index := current; index < len(hourly.Time) && index < current+MARINE_FORECAST_HOURS; index += MARINE_TABLE_STEP {
+		rows = append(rows, []string{
+			unixIn(hourly.Time[index], zone).Format("Mon " + options.clockFormat()),
+			formatWaves(valueAt(hourly.WaveHeight, index), valueAt(hourly.WaveDirection, index), valueAt(hourly.WavePeriod, index), options.Units),
+			formatWaves(valueAt(hourly.SwellHeight, index), valueAt(hourly.SwellDirection, index), valueAt(hourly.SwellPeriod, index), options.Units),
+			formatWaves(valueAt(hourly.WindWaveHeight, index), nil, nil, options.Units),
+			temperature(valueAt(hourly.SeaSurfaceTemp, index)),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
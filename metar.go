@@ -0,0 +1,619 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aviation Weather Center data API, which needs no key
+const AVIATION_WEATHER_URL = "https://aviationweather.gov/api/data"
+
+// Report groups, matched one whitespace separated token at a time
+var (
+	metarTimePattern        = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	metarWindPattern        = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	metarVariationPattern   = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	metarMetersPattern      = regexp.MustCompile(`^(\d{4})(NDV)?$`)
+	metarMilesPattern       = regexp.MustCompile(`^([MP])?(\d+)?(?:(\d)/(\d+))?SM$`)
+	metarWeatherPattern     = regexp.MustCompile(`^(\+|-|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+	metarCloudPattern       = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU|///)?$`)
+	metarTemperaturePattern = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	metarPressurePattern    = regexp.MustCompile(`^([QA])(\d{4})$`)
+	metarRunwayPattern      = regexp.MustCompile(`^R\d{2}[LCR]?/`)
+	tafValidityPattern      = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	tafFromPattern          = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	tafProbabilityPattern   = regexp.MustCompile(`^PROB(\d{2})$`)
+)
+
+// Wording of the weather codes
+var (
+	metarIntensities = map[string]string{"-": "light", "+": "heavy"}
+	metarDescriptors = map[string]string{
+		"MI": "shallow", "PR": "partial", "BC": "patches of", "DR": "low drifting",
+		"BL": "blowing", "FZ": "freezing",
+	}
+	metarPhenomena = map[string]string{
+		"DZ": "drizzle", "RA": "rain", "SN": "snow", "SG": "snow grains", "IC": "ice crystals",
+		"PL": "ice pellets", "GR": "hail", "GS": "small hail", "UP": "unknown precipitation",
+		"BR": "mist", "FG": "fog", "FU": "smoke", "VA": "volcanic ash", "DU": "dust", "SA": "sand",
+		"HZ": "haze", "PY": "spray", "PO": "dust whirls", "SQ": "squalls", "FC": "funnel cloud",
+		"SS": "sandstorm", "DS": "duststorm",
+	}
+	metarCloudCovers = map[string]string{"FEW": "few", "SCT": "scattered", "BKN": "broken", "OVC": "overcast"}
+	metarCloudTypes  = map[string]string{"CB": "cumulonimbus", "TCU": "towering cumulus"}
+	metarSkyClear    = map[string]string{
+		"SKC": "clear", "CLR": "clear below 12,000 ft", "NSC": "no significant clouds",
+		"NCD": "no clouds detected",
+	}
+)
+
+// Wind, visibility, weather and clouds, which METARs and every TAF period share
+type aviationConditions struct {
+	Wind       string
+	Visibility string
+	Weather    []string
+	Clouds     []string
+}
+
+// Decoded METAR observation
+type metarReport struct {
+	Station     string
+	Time        time.Time
+	Automated   bool
+	Conditions  aviationConditions
+	HasTemp     bool
+	Temp        float64 // °C
+	DewPoint    float64 // °C
+	HasDewPoint bool
+	Pressure    string
+	Trend       string
+	Raw         string
+}
+
+// One period of a TAF
+type tafPeriod struct {
+	Label      string
+	Conditions aviationConditions
+}
+
+// Decoded TAF forecast
+type tafReport struct {
+	Station    string
+	Issued     time.Time
+	ValidFrom  time.Time
+	ValidUntil time.Time
+	Periods    []tafPeriod
+	Raw        string
+}
+
+// Turns an aviation report's day and hour into a time in whichever of the
+// previous, current and next month puts it closest to now, so reports from
+// late last month and forecasts running into the next both fall right
+func aviationTime(day int, hour int, minute int, now time.Time) time.Time {
+	now = now.UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var closest time.Time
+	for _, offset := range []int{-1, 0, 1} {
+		month := current.AddDate(0, offset, 0)
+		if day < 1 || day > month.AddDate(0, 1, -1).Day() {
+			continue
+		}
+
+		candidate := month.AddDate(0, 0, day-1).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		if closest.IsZero() || candidate.Sub(now).Abs() < closest.Sub(now).Abs() {
+			closest = candidate
+		}
+	}
+
+	return closest
+}
+
+// Parses a number with an M prefix for minus, as in M05
+func metarNumber(text string) float64 {
+	value, _ := strconv.ParseFloat(strings.TrimPrefix(text, "M"), 64)
+	if strings.HasPrefix(text, "M") {
+		return -value
+	}
+
+	return value
+}
+
+// Describes a weather code such as -SHRA or VCTS
+func describeWeather(code string) (string, bool) {
+	if code == "NSW" {
+		return "no significant weather", true
+	}
+
+	match := metarWeatherPattern.FindStringSubmatch(code)
+	if match == nil || match[2] == "" && match[3] == "" {
+		return "", false
+	}
+
+	var phenomena []string
+	for index := 0; index+2 <= len(match[3]); index += 2 {
+		phenomena = append(phenomena, metarPhenomena[match[3][index:index+2]])
+	}
+	what := strings.Join(phenomena, " and ")
+
+	switch match[2] {
+	case "":
+	case "SH":
+		what = strings.TrimSpace(what + " showers")
+	case "TS":
+		if what == "" {
+			what = "thunderstorm"
+		} else {
+			what = "thunderstorm with " + what
+		}
+	default:
+		what = metarDescriptors[match[2]] + " " + what
+	}
+
+	if intensity := metarIntensities[match[1]]; intensity != "" {
+		what = intensity + " " + what
+	}
+	if match[1] == "VC" {
+		what += " in the vicinity"
+	}
+
+	return strings.TrimSpace(what), true
+}
+
+// Decodes a wind, visibility, weather or cloud group into the conditions.
+// The next token is passed for visibilities split like "1 1/2SM"; the result
+// tells how many tokens were used, zero when the token is something else.
+func (c *aviationConditions) decode(token string, next string) int {
+	if match := metarWindPattern.FindStringSubmatch(token); match != nil {
+		unit := map[string]string{"KT": "kt", "MPS": "m/s", "KMH": "km/h"}[match[4]]
+		speed, _ := strconv.Atoi(match[2])
+
+		switch {
+		case speed == 0:
+			c.Wind = "calm"
+		case match[1] == "VRB":
+			c.Wind = fmt.Sprintf("variable at %d %s", speed, unit)
+		default:
+			degrees, _ := strconv.ParseInt(match[1], 10, 64)
+			c.Wind = fmt.Sprintf("%s° (%s) at %d %s", match[1], compassDirection(degrees), speed, unit)
+		}
+
+		if match[3] != "" {
+			gust, _ := strconv.Atoi(match[3])
+			c.Wind += fmt.Sprintf(", gusting %d %s", gust, unit)
+		}
+
+		return 1
+	}
+
+	if match := metarVariationPattern.FindStringSubmatch(token); match != nil {
+		c.Wind += fmt.Sprintf(", varying between %s° and %s°", match[1], match[2])
+		return 1
+	}
+
+	if token == "CAVOK" {
+		c.Visibility = "10 km or more, no clouds below 5,000 ft and no significant weather"
+		return 1
+	}
+
+	if match := metarMetersPattern.FindStringSubmatch(token); match != nil {
+		meters, _ := strconv.Atoi(match[1])
+		if meters == 9999 {
+			c.Visibility = "10 km or more"
+		} else if meters >= 5000 {
+			c.Visibility = fmt.Sprintf("%d km", meters/1000)
+		} else {
+			c.Visibility = fmt.Sprintf("%d m", meters)
+		}
+
+		return 1
+	}
+
+	// Whole miles may come as their own token before the fraction
+	if len(token) == 1 && token[0] >= '1' && token[0] <= '9' {
+		if match := metarMilesPattern.FindStringSubmatch(next); match != nil && match[1] == "" && match[2] == "" && match[3] != "" {
+			c.Visibility = fmt.Sprintf("%s %s/%s statute miles", token, match[3], match[4])
+			return 2
+		}
+	}
+
+	if match := metarMilesPattern.FindStringSubmatch(token); match != nil && (match[2] != "" || match[3] != "") {
+		miles := match[2]
+		if match[3] != "" {
+			miles = strings.TrimSpace(miles + " " + match[3] + "/" + match[4])
+		}
+
+		switch match[1] {
+		case "P":
+			c.Visibility = "more than " + miles + " statute miles"
+		case "M":
+			c.Visibility = "less than " + miles + " statute miles"
+		default:
+			c.Visibility = miles + " statute miles"
+		}
+
+		return 1
+	}
+
+	if clear, found := metarSkyClear[token]; found {
+		c.Clouds = append(c.Clouds, clear)
+		return 1
+	}
+
+	if match := metarCloudPattern.FindStringSubmatch(token); match != nil {
+		height := "unknown height"
+		if feet, err := strconv.Atoi(match[2]); err == nil {
+			height = formatThousands(feet*100) + " ft"
+		}
+
+		var layer string
+		if match[1] == "VV" {
+			layer = "sky obscured, vertical visibility " + height
+		} else {
+			layer = metarCloudCovers[match[1]] + " at " + height
+		}
+
+		if kind := metarCloudTypes[match[3]]; kind != "" {
+			layer += " (" + kind + ")"
+		}
+
+		c.Clouds = append(c.Clouds, layer)
+		return 1
+	}
+
+	if description, ok := describeWeather(token); ok {
+		c.Weather = append(c.Weather, description)
+		return 1
+	}
+
+	return 0
+}
+
+// Formats a whole number with thousands separators, as in 12,000
+func formatThousands(value int) string {
+	text := strconv.Itoa(value)
+	for index := len(text) - 3; index > 0; index -= 3 {
+		text = text[:index] + "," + text[index:]
+	}
+
+	return text
+}
+
+// Decodes a raw METAR such as "EGLL 141450Z 24012KT 9999 FEW020 14/09 Q1013"
+func parseMetar(raw string, now time.Time) (metarReport, error) {
+	tokens := strings.Fields(raw)
+	report := metarReport{Raw: strings.Join(tokens, " ")}
+
+	// Some feeds keep the report type in front
+	if len(tokens) > 0 && (tokens[0] == "METAR" || tokens[0] == "SPECI") {
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) < 2 {
+		return report, fmt.Errorf("METAR %q is too short", raw)
+	}
+
+	report.Station = tokens[0]
+
+	match := metarTimePattern.FindStringSubmatch(tokens[1])
+	if match == nil {
+		return report, fmt.Errorf("METAR %q has no observation time", raw)
+	}
+	day, _ := strconv.Atoi(match[1])
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+	report.Time = aviationTime(day, hour, minute, now)
+
+	for index := 2; index < len(tokens); index++ {
+		token := tokens[index]
+
+		next := ""
+		if index+1 < len(tokens) {
+			next = tokens[index+1]
+		}
+
+		switch {
+		case token == "AUTO":
+			report.Automated = true
+		case token == "COR" || metarRunwayPattern.MatchString(token):
+			// Corrections and runway visual ranges aren't shown
+		case token == "RMK":
+			return report, nil
+		case token == "NOSIG":
+			report.Trend = "no significant change expected"
+		case token == "BECMG" || token == "TEMPO":
+			report.Trend = strings.Join(tokens[index:], " ")
+			if end := strings.Index(report.Trend, " RMK"); end >= 0 {
+				report.Trend = report.Trend[:end]
+			}
+			return report, nil
+		case metarTemperaturePattern.MatchString(token):
+			temperature := metarTemperaturePattern.FindStringSubmatch(token)
+			report.HasTemp = true
+			report.Temp = metarNumber(temperature[1])
+			if temperature[2] != "" {
+				report.HasDewPoint = true
+				report.DewPoint = metarNumber(temperature[2])
+			}
+		case metarPressurePattern.MatchString(token):
+			pressure := metarPressurePattern.FindStringSubmatch(token)
+			value, _ := strconv.Atoi(pressure[2])
+			if pressure[1] == "Q" {
+				report.Pressure = fmt.Sprintf("%d hPa", value)
+			} else {
+				report.Pressure = fmt.Sprintf("%.2f inHg (%.0f hPa)", float64(value)/100, float64(value)/100*33.8639)
+			}
+		default:
+			index += max(report.Conditions.decode(token, next)-1, 0)
+		}
+	}
+
+	return report, nil
+}
+
+// Decodes a raw TAF into its base forecast and change periods
+func parseTaf(raw string, now time.Time) (tafReport, error) {
+	tokens := strings.Fields(raw)
+	report := tafReport{Raw: strings.Join(tokens, " ")}
+
+	for len(tokens) > 0 && (tokens[0] == "TAF" || tokens[0] == "AMD" || tokens[0] == "COR") {
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) < 3 {
+		return report, fmt.Errorf("TAF %q is too short", raw)
+	}
+
+	report.Station = tokens[0]
+
+	issued := metarTimePattern.FindStringSubmatch(tokens[1])
+	validity := tafValidityPattern.FindStringSubmatch(tokens[2])
+	if issued == nil || validity == nil {
+		return report, fmt.Errorf("TAF %q has no issue time or validity", raw)
+	}
+
+	day, _ := strconv.Atoi(issued[1])
+	hour, _ := strconv.Atoi(issued[2])
+	minute, _ := strconv.Atoi(issued[3])
+	report.Issued = aviationTime(day, hour, minute, now)
+	report.ValidFrom, report.ValidUntil = tafSpan(validity, now)
+
+	current := tafPeriod{Label: tr("Initially")}
+	probability := ""
+
+	flush := func() {
+		if current.Conditions.Wind != "" || current.Conditions.Visibility != "" || len(current.Conditions.Weather) > 0 || len(current.Conditions.Clouds) > 0 {
+			report.Periods = append(report.Periods, current)
+		}
+	}
+
+	for index := 3; index < len(tokens); index++ {
+		token := tokens[index]
+
+		next := ""
+		if index+1 < len(tokens) {
+			next = tokens[index+1]
+		}
+
+		if match := tafFromPattern.FindStringSubmatch(token); match != nil {
+			flush()
+
+			day, _ := strconv.Atoi(match[1])
+			hour, _ := strconv.Atoi(match[2])
+			minute, _ := strconv.Atoi(match[3])
+			current = tafPeriod{Label: fmt.Sprintf(tr("From %s"), formatAviationTime(aviationTime(day, hour, minute, now)))}
+			continue
+		}
+
+		if match := tafProbabilityPattern.FindStringSubmatch(token); match != nil {
+			probability = fmt.Sprintf(tr("%s%% chance"), match[1])
+			continue
+		}
+
+		if token == "BECMG" || token == "TEMPO" {
+			flush()
+
+			verb := tr(map[string]string{"BECMG": "Becoming", "TEMPO": "Temporarily"}[token])
+			if probability != "" {
+				verb = probability + ", " + strings.ToLower(verb)
+				probability = ""
+			}
+
+			current = tafPeriod{Label: verb}
+			if span := tafValidityPattern.FindStringSubmatch(next); span != nil {
+				from, until := tafSpan(span, now)
+				current.Label += " " + formatAviationTime(from) + " – " + formatAviationTime(until)
+				index++
+			}
+			continue
+		}
+
+		// A probability may also apply to a period on its own
+		if span := tafValidityPattern.FindStringSubmatch(token); span != nil && probability != "" {
+			flush()
+
+			from, until := tafSpan(span, now)
+			current = tafPeriod{Label: probability + " " + formatAviationTime(from) + " – " + formatAviationTime(until)}
+			probability = ""
+			continue
+		}
+
+		if token == "RMK" {
+			break
+		}
+
+		// Temperature forecasts (TX/TN) and other groups are skipped
+		index += max(current.Conditions.decode(token, next)-1, 0)
+	}
+	flush()
+
+	return report, nil
+}
+
+// Turns a DDHH/DDHH span into times. Hour 24 means the end of the day.
+func tafSpan(match []string, now time.Time) (time.Time, time.Time) {
+	fromDay, _ := strconv.Atoi(match[1])
+	fromHour, _ := strconv.Atoi(match[2])
+	untilDay, _ := strconv.Atoi(match[3])
+	untilHour, _ := strconv.Atoi(match[4])
+
+	return aviationTime(fromDay, fromHour, 0, now), aviationTime(untilDay, untilHour, 0, now)
+}
+
+// Times in aviation reports are always UTC
+func formatAviationTime(t time.Time) string {
+	return t.UTC().Format("Mon 2 Jan 15:04Z")
+}
+
+// Fetches the newest raw report of a kind ("metar" or "taf") for a station,
+// returning an empty string when the station has none
+func fetchAviationReport(ctx context.Context, kind string, station string) (string, error) {
+	TARGET_URL := fmt.Sprintf("%s/%s?ids=%s&format=raw", AVIATION_WEATHER_URL, kind, url.QueryEscape(station))
+
+	body, err := fetch(ctx, TARGET_URL)
+	if err != nil {
+		// Stations without a report answer with no content
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.Code == http.StatusNoContent {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("fetching %s for %s: %w", strings.ToUpper(kind), station, err)
+	}
+
+	// Only the newest report, which comes first
+	text := strings.TrimSpace(string(body))
+	if before, _, found := strings.Cut(text, "\n\n"); found {
+		text = before
+	}
+
+	return text, nil
+}
+
+// Turns the argument of `weather metar` into an ICAO station, translating
+// IATA codes through the airport list
+func aviationStation(code string) (string, airport, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	known, found := findAirport(code)
+	if len(code) == 3 {
+		if !found {
+			return "", airport{}, fmt.Errorf("unknown airport %s, use its four letter ICAO code", code)
+		}
+
+		return known.ICAO, known, nil
+	}
+
+	if len(code) != 4 {
+		return "", airport{}, fmt.Errorf("%q is not an airport code, expected e.g. EGLL or LHR", code)
+	}
+
+	return code, known, nil
+}
+
+// Prints a labeled line of the decoded report when it has a value
+func printAviationLine(label string, value string) {
+	if value != "" {
+		fmt.Printf("%-14s %s\n", label+":", value)
+	}
+}
+
+// Prints the shared conditions of a METAR or TAF period
+func (c aviationConditions) print(indent string) {
+	printAviationLine(indent+tr("Wind"), c.Wind)
+	printAviationLine(indent+tr("Visibility"), c.Visibility)
+	printAviationLine(indent+tr("Weather"), strings.Join(c.Weather, ", "))
+	printAviationLine(indent+tr("Clouds"), strings.Join(c.Clouds, ", "))
+}
+
+// Implements `weather metar`
+func runMetar(ctx context.Context, code string, taf bool, units string) error {
+	station, known, err := aviationStation(code)
+	if err != nil {
+		return err
+	}
+
+	options, err := resolveDisplay(units, known.Country)
+	if err != nil {
+		return err
+	}
+
+	status("[@] Fetching METAR for " + station)
+
+	rawMetar, err := fetchAviationReport(ctx, "metar", station)
+	if err != nil {
+		return err
+	}
+	if rawMetar == "" {
+		return fmt.Errorf("no recent METAR for %s", station)
+	}
+
+	now := time.Now()
+
+	metar, err := parseMetar(rawMetar, now)
+	if err != nil {
+		return err
+	}
+
+	title := metar.Station
+	if known.Name != "" {
+		title += "  " + known.Name + ", " + known.City
+	}
+	fmt.Printf("\n%s\n\n", title)
+
+	observed := formatAviationTime(metar.Time)
+	if metar.Automated {
+		observed += " (" + tr("automated") + ")"
+	}
+	printAviationLine(tr("Observed"), observed)
+	metar.Conditions.print("")
+
+	temperature := func(celsius float64) string {
+		return fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+	}
+	if metar.HasTemp {
+		printAviationLine(tr("Temperature"), temperature(metar.Temp))
+	}
+	if metar.HasDewPoint {
+		printAviationLine(tr("Dew Point"), temperature(metar.DewPoint))
+	}
+	printAviationLine(tr("Pressure"), metar.Pressure)
+	printAviationLine(tr("Trend"), metar.Trend)
+	printAviationLine(tr("Raw"), metar.Raw)
+
+	if !taf {
+		return nil
+	}
+
+	rawTaf, err := fetchAviationReport(ctx, "taf", station)
+	if err != nil {
+		return err
+	}
+	if rawTaf == "" {
+		// Small airports often have no forecast
+		status("[!] No TAF is issued for " + station)
+		return nil
+	}
+
+	forecast, err := parseTaf(rawTaf, now)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n"+tr("Forecast issued %s, valid %s – %s")+"\n\n", formatAviationTime(forecast.Issued), formatAviationTime(forecast.ValidFrom), formatAviationTime(forecast.ValidUntil))
+	for _, period := range forecast.Periods {
+		fmt.Println(period.Label)
+		period.Conditions.print("  ")
+	}
+	fmt.Println()
+	printAviationLine(tr("Raw"), forecast.Raw)
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Report days fall in the month that puts them closest to now, both for
+// observations from late last month and forecasts running into the next
+func TestAviationMonthWrap(t *testing.T) {
+	utc := func(month time.Month, day int, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	metars := []struct {
+		raw  string
+		now  time.Time
+		want time.Time
+	}{
+		{"METAR EGLL 311750Z 24010KT 9999 BKN030 12/08 Q1015", utc(time.November, 1, 0), utc(time.October, 31, 17).Add(50 * time.Minute)},
+		{"METAR EGLL 010020Z 24010KT 9999 BKN030 12/08 Q1015", utc(time.November, 1, 1), utc(time.November, 1, 0).Add(20 * time.Minute)},
+		{"METAR EGLL 141150Z 24010KT 9999 BKN030 12/08 Q1015", utc(time.October, 14, 12), utc(time.October, 14, 11).Add(50 * time.Minute)},
+	}
+	for _, test := range metars {
+		report, err := parseMetar(test.raw, test.now)
+		if err != nil {
+			t.Fatalf("parseMetar(%q): %v", test.raw, err)
+		}
+		if !report.Time.Equal(test.want) {
+			t.Errorf("parseMetar(%q) at %s observed %s, want %s", test.raw, test.now, report.Time, test.want)
+		}
+	}
+
+	tafs := []struct {
+		raw   string
+		now   time.Time
+		from  time.Time
+		until time.Time
+		label string
+	}{
+		{"TAF EGLL 311700Z 3118/0124 24010KT 9999 BKN030 FM010600 27015KT CAVOK", utc(time.October, 31, 20), utc(time.October, 31, 18), utc(time.November, 2, 0), "From Sun 1 Nov 06:00Z"},
+		{"TAF EGLL 302300Z 3100/0106 24010KT 9999 BKN030 FM310600 27015KT CAVOK", utc(time.December, 31, 0), utc(time.December, 31, 0), utc(time.December, 31, 6).AddDate(0, 0, 1), "From Thu 31 Dec 06:00Z"},
+		{"TAF EGLL 141100Z 1412/1518 24010KT 9999 BKN030 FM150600 27015KT CAVOK", utc(time.October, 14, 12), utc(time.October, 14, 12), utc(time.October, 15, 18), "From Thu 15 Oct 06:00Z"},
+	}
+	for _, test := range tafs {
+		report, err := parseTaf(test.raw, test.now)
+		if err != nil {
+			t.Fatalf("parseTaf(%q): %v", test.raw, err)
+		}
+		if !report.ValidFrom.Equal(test.from) || !report.ValidUntil.Equal(test.until) {
+			t.Errorf("parseTaf(%q) at %s valid %s – %s, want %s – %s", test.raw, test.now, report.ValidFrom, report.ValidUntil, test.from, test.until)
+		}
+		if len(report.Periods) != 2 || report.Periods[1].Label != test.label {
+			t.Errorf("parseTaf(%q) periods %+v, want the second labeled %q", test.raw, report.Periods, test.label)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const MET_NO_URL = "https://api.met.no/weatherapi/locationforecast/2.0/complete"
+
+// Instant values of one met.no timeseries step
+type metNoInstant struct {
+	Pressure    float64 `json:"air_pressure_at_sea_level"`
+	Temperature float64 `json:"air_temperature"`
+	CloudCover  float64 `json:"cloud_area_fraction"`
+	DewPoint    float64 `json:"dew_point_temperature"`
+	Fog         float64 `json:"fog_area_fraction"`
+	Humidity    float64 `json:"relative_humidity"`
+	UVIndex     float64 `json:"ultraviolet_index_clear_sky"`
+	WindFrom    float64 `json:"wind_from_direction"`
+	WindSpeed   float64 `json:"wind_speed"`
+	WindGust    float64 `json:"wind_speed_of_gust"`
+}
+
+// Summary for the period following a timeseries step
+type metNoPeriod struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+	Details struct {
+		TemperatureMax float64 `json:"air_temperature_max"`
+		TemperatureMin float64 `json:"air_temperature_min"`
+		Precipitation  float64 `json:"precipitation_amount"`
+		Probability    float64 `json:"probability_of_precipitation"`
+	} `json:"details"`
+}
+
+type metNoStep struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		Instant struct {
+			Details metNoInstant `json:"details"`
+		} `json:"instant"`
+		Next1Hours  *metNoPeriod `json:"next_1_hours"`
+		Next6Hours  *metNoPeriod `json:"next_6_hours"`
+		Next12Hours *metNoPeriod `json:"next_12_hours"`
+	} `json:"data"`
+}
+
+type metNoResponse struct {
+	Properties struct {
+		Timeseries []metNoStep `json:"timeseries"`
+	} `json:"properties"`
+}
+
+// Fetches weather from the Norwegian Meteorological Institute, which needs
+// no key but does require an identifying User-Agent
+func fetchMetNo(ctx context.Context, at coordinate, units unitSystem) (weatherData, error) {
+	status("[@] Fetching weather from met.no")
+
+	// met.no asks clients not to send more than four decimals
+	TARGET_URL := fmt.Sprintf("%s?lat=%.4f&lon=%.4f", MET_NO_URL, at.Lat, at.Lon)
+
+	body, err := fetch(ctx, TARGET_URL)
+	if err != nil {
+		return weatherData{}, fmt.Errorf("fetching weather from met.no: %w", err)
+	}
+
+	var parsedResponse metNoResponse
+	if err := json.Unmarshal(body, &parsedResponse); err != nil {
+		return weatherData{}, fmt.Errorf("parsing met.no response: %w", err)
+	}
+
+	if len(parsedResponse.Properties.Timeseries) == 0 {
+		return weatherData{}, errors.New("met.no response contains no forecast")
+	}
+
+	return parsedResponse.toWeatherData(at, units), nil
+}
+
+// Maps a met.no forecast onto weatherData, converting from the metric units
+// met.no always answers in. met.no only reports UTC times, so the zone is
+// approximated from the longitude.
+func (r metNoResponse) toWeatherData(at coordinate, units unitSystem) weatherData {
+	offset := int(math.Round(at.Lon/15)) * 3600
+	zone := time.FixedZone(fmt.Sprintf("UTC%+d", offset/3600), offset)
+
+	steps := r.Properties.Timeseries
+
+	temperature := func(celsius float64) float64 { return units.fromCelsius(celsius) }
+	speed := func(metersPerSecond float64) float64 { return units.fromMetersPerSecond(metersPerSecond) }
+	precipitation := func(mm float64) float64 { return units.fromMillimeters(mm) }
+
+	instant := func(step metNoStep) conditions {
+		details := step.Data.Instant.Details
+
+		return conditions{
+			Time:      step.Time.In(zone),
+			Temp:      temperature(details.Temperature),
+			FeelsLike: temperature(details.Temperature),
+			DewPoint:  temperature(details.DewPoint),
+			Pressure:  int64(details.Pressure + 0.5),
+			Humidity:  int64(details.Humidity + 0.5),
+			Clouds:    int64(details.CloudCover + 0.5),
+			UVI:       details.UVIndex,
+			WindSpeed: speed(details.WindSpeed),
+			WindDeg:   int64(details.WindFrom + 0.5),
+			WindGust:  speed(details.WindGust),
+			Condition: metNoCondition(step.symbol()),
+		}
+	}
+
+	data := weatherData{
+		Coord:    at,
+		Timezone: zone.String(),
+		Offset:   offset,
+		Current:  instant(steps[0]),
+	}
+
+	daysByDate := map[string]*dailyForecast{}
+	var dates []string
+
+	for _, step := range steps {
+		details := step.Data.Instant.Details
+		local := step.Time.In(zone)
+
+		// Hourly steps come with a one hour summary
+		if step.Data.Next1Hours != nil {
+			hour := instant(step)
+			hour.Pop = step.Data.Next1Hours.Details.Probability / 100
+			hour.Precipitation = precipitation(step.Data.Next1Hours.Details.Precipitation)
+
+			data.Hourly = append(data.Hourly, hour)
+		}
+
+		date := local.Format(time.DateOnly)
+		day, found := daysByDate[date]
+		if !found {
+			noon := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, zone)
+			day = &dailyForecast{Date: noon, TempMax: math.Inf(-1), TempMin: math.Inf(1)}
+			daysByDate[date] = day
+			dates = append(dates, date)
+		}
+
+		day.TempMax = math.Max(day.TempMax, details.Temperature)
+		day.TempMin = math.Min(day.TempMin, details.Temperature)
+		if period := step.Data.Next6Hours; period != nil && period.Details.TemperatureMax != 0 {
+			day.TempMax = math.Max(day.TempMax, period.Details.TemperatureMax)
+			day.TempMin = math.Min(day.TempMin, period.Details.TemperatureMin)
+		}
+		day.WindSpeed = math.Max(day.WindSpeed, details.WindSpeed)
+		day.WindGust = math.Max(day.WindGust, details.WindGust)
+		day.UVI = math.Max(day.UVI, details.UVIndex)
+
+		// Count each hour of precipitation once, using the finest summary
+		switch {
+		case step.Data.Next1Hours != nil:
+			day.Precipitation += step.Data.Next1Hours.Details.Precipitation
+			day.Pop = math.Max(day.Pop, step.Data.Next1Hours.Details.Probability/100)
+		case step.Data.Next6Hours != nil:
+			day.Precipitation += step.Data.Next6Hours.Details.Precipitation
+			day.Pop = math.Max(day.Pop, step.Data.Next6Hours.Details.Probability/100)
+		}
+
+		// The midday symbol describes the day best
+		if day.Condition.Kind == "" || local.Hour() <= 12 {
+			day.Condition = metNoCondition(step.symbol())
+		}
+	}
+
+	for _, date := range dates {
+		day := daysByDate[date]
+		day.TempMax = temperature(day.TempMax)
+		day.TempMin = temperature(day.TempMin)
+		day.WindSpeed = speed(day.WindSpeed)
+		day.WindGust = speed(day.WindGust)
+		day.Precipitation = precipitation(day.Precipitation)
+		data.Daily = append(data.Daily, *day)
+	}
+
+	return data
+}
+
+// Symbol for the shortest period summarized after a step
+func (s metNoStep) symbol() string {
+	for _, period := range []*metNoPeriod{s.Data.Next1Hours, s.Data.Next6Hours, s.Data.Next12Hours} {
+		if period != nil && period.Summary.SymbolCode != "" {
+			return period.Summary.SymbolCode
+		}
+	}
+
+	return "cloudy"
+}
+
+// met.no symbol names mapped to conditions
+var metNoConditions = map[string]condition{
+	"clearsky":          {Kind: CLEAR, Description: "clear sky"},
+	"fair":              {Kind: PARTLY_CLOUDY, Description: "fair"},
+	"partlycloudy":      {Kind: PARTLY_CLOUDY, Description: "partly cloudy"},
+	"cloudy":            {Kind: CLOUDY, Description: "cloudy"},
+	"fog":               {Kind: FOG, Description: "fog"},
+	"lightrain":         {Kind: RAIN, Description: "light rain"},
+	"rain":              {Kind: RAIN, Description: "rain"},
+	"heavyrain":         {Kind: RAIN, Description: "heavy rain"},
+	"lightrainshowers":  {Kind: SHOWERS, Description: "light rain showers"},
+	"rainshowers":       {Kind: SHOWERS, Description: "rain showers"},
+	"heavyrainshowers":  {Kind: SHOWERS, Description: "heavy rain showers"},
+	"lightsleet":        {Kind: SLEET, Description: "light sleet"},
+	"sleet":             {Kind: SLEET, Description: "sleet"},
+	"heavysleet":        {Kind: SLEET, Description: "heavy sleet"},
+	"lightsleetshowers": {Kind: SLEET, Description: "light sleet showers"},
+	"sleetshowers":      {Kind: SLEET, Description: "sleet showers"},
+	"heavysleetshowers": {Kind: SLEET, Description: "heavy sleet showers"},
+	"lightsnow":         {Kind: SNOW, Description: "light snow"},
+	"snow":              {Kind: SNOW, Description: "snow"},
+	"heavysnow":         {Kind: SNOW, Description: "heavy snow"},
+	"lightsnowshowers":  {Kind: SNOW, Description: "light snow showers"},
+	"snowshowers":       {Kind: SNOW, Description: "snow showers"},
+	"heavysnowshowers":  {Kind: SNOW, Description: "heavy snow showers"},
+}
+
+// Converts a met.no symbol such as "lightrainshowers_day" into a condition,
+// described in the chosen language
+func metNoCondition(symbol string) condition {
+	name, variant, _ := strings.Cut(symbol, "_")
+
+	var result condition
+
+	if strings.Contains(name, "thunder") {
+		// Every "...andthunder" symbol is a thunderstorm of some strength
+		result = condition{Kind: THUNDERSTORM, Description: "thunderstorm"}
+		switch {
+		case strings.HasPrefix(name, "light"):
+			result.Description = "thunderstorm with light precipitation"
+		case strings.HasPrefix(name, "heavy"):
+			result.Description = "thunderstorm with heavy precipitation"
+		}
+	} else if known, found := metNoConditions[name]; found {
+		result = known
+	} else {
+		result = condition{Kind: CLOUDY, Description: name}
+	}
+
+	result.Description = tr(result.Description)
+
+	result.Night = variant == "night"
+
+	return result
+}
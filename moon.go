@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Geocentric altitude in degrees of the moon's center at moonrise and
+// moonset, after its parallax, refraction and half its disc
+const MOONRISE_ALTITUDE = 0.125
+
+// Names of the eight moon phases, starting at new moon
+var moonPhaseNames = []string{
+	"new moon", "waxing crescent", "first quarter", "waxing gibbous",
+	"full moon", "waning gibbous", "last quarter", "waning crescent",
+}
+
+// How far the moon is through its cycle at the moment, from 0 at new moon
+// over 0.5 at full moon back to 1, from how far it stands from the sun.
+// Good to about an hour, which puts the principal phases on the right day.
+func moonPhase(moment time.Time) float64 {
+	days := daysSinceJ2000(moment)
+	moonLongitude, _ := moonEcliptic(days)
+
+	phase := math.Mod((moonLongitude-sunLongitude(days))/(2*math.Pi), 1)
+	if phase < 0 {
+		phase++
+	}
+
+	return phase
+}
+
+// Lit fraction of the moon's disc, from 0 to 1
+func moonIllumination(moment time.Time) float64 {
+	return (1 - math.Cos(2*math.Pi*moonPhase(moment))) / 2
+}
+
+// Name of the phase the moon is in at the moment
+func moonPhaseName(moment time.Time) string {
+	// Each name covers an eighth of the cycle centered on its exact phase
+	index := int(math.Floor(moonPhase(moment)*8+0.5)) % len(moonPhaseNames)
+
+	return moonPhaseNames[index]
+}
+
+// Pictures of the eight phases as seen from the northern hemisphere
+var moonPhaseEmojis = []string{"🌑", "🌒", "🌓", "🌔", "🌕", "🌖", "🌗", "🌘"}
+
+// Picture of the phase the moon is in at the moment. South of the equator
+// the moon is seen upside down, so the lit side is mirrored.
+func moonPhaseEmoji(moment time.Time, latitude float64) string {
+	index := int(math.Floor(moonPhase(moment)*8+0.5)) % len(moonPhaseEmojis)
+	if latitude < 0 {
+		index = (len(moonPhaseEmojis) - index) % len(moonPhaseEmojis)
+	}
+
+	return moonPhaseEmojis[index]
+}
+
+// Position of the moon seen from the center of the earth, from the leading
+// terms of its orbit. Good to a few tenths of a degree, or a couple of
+// minutes of moonrise.
+func moonAt(moment time.Time, at coordinate) skyPosition {
+	radians := math.Pi / 180
+	days := daysSinceJ2000(moment)
+
+	longitude, latitude := moonEcliptic(days)
+	obliquity := (23.439 - 0.00000036*days) * radians
+
+	rightAscension := math.Atan2(math.Sin(longitude)*math.Cos(obliquity)-math.Tan(latitude)*math.Sin(obliquity), math.Cos(longitude))
+	declination := math.Asin(math.Sin(latitude)*math.Cos(obliquity) + math.Cos(latitude)*math.Sin(obliquity)*math.Sin(longitude))
+
+	return horizontalPosition(days, at, rightAscension, declination)
+}
+
+// Ecliptic longitude and latitude of the moon in radians, the given days
+// from J2000
+func moonEcliptic(days float64) (float64, float64) {
+	radians := math.Pi / 180
+
+	meanLongitude := 218.316 + 13.176396*days
+	anomaly := (134.963 + 13.064993*days) * radians
+	argument := (93.272 + 13.229350*days) * radians
+	elongation := (297.850 + 12.190749*days) * radians
+	sunAnomaly := (357.529 + 0.98560028*days) * radians
+
+	// The equation of the center, evection, variation and annual equation
+	longitude := (meanLongitude + 6.289*math.Sin(anomaly) + 1.274*math.Sin(2*elongation-anomaly) +
+		0.658*math.Sin(2*elongation) - 0.186*math.Sin(sunAnomaly)) * radians
+	latitude := 5.128 * math.Sin(argument) * radians
+
+	return longitude, latitude
+}
+
+// When the moon rises or sets on the local calendar day of the moment, false
+// when it doesn't that day. Rising some fifty minutes later every day, it
+// skips one day a month.
+func moonPasses(day time.Time, at coordinate, rising bool) (time.Time, bool) {
+	crossings := skyCrossings(day, func(moment time.Time) bool { return moonAt(moment, at).Altitude >= MOONRISE_ALTITUDE })
+	for _, crossing := range crossings {
+		if crossing.Rising == rising {
+			return crossing.Time, true
+		}
+	}
+
+	return time.Time{}, false
+}
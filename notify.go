@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Toast notification for PowerShell, the title and message as arguments
+const TOAST_SCRIPT = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName('text')
+$texts.Item(0).AppendChild($template.CreateTextNode($args[0])) > $null
+$texts.Item(1).AppendChild($template.CreateTextNode($args[1])) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('weather-cli').Show([Windows.UI.Notifications.ToastNotification]::new($template))
+`
+
+// Shows a desktop notification: notify-send on Linux, osascript on macOS or
+// a toast on Windows
+func notifyDesktop(ctx context.Context, title string, message string) error {
+	var name string
+	var args []string
+
+	switch runtime.GOOS {
+	case "darwin":
+		name = "osascript"
+		args = []string{"-e", "on run argv", "-e", "display notification (item 2 of argv) with title (item 1 of argv)", "-e", "end run", title, message}
+	case "windows":
+		name = "powershell"
+		args = powershellArgs(TOAST_SCRIPT, title, message)
+	default:
+		name = "notify-send"
+		args = []string{"--app-name=weather", title, message}
+	}
+
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("no desktop notifications available, %s was not found", name)
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, message)
+		}
+
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Alerts of the US National Weather Service in force at a point. They come
+// as GeoJSON, with the drawn area of storm based warnings.
+const NWS_ALERTS_URL = "https://api.weather.gov/alerts/active?point=%.4f,%.4f"
+
+// Alerts the NWS answers with, each a GeoJSON feature
+type nwsAlerts struct {
+	Features []struct {
+		Geometry *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			Event       string    `json:"event"`
+			SenderName  string    `json:"senderName"`
+			AreaDesc    string    `json:"areaDesc"`
+			Onset       time.Time `json:"onset"`
+			Ends        time.Time `json:"ends"`
+			Expires     time.Time `json:"expires"`
+			Description string    `json:"description"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+// Fetches the NWS alerts in force at a US coordinate. Those issued for whole
+// counties or zones also reach points outside a drawn warning area.
+func fetchNWSAlerts(ctx context.Context, at coordinate) ([]weatherAlert, error) {
+	status("[@] Fetching alerts from the National Weather Service")
+
+	body, err := fetch(ctx, fmt.Sprintf(NWS_ALERTS_URL, at.Lat, at.Lon))
+	if err != nil {
+		return nil, fmt.Errorf("fetching alerts from the National Weather Service: %w", err)
+	}
+
+	var parsed nwsAlerts
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing National Weather Service alerts: %w", err)
+	}
+
+	var alerts []weatherAlert
+	for _, feature := range parsed.Features {
+		properties := feature.Properties
+
+		// An alert without a known end runs until it expires
+		end := properties.Ends
+		if end.IsZero() {
+			end = properties.Expires
+		}
+
+		alert := weatherAlert{
+			Event:       properties.Event,
+			Sender:      properties.SenderName,
+			Start:       properties.Onset,
+			End:         end,
+			Description: properties.Description,
+			AreaName:    properties.AreaDesc,
+		}
+
+		if feature.Geometry != nil {
+			area, err := geoJSONPolygons(feature.Geometry.Type, feature.Geometry.Coordinates)
+			if err != nil {
+				return nil, fmt.Errorf("parsing the area of %s: %w", properties.Event, err)
+			}
+			alert.Area = area
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// Outer rings of a GeoJSON Polygon or MultiPolygon, whose positions are
+// longitude first. Holes are left out.
+func geoJSONPolygons(kind string, coordinates json.RawMessage) ([]polygon, error) {
+	var rings [][][]float64
+
+	switch kind {
+	case "Polygon":
+		var polygonRings [][][]float64
+		if err := json.Unmarshal(coordinates, &polygonRings); err != nil {
+			return nil, err
+		}
+		if len(polygonRings) > 0 {
+			rings = append(rings, polygonRings[0])
+		}
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(coordinates, &polygons); err != nil {
+			return nil, err
+		}
+		for _, polygonRings := range polygons {
+			if len(polygonRings) > 0 {
+				rings = append(rings, polygonRings[0])
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected geometry %q", kind)
+	}
+
+	var area []polygon
+	for _, ring := range rings {
+		var points polygon
+		for _, position := range ring {
+			if len(position) >= 2 {
+				points = append(points, coordinate{Lat: position[1], Lon: position[0]})
+			}
+		}
+		area = append(area, points)
+	}
+
+	return area, nil
+}
+
+// Whether a point lies in the drawn area of an alert, and how far it is
+// from its nearest corner when it doesn't
+func (a weatherAlert) covers(point coordinate) (bool, float64) {
+	nearest := math.Inf(1)
+	for _, shape := range a.Area {
+		if shape.contains(point) {
+			return true, 0
+		}
+
+		for _, corner := range shape {
+			nearest = min(nearest, distanceKm(point, corner))
+		}
+	}
+
+	return false, nearest
+}
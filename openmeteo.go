@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const OPEN_METEO_URL = "https://api.open-meteo.com/v1/forecast"
+
+// Variables requested for the current conditions and the hourly forecast
+const OPEN_METEO_CURRENT = "temperature_2m,apparent_temperature,relative_humidity_2m,dew_point_2m,is_day,weather_code,cloud_cover,pressure_msl,visibility,uv_index,wind_speed_10m,wind_direction_10m,wind_gusts_10m"
+const OPEN_METEO_HOURLY = "temperature_2m,apparent_temperature,relative_humidity_2m,dew_point_2m,is_day,weather_code,cloud_cover,pressure_msl,visibility,uv_index,wind_speed_10m,wind_direction_10m,wind_gusts_10m,precipitation_probability,precipitation"
+const OPEN_METEO_DAILY = "weather_code,temperature_2m_max,temperature_2m_min,sunrise,sunset,uv_index_max,precipitation_sum,precipitation_probability_max,wind_speed_10m_max,wind_gusts_10m_max,wind_direction_10m_dominant"
+
+// Current conditions from Open-Meteo
+type openMeteoValues struct {
+	Time                int64   `json:"time"`
+	Temperature         float64 `json:"temperature_2m"`
+	ApparentTemperature float64 `json:"apparent_temperature"`
+	RelativeHumidity    float64 `json:"relative_humidity_2m"`
+	DewPoint            float64 `json:"dew_point_2m"`
+	IsDay               int     `json:"is_day"`
+	WeatherCode         int     `json:"weather_code"`
+	CloudCover          float64 `json:"cloud_cover"`
+	PressureMSL         float64 `json:"pressure_msl"`
+	Visibility          float64 `json:"visibility"`
+	UVIndex             float64 `json:"uv_index"`
+	WindSpeed           float64 `json:"wind_speed_10m"`
+	WindDirection       float64 `json:"wind_direction_10m"`
+	WindGusts           float64 `json:"wind_gusts_10m"`
+}
+
+// Open-Meteo returns hourly and daily data as parallel arrays
+type openMeteoHourly struct {
+	Time                []int64   `json:"time"`
+	Temperature         []float64 `json:"temperature_2m"`
+	ApparentTemperature []float64 `json:"apparent_temperature"`
+	RelativeHumidity    []float64 `json:"relative_humidity_2m"`
+	DewPoint            []float64 `json:"dew_point_2m"`
+	IsDay               []int     `json:"is_day"`
+	WeatherCode         []int     `json:"weather_code"`
+	CloudCover          []float64 `json:"cloud_cover"`
+	PressureMSL         []float64 `json:"pressure_msl"`
+	Visibility          []float64 `json:"visibility"`
+	UVIndex             []float64 `json:"uv_index"`
+	WindSpeed           []float64 `json:"wind_speed_10m"`
+	WindDirection       []float64 `json:"wind_direction_10m"`
+	WindGusts           []float64 `json:"wind_gusts_10m"`
+	PrecipitationProb   []float64 `json:"precipitation_probability"`
+	Precipitation       []float64 `json:"precipitation"`
+}
+
+type openMeteoDaily struct {
+	Time              []int64   `json:"time"`
+	WeatherCode       []int     `json:"weather_code"`
+	TemperatureMax    []float64 `json:"temperature_2m_max"`
+	TemperatureMin    []float64 `json:"temperature_2m_min"`
+	Sunrise           []int64   `json:"sunrise"`
+	Sunset            []int64   `json:"sunset"`
+	UVIndexMax        []float64 `json:"uv_index_max"`
+	PrecipitationSum  []float64 `json:"precipitation_sum"`
+	PrecipitationProb []float64 `json:"precipitation_probability_max"`
+	WindSpeedMax      []float64 `json:"wind_speed_10m_max"`
+	WindGustsMax      []float64 `json:"wind_gusts_10m_max"`
+	WindDirection     []float64 `json:"wind_direction_10m_dominant"`
+}
+
+type openMeteoResponse struct {
+	Latitude         float64         `json:"latitude"`
+	Longitude        float64         `json:"longitude"`
+	Timezone         string          `json:"timezone"`
+	UTCOffsetSeconds int             `json:"utc_offset_seconds"`
+	Current          openMeteoValues `json:"current"`
+	Hourly           openMeteoHourly `json:"hourly"`
+	Daily            openMeteoDaily  `json:"daily"`
+}
+
+// Builds the common query for Open-Meteo endpoints in the requested units
+func openMeteoQuery(at coordinate, units unitSystem) url.Values {
+	query := url.Values{}
+	query.Set("latitude", fmt.Sprintf("%f", at.Lat))
+	query.Set("longitude", fmt.Sprintf("%f", at.Lon))
+	query.Set("timezone", "auto")
+	query.Set("timeformat", "unixtime")
+
+	if units == IMPERIAL {
+		query.Set("temperature_unit", "fahrenheit")
+		query.Set("wind_speed_unit", "mph")
+		query.Set("precipitation_unit", "inch")
+	} else {
+		query.Set("wind_speed_unit", "ms")
+	}
+
+	return query
+}
+
+// Fetches weather from Open-Meteo, which needs no API key
+func fetchOpenMeteo(ctx context.Context, at coordinate, units unitSystem) (weatherData, error) {
+	status("[@] Fetching weather from Open-Meteo")
+
+	query := openMeteoQuery(at, units)
+	query.Set("current", OPEN_METEO_CURRENT)
+	query.Set("hourly", OPEN_METEO_HOURLY)
+	query.Set("daily", OPEN_METEO_DAILY)
+	query.Set("forecast_days", "8")
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return weatherData{}, fmt.Errorf("fetching weather from Open-Meteo: %w", err)
+	}
+
+	var parsedResponse openMeteoResponse
+	if err := json.Unmarshal(body, &parsedResponse); err != nil {
+		return weatherData{}, fmt.Errorf("parsing Open-Meteo response: %w", err)
+	}
+
+	if parsedResponse.Current.Time == 0 {
+		return weatherData{}, errors.New("Open-Meteo response contains no current conditions")
+	}
+
+	return parsedResponse.toWeatherData(), nil
+}
+
+// Maps an Open-Meteo response onto weatherData. Units were already chosen
+// in the query, so only field names and weather codes need translating.
+func (r openMeteoResponse) toWeatherData() weatherData {
+	zone := time.FixedZone(r.Timezone, r.UTCOffsetSeconds)
+	local := func(unix int64) time.Time { return unixIn(unix, zone) }
+
+	current := r.Current
+
+	data := weatherData{
+		Coord:    coordinate{Lat: r.Latitude, Lon: r.Longitude},
+		Timezone: r.Timezone,
+		Offset:   r.UTCOffsetSeconds,
+		Current: conditions{
+			Time:       local(current.Time),
+			Temp:       current.Temperature,
+			FeelsLike:  current.ApparentTemperature,
+			DewPoint:   current.DewPoint,
+			Pressure:   int64(current.PressureMSL + 0.5),
+			Humidity:   int64(current.RelativeHumidity),
+			Clouds:     int64(current.CloudCover),
+			UVI:        current.UVIndex,
+			Visibility: int64(current.Visibility),
+			WindSpeed:  current.WindSpeed,
+			WindDeg:    int64(current.WindDirection),
+			WindGust:   current.WindGusts,
+			Condition:  wmoCondition(current.WeatherCode, current.IsDay == 1),
+		},
+	}
+
+	hourly := r.Hourly
+	for index, dt := range hourly.Time {
+		// Skip hours that already passed
+		if dt+3600 <= current.Time {
+			continue
+		}
+
+		data.Hourly = append(data.Hourly, conditions{
+			Time:          local(dt),
+			Temp:          valueAt(hourly.Temperature, index),
+			FeelsLike:     valueAt(hourly.ApparentTemperature, index),
+			DewPoint:      valueAt(hourly.DewPoint, index),
+			Pressure:      int64(valueAt(hourly.PressureMSL, index) + 0.5),
+			Humidity:      int64(valueAt(hourly.RelativeHumidity, index)),
+			Clouds:        int64(valueAt(hourly.CloudCover, index)),
+			UVI:           valueAt(hourly.UVIndex, index),
+			Visibility:    int64(valueAt(hourly.Visibility, index)),
+			WindSpeed:     valueAt(hourly.WindSpeed, index),
+			WindDeg:       int64(valueAt(hourly.WindDirection, index)),
+			WindGust:      valueAt(hourly.WindGusts, index),
+			Condition:     wmoCondition(valueAt(hourly.WeatherCode, index), valueAt(hourly.IsDay, index) == 1),
+			Pop:           valueAt(hourly.PrecipitationProb, index) / 100,
+			Precipitation: valueAt(hourly.Precipitation, index),
+		})
+	}
+
+	daily := r.Daily
+	for index, dt := range daily.Time {
+		// Daily times are local midnight; use noon like the other providers
+		data.Daily = append(data.Daily, dailyForecast{
+			Date:          local(dt).Add(12 * time.Hour),
+			Sunrise:       local(valueAt(daily.Sunrise, index)),
+			Sunset:        local(valueAt(daily.Sunset, index)),
+			TempMax:       valueAt(daily.TemperatureMax, index),
+			TempMin:       valueAt(daily.TemperatureMin, index),
+			UVI:           valueAt(daily.UVIndexMax, index),
+			WindSpeed:     valueAt(daily.WindSpeedMax, index),
+			WindDeg:       int64(valueAt(daily.WindDirection, index)),
+			WindGust:      valueAt(daily.WindGustsMax, index),
+			Precipitation: valueAt(daily.PrecipitationSum, index),
+			Pop:           valueAt(daily.PrecipitationProb, index) / 100,
+			Condition:     wmoCondition(valueAt(daily.WeatherCode, index), true),
+		})
+	}
+
+	// Today's entry carries the sunrise and sunset for the current block
+	if len(data.Daily) > 0 {
+		data.Current.Sunrise = data.Daily[0].Sunrise
+		data.Current.Sunset = data.Daily[0].Sunset
+	}
+
+	return data
+}
+
+// Returns the value at index, or the zero value when the array is short
+func valueAt[T any](values []T, index int) T {
+	var zero T
+	if index < len(values) {
+		return values[index]
+	}
+
+	return zero
+}
+
+// WMO weather interpretation codes mapped to conditions
+var wmoConditions = map[int]condition{
+	0:  {Kind: CLEAR, Description: "clear sky"},
+	1:  {Kind: PARTLY_CLOUDY, Description: "mainly clear"},
+	2:  {Kind: PARTLY_CLOUDY, Description: "partly cloudy"},
+	3:  {Kind: CLOUDY, Description: "overcast"},
+	45: {Kind: FOG, Description: "fog"},
+	48: {Kind: FOG, Description: "depositing rime fog"},
+	51: {Kind: DRIZZLE, Description: "light drizzle"},
+	53: {Kind: DRIZZLE, Description: "drizzle"},
+	55: {Kind: DRIZZLE, Description: "dense drizzle"},
+	56: {Kind: SLEET, Description: "light freezing drizzle"},
+	57: {Kind: SLEET, Description: "freezing drizzle"},
+	61: {Kind: RAIN, Description: "light rain"},
+	63: {Kind: RAIN, Description: "moderate rain"},
+	65: {Kind: RAIN, Description: "heavy rain"},
+	66: {Kind: SLEET, Description: "light freezing rain"},
+	67: {Kind: SLEET, Description: "freezing rain"},
+	71: {Kind: SNOW, Description: "light snow"},
+	73: {Kind: SNOW, Description: "snow"},
+	75: {Kind: SNOW, Description: "heavy snow"},
+	77: {Kind: SNOW, Description: "snow grains"},
+	80: {Kind: SHOWERS, Description: "light rain showers"},
+	81: {Kind: SHOWERS, Description: "rain showers"},
+	82: {Kind: SHOWERS, Description: "violent rain showers"},
+	85: {Kind: SNOW, Description: "light snow showers"},
+	86: {Kind: SNOW, Description: "snow showers"},
+	95: {Kind: THUNDERSTORM, Description: "thunderstorm"},
+	96: {Kind: THUNDERSTORM, Description: "thunderstorm with light hail"},
+	99: {Kind: THUNDERSTORM, Description: "thunderstorm with hail"},
+}
+
+// Converts a WMO code into a day or night condition, described in the
+// chosen language
+func wmoCondition(code int, day bool) condition {
+	result, known := wmoConditions[code]
+	if known {
+		result.Description = tr(result.Description)
+	} else {
+		result = condition{Kind: CLOUDY, Description: fmt.Sprintf(tr("weather code %d"), code)}
+	}
+
+	result.Night = !day
+
+	return result
+}
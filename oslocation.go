@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Longest the operating system may take to find a position
+const NATIVE_LOCATION_TIMEOUT = 10 * time.Second
+
+// Reported when this machine has no location service the program can use,
+// so the next source is tried without a warning
+var errNoLocationService = errors.New("no location service available")
+
+// GeoClue's demo client, which prints the position it gets and exits. Where
+// it is installed differs between distributions.
+var whereAmIPaths = []string{
+	"/usr/libexec/geoclue-2.0/demos/where-am-i",
+	"/usr/lib/geoclue-2.0/demos/where-am-i",
+	"/usr/lib/geoclue/demos/where-am-i",
+}
+
+// Lines of where-am-i's output such as "Latitude:    59.858000°"
+var whereAmIPattern = regexp.MustCompile(`(?m)^(Latitude|Longitude):\s*(-?[\d.]+)`)
+
+// PowerShell script reading the Windows Location API. Exits with 2 when no
+// position is known and 3 when access is denied in the privacy settings.
+const WINDOWS_LOCATION_SCRIPT = `
+Add-Type -AssemblyName System.Device
+$watcher = New-Object System.Device.Location.GeoCoordinateWatcher
+$watcher.Start()
+$deadline = (Get-Date).AddSeconds(9)
+while ($watcher.Status -ne 'Ready' -and $watcher.Permission -ne 'Denied' -and (Get-Date) -lt $deadline) {
+	Start-Sleep -Milliseconds 100
+}
+if ($watcher.Permission -eq 'Denied') { exit 3 }
+$position = $watcher.Position.Location
+if ($position.IsUnknown) { exit 2 }
+$culture = [Globalization.CultureInfo]::InvariantCulture
+$position.Latitude.ToString($culture) + ' ' + $position.Longitude.ToString($culture)
+`
+
+// Asks the operating system's location service where the machine is:
+// GeoClue on Linux, CoreLocation on macOS through CoreLocationCLI, or the
+// Windows Location API. These can use Wi-Fi and GPS, so they beat IP lookups.
+func locateWithSystem(ctx context.Context) (location, error) {
+	ctx, cancel := context.WithTimeout(ctx, NATIVE_LOCATION_TIMEOUT)
+	defer cancel()
+
+	var output []byte
+	var err error
+
+	switch runtime.GOOS {
+	case "darwin":
+		// CoreLocation only answers apps, so a helper from Homebrew asks
+		output, err = locationCommand(ctx, "CoreLocationCLI", "--format", "%latitude %longitude")
+		if err != nil {
+			return location{}, err
+		}
+	case "windows":
+		output, err = locationCommand(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", WINDOWS_LOCATION_SCRIPT)
+		switch exitCode(err) {
+		case 2:
+			return location{}, errors.New("windows doesn't know where this machine is")
+		case 3:
+			return location{}, errors.New("location access is turned off in the windows privacy settings")
+		}
+		if err != nil {
+			return location{}, err
+		}
+	default:
+		var tool string
+		for _, path := range whereAmIPaths {
+			if _, statErr := os.Stat(path); statErr == nil {
+				tool = path
+				break
+			}
+		}
+		if tool == "" {
+			return location{}, errNoLocationService
+		}
+
+		// where-am-i keeps printing updates until the timeout it's given
+		output, err = locationCommand(ctx, tool, "-t", "3", "-a", "8")
+		if err != nil && len(output) == 0 {
+			return location{}, err
+		}
+
+		var fields []string
+		for _, match := range whereAmIPattern.FindAllStringSubmatch(string(output), 2) {
+			fields = append(fields, match[2])
+		}
+		output = []byte(strings.Join(fields, " "))
+	}
+
+	values := strings.Fields(string(output))
+	if len(values) != 2 {
+		return location{}, fmt.Errorf("unexpected answer from the location service: %q", strings.TrimSpace(string(output)))
+	}
+
+	lat, latErr := strconv.ParseFloat(values[0], 64)
+	lon, lonErr := strconv.ParseFloat(values[1], 64)
+	if latErr != nil || lonErr != nil {
+		return location{}, fmt.Errorf("unexpected answer from the location service: %q", strings.TrimSpace(string(output)))
+	}
+
+	// Named like coordinates typed by the user, so they get the name of the
+	// nearest place
+	return location{Coord: coordinate{Lat: lat, Lon: lon}, Name: fmt.Sprintf("%.4f,%.4f", lat, lon)}, nil
+}
+
+// Runs a location helper, reporting a missing one as errNoLocationService.
+// Errors carry whatever the helper printed on stderr.
+func locationCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, errNoLocationService
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return output, fmt.Errorf("%s found no position within %s", name, NATIVE_LOCATION_TIMEOUT)
+		}
+
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return output, fmt.Errorf("%s: %w: %s", name, err, message)
+		}
+
+		return output, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return output, nil
+}
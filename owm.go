@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// These define schema for a searched response for a location
+type locationSearchResult struct {
+	Message string     `json:"message"`
+	Cod     string     `json:"cod"`
+	Count   int        `json:"count"`
+	Lists   []location `json:"list"`
+}
+
+// OpenWeatherMap's own response schema, mapped onto weatherData by toWeatherData
+type owmCondition struct {
+	ID          int64  `json:"id"`
+	Main        string `json:"main"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+type owmRain struct {
+	OneH float64 `json:"1h"`
+}
+
+type owmCurrent struct {
+	Dt         int64          `json:"dt"`
+	Sunrise    int64          `json:"sunrise"`
+	Sunset     int64          `json:"sunset"`
+	Temp       float64        `json:"temp"`
+	FeelsLike  float64        `json:"feels_like"`
+	Pressure   int64          `json:"pressure"`
+	Humidity   int64          `json:"humidity"`
+	DewPoint   float64        `json:"dew_point"`
+	UVI        float64        `json:"uvi"`
+	Clouds     int64          `json:"clouds"`
+	Visibility int64          `json:"visibility"`
+	WindSpeed  float64        `json:"wind_speed"`
+	WindDeg    int64          `json:"wind_deg"`
+	WindGust   float64        `json:"wind_gust"`
+	Weather    []owmCondition `json:"weather"`
+}
+
+type owmMinutely struct {
+	Dt            int64   `json:"dt"`
+	Precipitation float64 `json:"precipitation"`
+}
+
+type owmHourly struct {
+	owmCurrent
+	Pop  float64  `json:"pop"`
+	Rain *owmRain `json:"rain,omitempty"`
+	Snow *owmRain `json:"snow,omitempty"`
+}
+
+type owmDaily struct {
+	Dt            int64          `json:"dt"`
+	Sunrise       int64          `json:"sunrise"`
+	Sunset        int64          `json:"sunset"`
+	TempMax       float64        `json:"temp_max"`
+	TempMin       float64        `json:"temp_min"`
+	Pressure      int64          `json:"pressure"`
+	Humidity      int64          `json:"humidity"`
+	WindSpeed     float64        `json:"wind_speed"`
+	WindDeg       int64          `json:"wind_deg"`
+	WindGust      float64        `json:"wind_gust"`
+	Weather       []owmCondition `json:"weather"`
+	Clouds        int64          `json:"clouds"`
+	Precipitation float64        `json:"precipitation"`
+	Pop           float64        `json:"pop"`
+	UVI           float64        `json:"uvi"`
+}
+
+type owmAlert struct {
+	SenderName  string `json:"sender_name"`
+	Event       string `json:"event"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
+	Description string `json:"description"`
+}
+
+type owmResponse struct {
+	Lat            float64       `json:"lat"`
+	Lon            float64       `json:"lon"`
+	Timezone       string        `json:"timezone"`
+	TimezoneOffset float64       `json:"timezone_offset"`
+	Current        owmCurrent    `json:"current"`
+	Minutely       []owmMinutely `json:"minutely"`
+	Hourly         []owmHourly   `json:"hourly"`
+	Daily          []owmDaily    `json:"daily"`
+	Alerts         []owmAlert    `json:"alerts"`
+}
+
+const URL = "https://app.owm.io/app"
+
+// These are specific API keys
+const DEVICE_ID = "e13401912dbaf7cc"
+const APP_ID = "e0c56f6c3cee94d1a83f36043ff1ce5b"
+const TOKEN = DEVICE_ID + ":APA91bGAmF46L0bGb2jVYVfVKNpWePUqWdgoo4hz8_LLkfECQ8qw8JdcA-8hsJ6WSgjfEY5CvgjNoYMYF8PLvGlJ9GFM2ERKnKWjBR_Hq2tjsuZABJ_io3c"
+
+// Public One Call API, which answers in the same shape as the app's endpoint
+const ONE_CALL_URL = "https://api.openweathermap.org/data/3.0/onecall"
+
+// Personal OpenWeatherMap key. When set, weather comes from the One Call API
+// under the user's own account instead of the app's shared keys.
+var owmAPIKey string
+
+func (l locationName) findCoordinate(ctx context.Context) (locationSearchResult, error) {
+	status("[@] Searching for " + string(l))
+
+	// URL to be used to make request
+	TARGET_URL := fmt.Sprintf("%s/1.1/find/?q=%s&appid=%s&deviceid=%s", URL, url.QueryEscape(string(l)), APP_ID, DEVICE_ID)
+
+	var parsedResponse locationSearchResult
+
+	body, err := fetch(ctx, TARGET_URL)
+	if err != nil {
+		return parsedResponse, fmt.Errorf("searching for %q: %w", string(l), err)
+	}
+
+	// Parse the response to json
+	err = json.Unmarshal(body, &parsedResponse)
+	if err != nil {
+		return parsedResponse, fmt.Errorf("parsing search results for %q: %w", string(l), err)
+	}
+
+	return parsedResponse, nil
+}
+
+func (c coordinate) findWeather(ctx context.Context, units unitSystem) (weatherData, error) {
+	status("[@] Searching for weather")
+
+	TARGET_URL := fmt.Sprintf("%s/1.0/weather/?lat=%f&lon=%f&units=%s&lang=%s&appid=%s&deviceid=%s&token=%s", URL, c.Lat, c.Lon, units, owmLanguage(), APP_ID, DEVICE_ID, TOKEN)
+	if owmAPIKey != "" {
+		TARGET_URL = fmt.Sprintf("%s?lat=%f&lon=%f&units=%s&lang=%s&appid=%s", ONE_CALL_URL, c.Lat, c.Lon, units, owmLanguage(), url.QueryEscape(owmAPIKey))
+	}
+
+	body, err := fetch(ctx, TARGET_URL)
+	if err != nil {
+		return weatherData{}, fmt.Errorf("fetching weather: %w", err)
+	}
+
+	var parsedResponse owmResponse
+	err = json.Unmarshal(body, &parsedResponse)
+	if err != nil {
+		return weatherData{}, fmt.Errorf("parsing weather response: %w", err)
+	}
+
+	if len(parsedResponse.Current.Weather) == 0 {
+		return weatherData{}, errors.New("weather response contains no current conditions")
+	}
+
+	return parsedResponse.toWeatherData(units), nil
+}
+
+// The chosen language as OpenWeatherMap spells it, which writes regional
+// variants like pt_br
+func owmLanguage() string {
+	return strings.ReplaceAll(language, "-", "_")
+}
+
+// Maps an OpenWeatherMap response onto weatherData. OpenWeatherMap already
+// answers in the requested units, except for precipitation which is always mm.
+func (r owmResponse) toWeatherData(units unitSystem) weatherData {
+	offset := int(r.TimezoneOffset)
+	zone := time.FixedZone(r.Timezone, offset)
+	local := func(unix int64) time.Time { return unixIn(unix, zone) }
+	precipitation := func(mm float64) float64 { return units.fromMillimeters(mm) }
+
+	data := weatherData{
+		Coord:    coordinate{Lat: r.Lat, Lon: r.Lon},
+		Timezone: r.Timezone,
+		Offset:   offset,
+		Current:  r.Current.toConditions(zone),
+	}
+	data.Current.Sunrise = local(r.Current.Sunrise)
+	data.Current.Sunset = local(r.Current.Sunset)
+
+	for _, minute := range r.Minutely {
+		data.Minutely = append(data.Minutely, precipitationStep{Time: local(minute.Dt), Precipitation: precipitation(minute.Precipitation)})
+	}
+
+	for _, hour := range r.Hourly {
+		conditions := hour.toConditions(zone)
+		conditions.Pop = hour.Pop
+		for _, amount := range []*owmRain{hour.Rain, hour.Snow} {
+			if amount != nil {
+				conditions.Precipitation += precipitation(amount.OneH)
+			}
+		}
+
+		data.Hourly = append(data.Hourly, conditions)
+	}
+
+	for _, day := range r.Daily {
+		data.Daily = append(data.Daily, dailyForecast{
+			Date:          local(day.Dt),
+			Sunrise:       local(day.Sunrise),
+			Sunset:        local(day.Sunset),
+			TempMax:       day.TempMax,
+			TempMin:       day.TempMin,
+			Pressure:      day.Pressure,
+			Humidity:      day.Humidity,
+			Clouds:        day.Clouds,
+			UVI:           day.UVI,
+			WindSpeed:     day.WindSpeed,
+			WindDeg:       day.WindDeg,
+			WindGust:      day.WindGust,
+			Precipitation: precipitation(day.Precipitation),
+			Pop:           day.Pop,
+			Condition:     owmConditionOf(day.Weather),
+		})
+	}
+
+	for _, alert := range r.Alerts {
+		data.Alerts = append(data.Alerts, weatherAlert{
+			Event:       alert.Event,
+			Sender:      alert.SenderName,
+			Start:       local(alert.Start),
+			End:         local(alert.End),
+			Description: alert.Description,
+		})
+	}
+
+	return data
+}
+
+func (c owmCurrent) toConditions(zone *time.Location) conditions {
+	return conditions{
+		Time:       unixIn(c.Dt, zone),
+		Temp:       c.Temp,
+		FeelsLike:  c.FeelsLike,
+		DewPoint:   c.DewPoint,
+		Pressure:   c.Pressure,
+		Humidity:   c.Humidity,
+		Clouds:     c.Clouds,
+		UVI:        c.UVI,
+		Visibility: c.Visibility,
+		WindSpeed:  c.WindSpeed,
+		WindDeg:    c.WindDeg,
+		WindGust:   c.WindGust,
+		Condition:  owmConditionOf(c.Weather),
+	}
+}
+
+// Converts the first OpenWeatherMap condition into a condition. Codes are
+// grouped by hundreds (2xx thunderstorm, 3xx drizzle, 5xx rain, 6xx snow,
+// 7xx atmosphere, 80x clouds) and icons end in "n" at night.
+func owmConditionOf(conditions []owmCondition) condition {
+	if len(conditions) == 0 {
+		return condition{Kind: CLOUDY, Description: "unknown"}
+	}
+
+	owm := conditions[0]
+	result := condition{Description: owm.Description, Night: strings.HasSuffix(owm.Icon, "n")}
+
+	switch id := owm.ID; {
+	case id >= 200 && id < 300:
+		result.Kind = THUNDERSTORM
+	case id >= 300 && id < 400:
+		result.Kind = DRIZZLE
+	case id == 511:
+		result.Kind = SLEET
+	case id >= 520 && id < 600:
+		result.Kind = SHOWERS
+	case id >= 500 && id < 600:
+		result.Kind = RAIN
+	case id >= 611 && id <= 616:
+		result.Kind = SLEET
+	case id >= 600 && id < 700:
+		result.Kind = SNOW
+	case id >= 700 && id < 800:
+		result.Kind = FOG
+	case id == 800:
+		result.Kind = CLEAR
+	case id == 801 || id == 802:
+		result.Kind = PARTLY_CLOUDY
+	default:
+		result.Kind = CLOUDY
+	}
+
+	return result
+}
+
+// Local time for a Unix timestamp, or the zero time when the provider left it out
+func unixIn(unix int64, zone *time.Location) time.Time {
+	if unix == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(unix, 0).In(zone)
+}
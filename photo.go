@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Bands of sun altitude in degrees that photographers plan around
+var lightPhases = []struct {
+	Name string
+	Low  float64
+	High float64
+}{
+	{"blue hour", -6, -4},
+	{"golden hour", -4, 6},
+}
+
+// One spell of soft light around sunrise or sunset
+type lightSession struct {
+	Name    string
+	Morning bool
+	From    time.Time
+	To      time.Time
+}
+
+// The blue and golden hours of a local calendar day in the order they
+// happen. Spells the sun doesn't go through, as in polar summer, are left out.
+func lightSessions(day time.Time, at coordinate) []lightSession {
+	var morning, evening []lightSession
+
+	for _, phase := range lightPhases {
+		from, rose := sunPasses(day, at, phase.Low, true)
+		to, climbed := sunPasses(day, at, phase.High, true)
+		if rose && climbed && to.After(from) {
+			morning = append(morning, lightSession{Name: phase.Name, Morning: true, From: from, To: to})
+		}
+
+		from, sank := sunPasses(day, at, phase.High, false)
+		to, set := sunPasses(day, at, phase.Low, false)
+		if sank && set && to.After(from) {
+			// Evening spells run in the opposite order of the morning ones
+			evening = append([]lightSession{{Name: phase.Name, From: from, To: to}}, evening...)
+		}
+	}
+
+	return append(morning, evening...)
+}
+
+// What the cloud cover means for light at sunrise or sunset. Some clouds
+// beat none, as they catch the color.
+func photoOutlook(hour conditions) string {
+	switch clouds := hour.Clouds; {
+	case hour.Condition.Kind.precipitating() || hour.Condition.Kind == FOG:
+		return "wet or foggy, skip it"
+	case clouds < 10:
+		return "clear, clean but plain light"
+	case clouds < 30:
+		return "good, a few clouds may light up"
+	case clouds <= 70:
+		return "best, clouds can catch the color"
+	case clouds <= 90:
+		return "mostly cloudy, the light may not break through"
+	default:
+		return "overcast, flat light"
+	}
+}
+
+// Implements `weather photo`, listing the coming blue and golden hours of
+// today and tomorrow with the forecast clouds at each
+func runPhoto(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	clock := result.Options.clockFormat()
+	now := weather.Current.Time
+
+	var sessions []lightSession
+	for offset := 0; offset < 2; offset++ {
+		for _, session := range lightSessions(now.AddDate(0, 0, offset), weather.Coord) {
+			if session.To.After(now) {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+	if len(sessions) == 0 {
+		return fmt.Errorf("the sun neither rises nor sets at %.4f, %.4f these days", weather.Coord.Lat, weather.Coord.Lon)
+	}
+
+	var sunrise *lightSession
+	rows := [][]string{translated("Day", "Light", "Time", "Clouds", "Outlook")}
+	for index, session := range sessions {
+		clouds, outlook := "-", tr("no hourly forecast yet")
+		if hour, found := weather.hourAt(session.From.Add(session.To.Sub(session.From) / 2)); found {
+			clouds = fmt.Sprintf("%d%%", hour.Clouds)
+			outlook = tr(photoOutlook(hour))
+
+			if sunrise == nil && session.Morning && session.Name == "golden hour" {
+				sunrise = &sessions[index]
+			}
+		}
+
+		rows = append(rows, []string{
+			session.From.Format("Mon"),
+			tr(session.Name),
+			session.From.Format(clock) + "–" + session.To.Format(clock),
+			clouds,
+			outlook,
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	// The question that gets people out of bed
+	if sunrise != nil {
+		hour, _ := weather.hourAt(sunrise.From.Add(sunrise.To.Sub(sunrise.From) / 2))
+		fmt.Printf("\n"+tr("Next sunrise shoot, %s %s: %s")+"\n", sunrise.From.Format("Mon"), sunrise.From.Format(clock), tr(photoOutlook(hour)))
+	}
+
+	return nil
+}
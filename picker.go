@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Most matches the picker shows at once
+const PICKER_ROWS = 10
+
+// Whether the interactive picker can run: both ends must be a terminal, and
+// raw mode is switched with stty, which Windows doesn't have
+func canPick() bool {
+	return runtime.GOOS != "windows" && isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+// Puts the terminal into raw mode and returns the function restoring it
+func rawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	return func() { stty(strings.TrimSpace(saved)) }, nil
+}
+
+// Runs stty against the terminal on stdin
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+
+	return string(output), nil
+}
+
+// Number of columns of the terminal, 80 when it can't be determined
+func terminalWidth() int {
+	size, err := stty("size")
+	if err != nil {
+		return 80
+	}
+
+	fields := strings.Fields(size)
+	if len(fields) != 2 {
+		return 80
+	}
+
+	columns, err := strconv.Atoi(fields[1])
+	if err != nil || columns <= 0 {
+		return 80
+	}
+
+	return columns
+}
+
+// Flag emoji for a two letter country code, built from regional indicators
+func countryFlag(country string) string {
+	country = strings.ToUpper(country)
+	if len(country) != 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+		return "  "
+	}
+
+	return string([]rune{0x1F1E6 + rune(country[0]-'A'), 0x1F1E6 + rune(country[1]-'A')})
+}
+
+// Scores how well a query matches text when its characters appear in order,
+// rewarding runs of consecutive characters and matches at word starts.
+// The second result is false when some character is missing.
+func fuzzyScore(query string, text string) (int, bool) {
+	needle := []rune(strings.ToLower(query))
+	haystack := []rune(strings.ToLower(text))
+
+	score, next, previous := 0, 0, -2
+	for index, char := range haystack {
+		if next == len(needle) {
+			break
+		}
+		if char != needle[next] {
+			continue
+		}
+
+		score++
+		if index == previous+1 {
+			score += 2
+		}
+		if index == 0 || !unicode.IsLetter(haystack[index-1]) {
+			score += 3
+		}
+
+		previous = index
+		next++
+	}
+
+	return score, next == len(needle)
+}
+
+// One line of the picker
+type pickerItem struct {
+	Location location
+	Label    string
+}
+
+// Matches for a query, best first and in search order on ties
+func filterItems(items []pickerItem, query string) []pickerItem {
+	type scored struct {
+		item  pickerItem
+		score int
+	}
+
+	var matches []scored
+	for _, item := range items {
+		if score, found := fuzzyScore(query, item.Label); found {
+			matches = append(matches, scored{item, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	filtered := make([]pickerItem, len(matches))
+	for index, match := range matches {
+		filtered[index] = match.item
+	}
+
+	return filtered
+}
+
+// Cuts text to fit in a number of columns
+func truncate(text string, width int) string {
+	if displayWidth(text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && displayWidth(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
+
+// Lets the user narrow the matches by typing and pick one with the arrow
+// keys. Drawn on stderr so stdout stays clean for data.
+func (l locationSearchResult) pick(ctx context.Context) (location, error) {
+	var items []pickerItem
+	for _, match := range l.Lists {
+		name := match.FullName
+		if name == "" {
+			name = match.CompactName
+		}
+
+		items = append(items, pickerItem{
+			Location: match,
+			Label:    fmt.Sprintf("%s %s (%.4f, %.4f)", countryFlag(match.Country), name, match.Coord.Lat, match.Coord.Lon),
+		})
+	}
+
+	restore, err := rawMode()
+	if err != nil {
+		// Fall back to the numbered prompt when raw mode isn't available
+		logger.Debug("raw mode unavailable", "error", err)
+		return l.chooseByIndex(ctx)
+	}
+	defer restore()
+
+	width := terminalWidth()
+
+	// Keys are read in the background so Ctrl+C via the context still works
+	keys := make(chan []byte)
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			count, err := os.Stdin.Read(buffer)
+			if err != nil {
+				close(keys)
+				return
+			}
+
+			keys <- append([]byte(nil), buffer[:count]...)
+		}
+	}()
+
+	query := ""
+	selected := 0
+	drawn := 0
+
+	draw := func(matches []pickerItem) {
+		// Return to the top of the previous frame and clear it
+		if drawn > 0 {
+			fmt.Fprintf(os.Stderr, "\x1b[%dA", drawn)
+		}
+		fmt.Fprint(os.Stderr, "\r\x1b[J")
+
+		fmt.Fprintf(os.Stderr, "Search: %s\r\n", query)
+		drawn = 1
+
+		for index, item := range matches[:min(len(matches), PICKER_ROWS)] {
+			line := truncate(item.Label, width-3)
+			if index == selected {
+				fmt.Fprintf(os.Stderr, "\x1b[7m> %s\x1b[0m\r\n", line)
+			} else {
+				fmt.Fprintf(os.Stderr, "  %s\r\n", line)
+			}
+			drawn++
+		}
+
+		if len(matches) > PICKER_ROWS {
+			fmt.Fprintf(os.Stderr, "  … %d more\r\n", len(matches)-PICKER_ROWS)
+			drawn++
+		}
+
+		fmt.Fprint(os.Stderr, "\x1b[2m↑/↓ move, type to filter, Enter select, Esc cancel\x1b[0m")
+	}
+
+	// Erases the picker so later output starts where it was drawn
+	finish := func() {
+		fmt.Fprintf(os.Stderr, "\x1b[%dA\r\x1b[J", drawn)
+	}
+
+	matches := items
+	draw(matches)
+
+	for {
+		var input []byte
+
+		select {
+		case <-ctx.Done():
+			finish()
+			return location{}, ctx.Err()
+		case read, open := <-keys:
+			if !open {
+				finish()
+				return location{}, errors.New("reading the selection from stdin failed")
+			}
+
+			input = read
+		}
+
+		switch {
+		case string(input) == "\x1b[A" || string(input) == "\x10": // Up, Ctrl+P
+			selected = max(selected-1, 0)
+		case string(input) == "\x1b[B" || string(input) == "\x0e" || string(input) == "\t": // Down, Ctrl+N, Tab
+			selected = min(selected+1, min(len(matches), PICKER_ROWS)-1)
+		case string(input) == "\r" || string(input) == "\n":
+			if len(matches) == 0 {
+				continue
+			}
+
+			finish()
+			return matches[selected].Location, nil
+		case string(input) == "\x1b" || string(input) == "\x03": // Esc, Ctrl+C
+			finish()
+			return location{}, context.Canceled
+		case string(input) == "\x7f" || string(input) == "\b": // Backspace
+			if runes := []rune(query); len(runes) > 0 {
+				query = string(runes[:len(runes)-1])
+			}
+		case input[0] == 0x1b:
+			// Ignore other escape sequences such as left and right
+			continue
+		default:
+			for _, char := range string(input) {
+				if unicode.IsPrint(char) {
+					query += string(char)
+				}
+			}
+		}
+
+		matches = filterItems(items, query)
+		selected = max(0, min(selected, min(len(matches), PICKER_ROWS)-1))
+		draw(matches)
+	}
+}
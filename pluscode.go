@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Digits of Open Location Codes, in order of value
+const PLUS_CODE_ALPHABET = "23456789CFGHJMPQRVWX"
+
+// Position of the "+" in a full Plus Code
+const PLUS_CODE_SEPARATOR = 8
+
+// Size in degrees of the area the first pair of digits picks
+const PLUS_CODE_FIRST_RESOLUTION = 20.0
+
+// Splits a query into a Plus Code and, for short codes such as
+// "9G8F+6X Zurich", the place they are relative to. Full codes look like
+// "8FVC9G8F+6X".
+func parsePlusCode(query string) (string, string, bool) {
+	code, locality, _ := strings.Cut(strings.TrimSpace(query), " ")
+	code = strings.ToUpper(strings.TrimSuffix(code, ","))
+	locality = strings.TrimSpace(locality)
+
+	separator := strings.Index(code, "+")
+	if separator < 0 || separator != strings.LastIndex(code, "+") || separator > PLUS_CODE_SEPARATOR || separator%2 != 0 {
+		return "", "", false
+	}
+
+	// Only full codes stand alone
+	if (separator == PLUS_CODE_SEPARATOR) == (locality != "") {
+		return "", "", false
+	}
+
+	// A single digit after the separator is not allowed
+	if len(code)-separator-1 == 1 {
+		return "", "", false
+	}
+
+	// Zeros pad codes of large areas, like "8FVC0000+"
+	digits := code[:separator]
+	if padded := strings.Index(digits, "0"); padded >= 0 {
+		if separator != PLUS_CODE_SEPARATOR || padded == 0 || padded%2 != 0 || strings.Trim(digits[padded:], "0") != "" || len(code) > separator+1 {
+			return "", "", false
+		}
+		digits = digits[:padded]
+	}
+
+	for _, char := range digits + code[separator+1:] {
+		if !strings.ContainsRune(PLUS_CODE_ALPHABET, char) {
+			return "", "", false
+		}
+	}
+
+	// The first digits can't point north of the pole or east of 180°
+	if separator == PLUS_CODE_SEPARATOR {
+		if strings.IndexByte(PLUS_CODE_ALPHABET, code[0]) > 8 || strings.IndexByte(PLUS_CODE_ALPHABET, code[1]) > 17 {
+			return "", "", false
+		}
+	}
+
+	return code, locality, true
+}
+
+// Decodes a full Plus Code into the center of the area it names
+func decodePlusCode(code string) coordinate {
+	digits := strings.TrimRight(strings.Replace(code, "+", "", 1), "0")
+
+	lat, lon := -90.0, -180.0
+	latResolution, lonResolution := PLUS_CODE_FIRST_RESOLUTION*20, PLUS_CODE_FIRST_RESOLUTION*20
+
+	for index, char := range digits {
+		value := float64(strings.IndexRune(PLUS_CODE_ALPHABET, char))
+
+		switch {
+		// The first ten digits are pairs, each pair a twentieth of the last
+		case index < 10 && index%2 == 0:
+			latResolution /= 20
+			lat += value * latResolution
+		case index < 10:
+			lonResolution /= 20
+			lon += value * lonResolution
+		// Then each digit picks one cell of a grid four wide and five high
+		default:
+			latResolution /= 5
+			lonResolution /= 4
+			lat += math.Floor(value/4) * latResolution
+			lon += math.Mod(value, 4) * lonResolution
+		}
+	}
+
+	return coordinate{Lat: math.Min(lat+latResolution/2, 90), Lon: math.Min(lon+lonResolution/2, 180)}
+}
+
+// The first digits of the full code for a coordinate, as many as a short
+// code leaves out
+func plusCodePrefix(coord coordinate, length int) string {
+	lat := math.Min(math.Max(coord.Lat, -90), 90) + 90
+	lon := math.Mod(math.Mod(coord.Lon+180, 360)+360, 360)
+
+	// The north pole belongs to the last row
+	if lat >= 180 {
+		lat = 180 - 1e-9
+	}
+
+	var prefix strings.Builder
+	resolution := PLUS_CODE_FIRST_RESOLUTION
+	for prefix.Len() < length {
+		latDigit, lonDigit := int(lat/resolution), int(lon/resolution)
+		prefix.WriteByte(PLUS_CODE_ALPHABET[latDigit])
+		prefix.WriteByte(PLUS_CODE_ALPHABET[lonDigit])
+
+		lat -= float64(latDigit) * resolution
+		lon -= float64(lonDigit) * resolution
+		resolution /= 20
+	}
+
+	return prefix.String()
+}
+
+// Completes a short code with the digits of a nearby place, moving to the
+// neighbouring area when that is closer to the reference
+func recoverPlusCode(code string, reference coordinate) coordinate {
+	missing := PLUS_CODE_SEPARATOR - strings.Index(code, "+")
+	resolution := math.Pow(20, 2-float64(missing)/2)
+
+	center := decodePlusCode(plusCodePrefix(reference, missing) + code)
+
+	switch {
+	case reference.Lat+resolution/2 < center.Lat && center.Lat-resolution >= -90:
+		center.Lat -= resolution
+	case reference.Lat-resolution/2 > center.Lat && center.Lat+resolution <= 90:
+		center.Lat += resolution
+	}
+
+	switch {
+	case reference.Lon+resolution/2 < center.Lon:
+		center.Lon -= resolution
+	case reference.Lon-resolution/2 > center.Lon:
+		center.Lon += resolution
+	}
+
+	// Stepping across the antimeridian
+	if center.Lon > 180 {
+		center.Lon -= 360
+	} else if center.Lon < -180 {
+		center.Lon += 360
+	}
+
+	return center
+}
+
+// Resolves a Plus Code, looking up the place a short code is relative to
+func findPlusCode(ctx context.Context, code string, locality string) (locationSearchResult, error) {
+	coord := coordinate{}
+	if locality == "" {
+		coord = decodePlusCode(code)
+	} else {
+		nearby, err := searchLocations(ctx, locality)
+		if err != nil {
+			return locationSearchResult{}, fmt.Errorf("finding %q for Plus Code %s: %w", locality, code, err)
+		}
+		if len(nearby.Lists) == 0 {
+			return locationSearchResult{}, fmt.Errorf("no location found for %q to complete Plus Code %s", locality, code)
+		}
+
+		coord = recoverPlusCode(code, nearby.Lists[0].Coord)
+	}
+
+	name := code
+	if locality != "" {
+		name += " " + locality
+	}
+
+	return locationSearchResult{Count: 1, Lists: []location{{Coord: coord, Name: name, FullName: name}}}, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Default number of items processed at once by bulk operations
+const DEFAULT_CONCURRENCY = 4
+
+// Width of the progress bar in characters
+const PROGRESS_WIDTH = 30
+
+// Maximum number of items processed at once, set with -concurrency
+var concurrency = DEFAULT_CONCURRENCY
+
+// Set while a progress bar is on screen
+var progressShown atomic.Bool
+
+// Outcome of processing a single item in a bulk operation
+type jobResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// Terminal progress bar drawn on stderr
+type progressBar struct {
+	mu    sync.Mutex
+	label string
+	total int
+	done  int
+	shown bool
+}
+
+// Reports whether the file is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func newProgressBar(label string, total int) *progressBar {
+	// Only draw when someone is watching and there is more than one item
+	shown := !quiet && total > 1 && isTerminal(os.Stderr)
+
+	bar := &progressBar{label: label, total: total, shown: shown}
+	progressShown.Store(shown)
+	bar.draw()
+
+	return bar
+}
+
+func (p *progressBar) draw() {
+	if !p.shown {
+		return
+	}
+
+	filled := PROGRESS_WIDTH * p.done / p.total
+	fmt.Fprintf(os.Stderr, "\r%s [%s%s] %d/%d", p.label, strings.Repeat("#", filled), strings.Repeat(".", PROGRESS_WIDTH-filled), p.done, p.total)
+}
+
+// Marks one more item as finished
+func (p *progressBar) step() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.draw()
+}
+
+// Moves the cursor past the bar so later output starts on a fresh line
+func (p *progressBar) finish() {
+	if p.shown {
+		fmt.Fprintln(os.Stderr)
+		progressShown.Store(false)
+	}
+}
+
+// Runs fn over every item using at most `concurrency` goroutines. Results are
+// returned in the same order as items, and failures are summarized on stderr
+// once everything has finished.
+func runPool[I, O any](label string, items []I, name func(I) string, fn func(I) (O, error)) []jobResult[O] {
+	results := make([]jobResult[O], len(items))
+
+	workers := concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	bar := newProgressBar(label, len(items))
+
+	// Each worker pulls indexes until the queue is drained
+	queue := make(chan int)
+	var wg sync.WaitGroup
+
+	for range min(workers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for index := range queue {
+				value, err := fn(items[index])
+				results[index] = jobResult[O]{Value: value, Err: err}
+				bar.step()
+			}
+		}()
+	}
+
+	for index := range items {
+		queue <- index
+	}
+	close(queue)
+
+	wg.Wait()
+	bar.finish()
+
+	// Summarize every failure at the end instead of failing silently
+	var failures []string
+	for index, result := range results {
+		if result.Err != nil {
+			failures = append(failures, fmt.Sprintf("  %s: %v", name(items[index]), result.Err))
+		}
+	}
+
+	if len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d items failed:\n", len(failures), len(items))
+		fmt.Fprintln(os.Stderr, strings.Join(failures, "\n"))
+	}
+
+	return results
+}
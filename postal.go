@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Looks up postal codes without a key, for about 60 countries
+const POSTAL_URL = "https://api.zippopotam.us"
+
+// Zippopotam's answer for one postal code
+type postalResponse struct {
+	PostCode    string        `json:"post code"`
+	Country     string        `json:"country"`
+	CountryCode string        `json:"country abbreviation"`
+	Places      []postalPlace `json:"places"`
+}
+
+// One place inside a postal code area
+type postalPlace struct {
+	Name      string `json:"place name"`
+	State     string `json:"state"`
+	StateCode string `json:"state abbreviation"`
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+}
+
+// Splits a query such as "94103,US" or "SW1A, GB" into a postal code and a
+// two letter country code. Codes need at least one digit, so "Paris,FR"
+// stays a place name.
+func parsePostalCode(query string) (string, string, bool) {
+	index := strings.LastIndex(query, ",")
+	if index < 0 {
+		return "", "", false
+	}
+
+	code := strings.TrimSpace(query[:index])
+	country := strings.ToUpper(strings.TrimSpace(query[index+1:]))
+
+	if len(country) != 2 || !unicode.IsLetter(rune(country[0])) || !unicode.IsLetter(rune(country[1])) {
+		return "", "", false
+	}
+
+	if len(code) < 2 || len(code) > 10 || !strings.ContainsFunc(code, unicode.IsDigit) {
+		return "", "", false
+	}
+
+	for _, char := range code {
+		if !(char < unicode.MaxASCII && (unicode.IsLetter(char) || unicode.IsDigit(char)) || char == ' ' || char == '-') {
+			return "", "", false
+		}
+	}
+
+	return code, country, true
+}
+
+// Finds the places a postal code covers in a country
+func findPostalCode(ctx context.Context, code string, country string) (locationSearchResult, error) {
+	status("[@] Searching for postal code " + code + " in " + country)
+
+	TARGET_URL := fmt.Sprintf("%s/%s/%s", POSTAL_URL, strings.ToLower(country), url.PathEscape(code))
+
+	var result locationSearchResult
+
+	body, err := fetch(ctx, TARGET_URL)
+	if err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound {
+			return result, fmt.Errorf("no place found for postal code %s in %s", code, country)
+		}
+
+		return result, fmt.Errorf("looking up postal code %s: %w", code, err)
+	}
+
+	var parsed postalResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return result, fmt.Errorf("parsing postal code %s: %w", code, err)
+	}
+
+	for _, place := range parsed.Places {
+		lat, latErr := strconv.ParseFloat(place.Latitude, 64)
+		lon, lonErr := strconv.ParseFloat(place.Longitude, 64)
+		if latErr != nil || lonErr != nil {
+			continue
+		}
+
+		region := place.StateCode
+		if region == "" {
+			region = place.State
+		}
+
+		fullName := place.Name
+		if region != "" {
+			fullName += ", " + region
+		}
+
+		result.Lists = append(result.Lists, location{
+			Coord:       coordinate{Lat: lat, Lon: lon},
+			Name:        place.Name,
+			FullName:    fmt.Sprintf("%s %s (%s)", parsed.PostCode, fullName, parsed.CountryCode),
+			CompactName: place.Name + ", " + parsed.CountryCode,
+			Country:     parsed.CountryCode,
+		})
+	}
+	result.Count = len(result.Lists)
+
+	if result.Count == 0 {
+		return result, fmt.Errorf("no place found for postal code %s in %s", code, country)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fetches weather for a coordinate in the requested units
+type weatherFetcher func(ctx context.Context, at coordinate, units unitSystem) (weatherData, error)
+
+// Every weather backend by the name used with -providers
+var weatherProviders = map[string]weatherFetcher{
+	"owm": func(ctx context.Context, at coordinate, units unitSystem) (weatherData, error) {
+		return at.findWeather(ctx, units)
+	},
+	"open-meteo": fetchOpenMeteo,
+	"met.no":     fetchMetNo,
+}
+
+// Providers whose answers include official weather alerts
+var alertProviders = map[string]bool{"owm": true}
+
+// Services that accept a personal API key
+var keyedProviders = map[string]bool{"owm": true, "what3words": true}
+
+// Provider names in the order they are listed to users
+var providerNames = []string{"owm", "open-meteo", "met.no"}
+
+// Fallback chain used when neither -providers nor the config name one
+const DEFAULT_PROVIDERS = "owm,open-meteo,met.no"
+
+// Providers queried for weather in order of preference, set with -providers
+var activeProviders = []string{"owm"}
+
+// Parses a comma separated provider list such as "owm,open-meteo"
+func parseProviders(list string) ([]string, error) {
+	var names []string
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if _, known := weatherProviders[name]; !known {
+			return nil, fmt.Errorf("unknown provider %q, expected one of %s", name, strings.Join(providerNames, ", "))
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no providers given, expected one of %s", strings.Join(providerNames, ", "))
+	}
+
+	return names, nil
+}
+
+// Asks each active provider in turn until one answers, returning the data,
+// the provider that answered and the ones that failed before it
+func fetchWithFallback(ctx context.Context, at coordinate, units unitSystem) (weatherData, string, []string, error) {
+	var failed []string
+	var errs []error
+
+	for _, name := range activeProviders {
+		weather, err := weatherProviders[name](ctx, at, units)
+		if err == nil {
+			return weather, name, failed, nil
+		}
+
+		// Don't move on to the next provider after Ctrl+C
+		if ctx.Err() != nil {
+			return weatherData{}, "", failed, ctx.Err()
+		}
+
+		failed = append(failed, name)
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		logger.Debug("provider failed", "provider", name, "error", err)
+
+		if len(failed) < len(activeProviders) {
+			status("[!] " + name + " failed, falling back to the next provider")
+		}
+	}
+
+	return weatherData{}, "", failed, fmt.Errorf("every provider failed: %w", errors.Join(errs...))
+}
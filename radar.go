@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"time"
+)
+
+// Recent radar frames from RainViewer, which serves them without a key
+const RAINVIEWER_MAPS_URL = "https://api.rainviewer.com/public/weather-maps.json"
+
+// Street map tiles shown under the radar
+const OSM_TILE_URL = "https://tile.openstreetmap.org/%d/%d/%d.png"
+
+// Side of a map tile in pixels
+const TILE_SIZE = 256
+
+// Size of the radar picture in pixels, centered on the place
+const (
+	RADAR_WIDTH  = 640
+	RADAR_HEIGHT = 480
+)
+
+// Default and largest zoom RainViewer serves radar at
+const (
+	DEFAULT_RADAR_ZOOM = 6
+	MAX_RADAR_ZOOM     = 7
+)
+
+// Radar frames of the past hour shown by -animate, ten minutes apart
+const RADAR_ANIMATION_FRAMES = 6
+
+// Pause between two frames of the animation, and after the last one
+const (
+	RADAR_FRAME_DELAY = 500 * time.Millisecond
+	RADAR_LOOP_DELAY  = 1500 * time.Millisecond
+)
+
+// One radar picture on RainViewer's tile server
+type rainViewerFrame struct {
+	Time int64  `json:"time"`
+	Path string `json:"path"`
+}
+
+type rainViewerMaps struct {
+	Host  string `json:"host"`
+	Radar struct {
+		Past []rainViewerFrame `json:"past"`
+	} `json:"radar"`
+}
+
+// Fetches the list of radar frames, oldest first
+func fetchRadarFrames(ctx context.Context) (rainViewerMaps, error) {
+	status("[@] Fetching radar frames from RainViewer")
+
+	body, err := fetch(ctx, RAINVIEWER_MAPS_URL)
+	if err != nil {
+		return rainViewerMaps{}, fmt.Errorf("fetching radar frames from RainViewer: %w", err)
+	}
+
+	var maps rainViewerMaps
+	if err := json.Unmarshal(body, &maps); err != nil {
+		return rainViewerMaps{}, fmt.Errorf("parsing RainViewer radar frames: %w", err)
+	}
+	if len(maps.Radar.Past) == 0 {
+		return rainViewerMaps{}, errors.New("RainViewer has no radar frames right now")
+	}
+
+	return maps, nil
+}
+
+// Where a coordinate falls on the map at the zoom, in pixels from the
+// north-west corner of the world, as in the Web Mercator tiles
+func tilePixel(at coordinate, zoom int) (float64, float64) {
+	scale := float64(TILE_SIZE) * math.Exp2(float64(zoom))
+	latitude := at.Lat * math.Pi / 180
+
+	x := (at.Lon + 180) / 360 * scale
+	y := (1 - math.Log(math.Tan(latitude)+1/math.Cos(latitude))/math.Pi) / 2 * scale
+
+	return x, y
+}
+
+// Draws the tiles a URL pattern gives around the place onto the picture,
+// which has the place in its center. Tiles that fail are left blank.
+func drawTiles(ctx context.Context, picture *image.RGBA, at coordinate, zoom int, tileURL func(x, y int) string) {
+	centerX, centerY := tilePixel(at, zoom)
+	left, top := int(centerX)-RADAR_WIDTH/2, int(centerY)-RADAR_HEIGHT/2
+	tiles := 1 << zoom
+
+	type tile struct{ X, Y int }
+	var needed []tile
+	for y := floorDiv(top, TILE_SIZE); y <= floorDiv(top+RADAR_HEIGHT, TILE_SIZE); y++ {
+		for x := floorDiv(left, TILE_SIZE); x <= floorDiv(left+RADAR_WIDTH, TILE_SIZE); x++ {
+			if y >= 0 && y < tiles {
+				needed = append(needed, tile{x, y})
+			}
+		}
+	}
+
+	results := runPool("Fetching map tiles", needed, func(t tile) string { return fmt.Sprintf("%d/%d", t.X, t.Y) }, func(t tile) (image.Image, error) {
+		// Tiles wrap around the antimeridian
+		body, err := fetch(ctx, tileURL((t.X%tiles+tiles)%tiles, t.Y))
+		if err != nil {
+			return nil, err
+		}
+		picture, _, err := image.Decode(bytes.NewReader(body))
+		return picture, err
+	})
+
+	for index, result := range results {
+		if result.Err != nil {
+			logger.Debug("map tile failed", "error", result.Err)
+			continue
+		}
+
+		t := needed[index]
+		origin := image.Pt(t.X*TILE_SIZE-left, t.Y*TILE_SIZE-top)
+		draw.Draw(picture, image.Rectangle{origin, origin.Add(image.Pt(TILE_SIZE, TILE_SIZE))}, result.Value, image.Point{}, draw.Over)
+	}
+}
+
+// Rounds a division towards negative infinity, for tiles west of the picture
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// Marks the place in the center of the picture with a cross
+func drawMarker(picture *image.RGBA) {
+	marker := color.RGBA{R: 220, A: 255}
+	for offset := -8; offset <= 8; offset++ {
+		for width := -1; width <= 1; width++ {
+			picture.Set(RADAR_WIDTH/2+offset, RADAR_HEIGHT/2+width, marker)
+			picture.Set(RADAR_WIDTH/2+width, RADAR_HEIGHT/2+offset, marker)
+		}
+	}
+}
+
+// Implements `weather radar`, the latest precipitation radar around the
+// place drawn in the terminal, or the past hour of it animated. The picture
+// can be saved as a PNG where the terminal shows no images.
+func runRadar(ctx context.Context, target location, zoom int, protocol string, animate bool, save string) error {
+	protocol, err := chooseGraphics(protocol, save)
+	if err != nil {
+		return err
+	}
+
+	maps, err := fetchRadarFrames(ctx)
+	if err != nil {
+		return err
+	}
+
+	frames := maps.Radar.Past[len(maps.Radar.Past)-1:]
+	if animate && save == "" {
+		frames = maps.Radar.Past[max(0, len(maps.Radar.Past)-RADAR_ANIMATION_FRAMES):]
+	}
+
+	// The street map is the same under every frame
+	base := image.NewRGBA(image.Rect(0, 0, RADAR_WIDTH, RADAR_HEIGHT))
+	draw.Draw(base, base.Bounds(), image.NewUniform(color.RGBA{R: 235, G: 235, B: 235, A: 255}), image.Point{}, draw.Src)
+	drawTiles(ctx, base, target.Coord, zoom, func(x, y int) string { return fmt.Sprintf(OSM_TILE_URL, zoom, x, y) })
+
+	options, err := resolveDisplay("", target.Country)
+	if err != nil {
+		return err
+	}
+
+	pictures := make([]*image.RGBA, len(frames))
+	for index, frame := range frames {
+		picture := image.NewRGBA(base.Bounds())
+		draw.Draw(picture, picture.Bounds(), base, image.Point{}, draw.Src)
+		drawTiles(ctx, picture, target.Coord, zoom, func(x, y int) string {
+			// Color scheme 2, smoothed, with snow in its own colors
+			return fmt.Sprintf("%s%s/%d/%d/%d/%d/2/1_1.png", maps.Host, frame.Path, TILE_SIZE, zoom, x, y)
+		})
+		drawMarker(picture)
+		pictures[index] = picture
+	}
+
+	caption := func(frame rainViewerFrame) string {
+		moment := options.in(time.Unix(frame.Time, 0))
+		return fmt.Sprintf(tr("Radar at %s, map © OpenStreetMap contributors, radar RainViewer"), moment.Format(options.clockFormat()))
+	}
+
+	if save != "" || !animate {
+		return showImage(pictures[0], protocol, save, caption(frames[0]))
+	}
+
+	// Frames are redrawn from the top of a cleared screen until interrupted
+	fmt.Print("\x1b[2J")
+	for ctx.Err() == nil {
+		for index, picture := range pictures {
+			fmt.Print("\x1b[H")
+			if err := writeImage(os.Stdout, picture, protocol); err != nil {
+				return err
+			}
+			fmt.Printf("%s\x1b[K\n", caption(frames[index]))
+
+			delay := RADAR_FRAME_DELAY
+			if index == len(pictures)-1 {
+				delay = RADAR_LOOP_DELAY
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+		}
+	}
+
+	return nil
+}
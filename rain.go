@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Chance of precipitation from which `weather rain` says it will rain
+const RAIN_LIKELY = 0.5
+
+// Hours count toward the rain window when their chance reaches this share of
+// the day's highest hourly chance, and at least RAIN_WINDOW_MIN_POP
+const (
+	RAIN_WINDOW_SHARE   = 0.6
+	RAIN_WINDOW_MIN_POP = 0.2
+)
+
+// Intensity in mm/h from which a minute of the nowcast counts as wet
+const WET_MM_PER_HOUR = 0.1
+
+// Exit status of `weather rain` when it should stay dry, kept apart from the
+// 1 of errors
+const DRY_EXIT_STATUS = 2
+
+// The rest of today's hours in the hourly forecast
+func hoursLeftToday(weather weatherData) []conditions {
+	now := weather.Current.Time
+
+	var today []conditions
+	for _, hour := range weather.Hourly {
+		if sameDate(hour.Time, now) && !hour.Time.Before(now.Truncate(time.Hour)) {
+			today = append(today, hour)
+		}
+	}
+
+	return today
+}
+
+// The span of hours around the wettest one where rain is most likely, false
+// when no hour is wet enough
+func rainWindow(hours []conditions) (time.Time, time.Time, bool) {
+	peak := -1
+	for index, hour := range hours {
+		if peak < 0 || hour.Pop > hours[peak].Pop {
+			peak = index
+		}
+	}
+
+	if peak < 0 || hours[peak].Pop < RAIN_WINDOW_MIN_POP {
+		return time.Time{}, time.Time{}, false
+	}
+
+	threshold := max(hours[peak].Pop*RAIN_WINDOW_SHARE, RAIN_WINDOW_MIN_POP)
+
+	first, last := peak, peak
+	for first > 0 && hours[first-1].Pop >= threshold {
+		first--
+	}
+	for last < len(hours)-1 && hours[last+1].Pop >= threshold {
+		last++
+	}
+
+	return hours[first].Time, hours[last].Time.Add(time.Hour), true
+}
+
+// Says when rain starts or stops within the minutely forecast, such as
+// "Rain starting in 23 minutes, stopping around 14:40". Empty when the hour
+// stays dry or there is no minutely forecast.
+func (w weatherData) nowcast(options displayOptions) string {
+	steps := w.Minutely
+	if len(steps) == 0 {
+		return ""
+	}
+
+	threshold := options.Units.fromMillimeters(WET_MM_PER_HOUR)
+	wet := func(step precipitationStep) bool { return step.Precipitation >= threshold }
+
+	// Index of the first step from start on whose wetness is the opposite
+	// of the given one, -1 when there is none
+	change := func(start int, raining bool) int {
+		for index := start; index < len(steps); index++ {
+			if wet(steps[index]) != raining {
+				return index
+			}
+		}
+		return -1
+	}
+
+	minutesUntil := func(index int) int {
+		return max(1, int(steps[index].Time.Sub(w.Current.Time).Round(time.Minute).Minutes()))
+	}
+	clock := func(index int) string { return steps[index].Time.Format(options.clockFormat()) }
+	until := steps[len(steps)-1].Time.Format(options.clockFormat())
+
+	if wet(steps[0]) {
+		stop := change(0, true)
+		if stop < 0 {
+			return fmt.Sprintf(tr("Rain continuing past %s"), until)
+		}
+
+		return fmt.Sprintf(tr("Rain stopping in %d minutes, around %s"), minutesUntil(stop), clock(stop))
+	}
+
+	start := change(0, false)
+	if start < 0 {
+		return ""
+	}
+
+	sentence := fmt.Sprintf(tr("Rain starting in %d minutes"), minutesUntil(start))
+	if stop := change(start, true); stop >= 0 {
+		return sentence + ", " + fmt.Sprintf(tr("stopping around %s"), clock(stop))
+	}
+
+	return sentence + ", " + fmt.Sprintf(tr("lasting past %s"), until)
+}
+
+// Implements `weather rain`, answering in one sentence whether it will rain
+// today. Exits with DRY_EXIT_STATUS when it probably won't, so scripts can
+// ask with `weather rain && ...`.
+func runRain(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+
+	if len(weather.Daily) == 0 {
+		return fmt.Errorf("%s returned no daily forecast", result.Provider)
+	}
+
+	today := weather.Daily[0]
+	chance := today.Pop
+
+	// Only the hours left count, so rain this morning doesn't keep the
+	// answer wet all evening
+	hours := hoursLeftToday(weather)
+	if len(hours) > 0 {
+		chance = 0
+		for _, hour := range hours {
+			chance = max(chance, hour.Pop)
+		}
+	}
+
+	// Rain that shows up in the next hour answers the question whatever the
+	// forecast chance says
+	nowcast := weather.nowcast(options)
+
+	if chance < RAIN_LIKELY && nowcast == "" {
+		fmt.Printf(tr("No rain expected today (%.0f%% chance).")+"\n", chance*100)
+		return exitStatus(DRY_EXIT_STATUS)
+	}
+
+	sentence := fmt.Sprintf(tr("%.0f%% chance of rain today"), chance*100)
+	if today.Precipitation > 0 {
+		sentence += fmt.Sprintf(", "+tr("about %s"), options.Units.formatPrecipitation(today.Precipitation))
+	}
+	if start, end, ok := rainWindow(hours); ok {
+		sentence += fmt.Sprintf(", "+tr("mainly %s–%s"), start.Format(options.clockFormat()), end.Format(options.clockFormat()))
+	}
+
+	fmt.Println(sentence + ".")
+	if nowcast != "" {
+		fmt.Println(nowcast + ".")
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Requests per second allowed for a provider without a configured limit
+const DEFAULT_RATE_LIMIT = 5.0
+
+// Hosts belonging to each provider, so limits can be configured by name
+var providerHosts = map[string]string{
+	"app.owm.io":                     "owm",
+	"api.openweathermap.org":         "owm",
+	"api.open-meteo.com":             "open-meteo",
+	"archive-api.open-meteo.com":     "open-meteo",
+	"marine-api.open-meteo.com":      "open-meteo",
+	"air-quality-api.open-meteo.com": "open-meteo",
+	"api.met.no":                     "met.no",
+	"web-api.nordvpn.com":            "nordvpn",
+	"nominatim.openstreetmap.org":    "nominatim",
+	"api.what3words.com":             "what3words",
+	"ip-api.com":                     "ip-api",
+	"ipinfo.io":                      "ipinfo",
+	"ifconfig.co":                    "ifconfig.co",
+}
+
+// Limits services ask for in their usage policies, used unless configured
+var defaultRateLimits = map[string]float64{
+	"nominatim": 1,
+	"ip-api":    0.75, // 45 a minute
+}
+
+// Token bucket allowing `rate` requests per second with bursts of `burst`
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*tokenBucket{}
+
+	// Configured requests per second per provider name, set from the config
+	rateLimits = map[string]float64{}
+)
+
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := math.Max(1, math.Ceil(rate))
+
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Returns the limiter shared by every request to the host's provider
+func limiterFor(host string) *tokenBucket {
+	provider, known := providerHosts[host]
+	if !known {
+		provider = host
+	}
+
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	if bucket, found := limiters[provider]; found {
+		return bucket
+	}
+
+	rate, configured := rateLimits[provider]
+	if !configured {
+		rate, configured = defaultRateLimits[provider]
+	}
+	if !configured {
+		rate, configured = rateLimits["default"]
+	}
+	if !configured {
+		rate = DEFAULT_RATE_LIMIT
+	}
+
+	bucket := newTokenBucket(rate)
+	limiters[provider] = bucket
+
+	return bucket
+}
+
+// Blocks until a request may be sent or the context is done. A rate of zero
+// disables limiting.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b.rate <= 0 {
+		return nil
+	}
+
+	for {
+		b.mu.Lock()
+
+		// Refill for the time passed since the last check
+		now := time.Now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// OpenStreetMap's Nominatim, which turns coordinates into place names
+const REVERSE_GEOCODE_URL = "https://nominatim.openstreetmap.org/reverse"
+
+// Distance within which a point counts as at the nearest city rather than
+// some way from it
+const NEAR_CITY_KM = 10.0
+
+// Distance within which a point is taken to be in the nearest city's
+// country when reverse geocoding doesn't know better
+const SAME_COUNTRY_KM = 50.0
+
+// Nominatim's answer for a point
+type reverseGeocodeResponse struct {
+	Error   string `json:"error"`
+	Address struct {
+		City         string `json:"city"`
+		Town         string `json:"town"`
+		Village      string `json:"village"`
+		Hamlet       string `json:"hamlet"`
+		Municipality string `json:"municipality"`
+		County       string `json:"county"`
+		State        string `json:"state"`
+		CountryCode  string `json:"country_code"`
+	} `json:"address"`
+}
+
+// Names the settlement at a coordinate, e.g. "Uppsala" in "SE", along with
+// its region. Out in the country only the region and country are known.
+func reverseGeocode(ctx context.Context, coord coordinate) (string, string, string, error) {
+	TARGET_URL := fmt.Sprintf("%s?lat=%f&lon=%f&format=jsonv2&zoom=10&accept-language=en", REVERSE_GEOCODE_URL, coord.Lat, coord.Lon)
+
+	body, err := fetch(ctx, TARGET_URL)
+	if err != nil {
+		return "", "", "", fmt.Errorf("reverse geocoding: %w", err)
+	}
+
+	var parsed reverseGeocodeResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", "", "", fmt.Errorf("parsing reverse geocoding: %w", err)
+	}
+
+	// Points far out at sea have no address
+	if parsed.Error != "" {
+		return "", "", "", errors.New(parsed.Error)
+	}
+
+	address := parsed.Address
+	place := firstNonEmpty(address.City, address.Town, address.Village, address.Hamlet, address.Municipality)
+
+	region := firstNonEmpty(address.State, address.County)
+	if region == place {
+		region = ""
+	}
+
+	return place, region, strings.ToUpper(address.CountryCode), nil
+}
+
+// First of the values that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Gives a place picked by bare coordinates or a Plus Code a name and
+// country, which also lets -units auto pick the right system. Where nobody
+// lives, or offline, it is described by the nearest city of the offline
+// list, as in "85 km NW of Uppsala, SE".
+func nameCoordinates(ctx context.Context, place location) location {
+	_, isCoordinate := parseCoordinate(place.Name)
+	_, _, isPlusCode := parsePlusCode(place.Name)
+	if !isCoordinate && !isPlusCode || place.Country != "" {
+		return place
+	}
+
+	// Privacy mode keeps precise positions to this machine, so only the
+	// offline list names them
+	var name, region, country string
+	if !privacyMode {
+		var err error
+		name, region, country, err = reverseGeocode(ctx, place.Coord)
+		if err != nil {
+			logger.Debug("could not name coordinates", "coordinate", place.Name, "error", err)
+		}
+	}
+
+	var description, compactName string
+	if name != "" {
+		parts := []string{name}
+		if region != "" {
+			parts = append(parts, region)
+		}
+		if country != "" {
+			parts = append(parts, country)
+		}
+
+		description = "near " + strings.Join(parts, ", ")
+		compactName = name
+		if country != "" {
+			compactName += ", " + country
+		}
+	} else if nearest, km, found := nearestCity(place.Coord); found {
+		cityName := nearest.Name + ", " + nearest.Country
+
+		if km < NEAR_CITY_KM {
+			description = "near " + cityName
+			compactName = cityName
+		} else {
+			// In the units customary where the city is
+			units := METRIC
+			if imperialCountries[nearest.Country] {
+				units = IMPERIAL
+			}
+
+			direction := compassDirection(int64(math.Round(bearingDegrees(nearest.Coord, place.Coord))))
+			description = fmt.Sprintf("%s %s of %s", formatDistance(km, units), direction, cityName)
+			compactName = description
+		}
+
+		// Reverse geocoding may still know the region, across a border too
+		if region != "" && country != "" {
+			description += ", in " + region + ", " + country
+		}
+
+		// Far from the city the point may be at sea or across a border
+		if country == "" && km <= SAME_COUNTRY_KM {
+			country = nearest.Country
+		}
+	} else {
+		return place
+	}
+
+	place.Country = country
+
+	place.FullName = description
+	if isPlusCode {
+		place.FullName = place.Name + " " + description
+	}
+
+	// Aliases keep their nickname
+	if place.CompactName == "" {
+		place.CompactName = compactName
+	}
+
+	return place
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// Thresholds of the road warnings in metric units
+const (
+	ROAD_WINTER_C      = 3.0 // At or below this the report and commute warn of the roads
+	BLACK_ICE_C        = 1.0 // Surface temperature that lets water freeze on the road
+	BLACK_ICE_SPREAD_C = 1.0 // Dew point spread at which the road frosts over
+	ROAD_WET_MM        = 0.1
+	CROSSWIND_KMH      = 45.0
+	CROSSWIND_GUST_KMH = 65.0
+	ROAD_VISIBILITY_M  = 500
+)
+
+// Hours of rain or drizzle before that leave the road wet
+const ROAD_WET_HOURS = 3
+
+// Hours `weather road` looks ahead
+const ROAD_FORECAST_HOURS = 24
+
+// Road hazards, worst first
+const (
+	FREEZING_RAIN_HAZARD = "freezing rain"
+	BLACK_ICE_HAZARD     = "black ice"
+	SNOW_HAZARD          = "snow on the road"
+	CROSSWIND_HAZARD     = "strong crosswinds"
+	VISIBILITY_HAZARD    = "poor visibility"
+)
+
+// Hourly temperature of the ground's surface from Open-Meteo, in °C
+type openMeteoSurface struct {
+	Hourly struct {
+		Time        []int64   `json:"time"`
+		Temperature []float64 `json:"soil_temperature_0cm"`
+	} `json:"hourly"`
+}
+
+// Fetches the surface temperature of today and tomorrow by Unix hour, which
+// freezes before the air does on clear nights
+func fetchSurfaceTemperatures(ctx context.Context, at coordinate) (map[int64]float64, error) {
+	status("[@] Fetching the surface temperature from Open-Meteo")
+
+	query := openMeteoQuery(at, METRIC)
+	query.Set("hourly", "soil_temperature_0cm")
+	query.Set("past_days", "1")
+	query.Set("forecast_days", "2")
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("fetching the surface temperature from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoSurface
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing the Open-Meteo surface temperature: %w", err)
+	}
+
+	surfaces := map[int64]float64{}
+	for index, unix := range parsed.Hourly.Time {
+		if index < len(parsed.Hourly.Temperature) {
+			surfaces[unix] = parsed.Hourly.Temperature[index]
+		}
+	}
+
+	return surfaces, nil
+}
+
+// One moment of the road forecast
+type roadMoment struct {
+	Conditions conditions
+	Surface    float64 // °C, NaN when only the air temperature is known
+	Hazards    []string
+}
+
+// Hazards on the road in the conditions, worst first. The surface is in °C,
+// NaN to judge by the air; wet tells of rain in the hours before.
+func (c conditions) roadHazards(surface float64, wet bool, units unitSystem) []string {
+	air := units.toCelsius(c.Temp)
+	if math.IsNaN(surface) {
+		surface = air
+	}
+
+	var hazards []string
+	kind := c.Condition.Kind
+	liquid := kind == DRIZZLE || kind == RAIN || kind == SHOWERS || kind == SLEET || (c.Precipitation > 0 && kind != SNOW && air > 0)
+	if liquid && (surface <= 0 || (kind == SLEET && air <= 1)) {
+		hazards = append(hazards, FREEZING_RAIN_HAZARD)
+	} else if surface <= BLACK_ICE_C && (wet || air-units.toCelsius(c.DewPoint) <= BLACK_ICE_SPREAD_C) {
+		hazards = append(hazards, BLACK_ICE_HAZARD)
+	}
+
+	if kind == SNOW {
+		hazards = append(hazards, SNOW_HAZARD)
+	}
+
+	wind := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(c.WindSpeed))
+	gust := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(c.WindGust))
+	if wind >= CROSSWIND_KMH || gust >= CROSSWIND_GUST_KMH {
+		hazards = append(hazards, CROSSWIND_HAZARD)
+	}
+
+	if kind == FOG || (c.Visibility > 0 && c.Visibility < ROAD_VISIBILITY_M) {
+		hazards = append(hazards, VISIBILITY_HAZARD)
+	}
+
+	return hazards
+}
+
+// The road forecast of now and the hours ahead, with surface temperatures by
+// Unix hour where known. Rain in the hours before now counts as well.
+func (w weatherData) roadForecast(hours int, surfaces map[int64]float64, units unitSystem) []roadMoment {
+	threshold := units.fromMillimeters(ROAD_WET_MM)
+	raining := func(c conditions) bool {
+		return c.Precipitation >= threshold || (c.Condition.Kind.precipitating() && c.Condition.Kind != SNOW)
+	}
+	wet := func(c conditions) bool {
+		if raining(c) {
+			return true
+		}
+		for _, hour := range w.Hourly {
+			if hour.Time.Before(c.Time) && !hour.Time.Before(c.Time.Add(-ROAD_WET_HOURS*time.Hour)) && raining(hour) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var forecast []roadMoment
+	judge := func(c conditions) {
+		surface, known := surfaces[c.Time.Truncate(time.Hour).Unix()]
+		if !known {
+			surface = math.NaN()
+		}
+		forecast = append(forecast, roadMoment{Conditions: c, Surface: surface, Hazards: c.roadHazards(surface, wet(c), units)})
+	}
+
+	judge(w.Current)
+	until := w.Current.Time.Add(time.Duration(hours) * time.Hour)
+	for _, hour := range w.Hourly {
+		if hour.Time.After(w.Current.Time) && hour.Time.Before(until) {
+			judge(hour)
+		}
+	}
+
+	return forecast
+}
+
+// Whether the air gets cold enough within the hours for the roads to freeze
+func (w weatherData) wintry(hours int, units unitSystem) bool {
+	situation := tipContext{Current: w.Current, Hours: w.Hourly, Units: units}
+
+	return situation.any(hours, func(c conditions) bool { return units.toCelsius(c.Temp) <= ROAD_WINTER_C })
+}
+
+// The hazards of the road forecast with the first hour of each, as in "black
+// ice from 5:00 AM, strong crosswinds from 2:00 PM"
+func roadSummary(forecast []roadMoment, clock string) string {
+	var parts []string
+	seen := map[string]bool{}
+	for _, moment := range forecast {
+		for _, hazard := range moment.Hazards {
+			if !seen[hazard] {
+				seen[hazard] = true
+				parts = append(parts, fmt.Sprintf(tr("%s from %s"), tr(hazard), moment.Conditions.Time.Format(clock)))
+			}
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Implements `weather road`, the hazards for drivers now and hour by hour:
+// freezing rain, black ice from the surface temperature and moisture, snow,
+// crosswinds and poor visibility
+func runRoad(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	clock := options.clockFormat()
+
+	// The surface temperature is a bonus, the air stands in for it
+	surfaces, err := fetchSurfaceTemperatures(ctx, weather.Coord)
+	if err != nil {
+		logger.Debug("surface temperature lookup failed", "error", err)
+	}
+
+	forecast := weather.roadForecast(ROAD_FORECAST_HOURS, surfaces, options.Units)
+	temperature := func(celsius float64) string {
+		if math.IsNaN(celsius) {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+	}
+
+	if summary := roadSummary(forecast, clock); summary != "" {
+		fmt.Printf("⚠️  %s: %s\n", tr("Road Warnings"), summary)
+	} else {
+		fmt.Println(tr("No road hazards expected"))
+	}
+
+	rows := [][]string{translated("Time", "Air", "Surface", "Wind", "Gusts", "Visibility", "Hazards")}
+	for _, moment := range forecast {
+		hour := moment.Conditions
+
+		visibility := "-"
+		if hour.Visibility > 0 {
+			visibility = formatVisibility(float64(hour.Visibility), options.Units)
+		}
+
+		hazards := make([]string, len(moment.Hazards))
+		for index, hazard := range moment.Hazards {
+			hazards[index] = tr(hazard)
+		}
+
+		rows = append(rows, []string{
+			hour.Time.Format(clock),
+			temperature(options.Units.toCelsius(hour.Temp)),
+			temperature(moment.Surface),
+			options.wind(hour.WindSpeed, 0),
+			options.wind(hour.WindGust, 0),
+			visibility,
+			strings.Join(hazards, ", "),
+		})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default hours `weather run` looks for training windows in
+const DEFAULT_ACTIVITY_WINDOW = "06:00-21:00"
+
+// Hours scoring at least this make up a training window
+const GOOD_ACTIVITY_SCORE = 70
+
+// At most this many training windows are suggested
+const MAX_ACTIVITY_WINDOWS = 3
+
+// A sport and the weather it suits. Temperatures are felt ones in °C and
+// winds in km/h.
+type activity struct {
+	Name        string
+	IdealMinC   float64
+	IdealMaxC   float64
+	CalmKmh     float64 // Wind that doesn't bother yet
+	WindPenalty float64 // Points lost per km/h beyond CalmKmh
+}
+
+// Sports `weather run` scores for. Cyclists want it warmer than runners, as
+// they make less heat and ride into their own headwind.
+var activities = []activity{
+	{Name: "running", IdealMinC: 8, IdealMaxC: 18, CalmKmh: 20, WindPenalty: 1.2},
+	{Name: "cycling", IdealMinC: 14, IdealMaxC: 24, CalmKmh: 12, WindPenalty: 2},
+}
+
+// Names accepted by -sport
+func activityNames() []string {
+	names := make([]string, len(activities))
+	for index, sport := range activities {
+		names[index] = sport.Name
+	}
+
+	return names
+}
+
+// The sport named by -sport
+func findActivity(name string) (activity, bool) {
+	for _, sport := range activities {
+		if strings.EqualFold(sport.Name, name) {
+			return sport, true
+		}
+	}
+
+	return activity{}, false
+}
+
+// Hours of the day between two clock times, such as 06:00-21:00
+type clockSpan struct {
+	From time.Duration // Since midnight
+	To   time.Duration
+}
+
+// Parses a span like 06:00-21:00 or 7-10
+func parseClockSpan(raw string) (clockSpan, error) {
+	from, to, found := strings.Cut(raw, "-")
+	if !found {
+		return clockSpan{}, fmt.Errorf("invalid time range %q, expected e.g. %s", raw, DEFAULT_ACTIVITY_WINDOW)
+	}
+
+	clock := func(text string) (time.Duration, error) {
+		text = strings.TrimSpace(text)
+		if !strings.Contains(text, ":") {
+			text += ":00"
+		}
+
+		parsed, err := time.Parse("15:04", text)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q in %q, expected HH:MM", text, raw)
+		}
+
+		return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+	}
+
+	var span clockSpan
+	var err error
+	if span.From, err = clock(from); err != nil {
+		return clockSpan{}, err
+	}
+	if span.To, err = clock(to); err != nil {
+		return clockSpan{}, err
+	}
+	if span.To <= span.From {
+		return clockSpan{}, fmt.Errorf("time range %q must end after it starts", raw)
+	}
+
+	return span, nil
+}
+
+// Whether an hour starting at the moment lies entirely within the span
+func (s clockSpan) holds(moment time.Time) bool {
+	since := time.Duration(moment.Hour())*time.Hour + time.Duration(moment.Minute())*time.Minute
+
+	return since >= s.From && since+time.Hour <= s.To
+}
+
+// Forecast hours of a calendar day that lie within the span, leaving out
+// those already over
+func hoursWithin(weather weatherData, day time.Time, span clockSpan) []conditions {
+	now := weather.Current.Time.Truncate(time.Hour)
+
+	var hours []conditions
+	for _, hour := range weather.Hourly {
+		if sameDate(hour.Time, day) && !hour.Time.Before(now) && span.holds(hour.Time) {
+			hours = append(hours, hour)
+		}
+	}
+
+	return hours
+}
+
+// How good an hour is for the sport from 0 to 100, given the US AQI or a
+// negative one when unknown
+func (a activity) score(hour conditions, aqi float64, units unitSystem) float64 {
+	score := 100.0
+
+	// Heat wears you down faster than cold, which more clothes fix
+	switch felt := units.toCelsius(hour.FeelsLike); {
+	case felt < a.IdealMinC:
+		score -= (a.IdealMinC - felt) * 2.5
+	case felt > a.IdealMaxC:
+		score -= (felt - a.IdealMaxC) * 4
+	}
+
+	wind := KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(hour.WindSpeed))
+	score -= max(0, wind-a.CalmKmh) * a.WindPenalty
+
+	score -= hour.Pop * 50
+	if hour.Condition.Kind == THUNDERSTORM {
+		score -= 60
+	}
+
+	// Muggy air keeps sweat from cooling you
+	score -= max(0, float64(hour.Humidity)-70) * 0.6
+
+	// Above 50 the air becomes a concern for sensitive lungs under effort
+	if aqi > 50 {
+		score -= (aqi - 50) * 0.5
+	}
+
+	return math.Max(0, math.Min(100, score))
+}
+
+// A stretch of consecutive good hours
+type activityWindow struct {
+	Start time.Time
+	End   time.Time
+	Score float64 // Average over the hours
+}
+
+// Runs of consecutive hours scoring at least the threshold, best first
+func activityWindows(hours []conditions, scores []float64, threshold float64) []activityWindow {
+	var windows []activityWindow
+
+	for start := 0; start < len(hours); start++ {
+		if scores[start] < threshold {
+			continue
+		}
+
+		end, total := start, 0.0
+		for end < len(hours) && scores[end] >= threshold && (end == start || hours[end].Time.Sub(hours[end-1].Time) == time.Hour) {
+			total += scores[end]
+			end++
+		}
+
+		windows = append(windows, activityWindow{Start: hours[start].Time, End: hours[end-1].Time.Add(time.Hour), Score: total / float64(end-start)})
+		start = end - 1
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool { return windows[i].Score > windows[j].Score })
+
+	return windows[:min(len(windows), MAX_ACTIVITY_WINDOWS)]
+}
+
+// Implements `weather run`, scoring each hour of today's window for the
+// sport and pointing out the best stretches. Once today's window is over it
+// plans tomorrow's.
+func runActivity(ctx context.Context, target location, sport activity, span clockSpan, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	temperature := options.Units.temperature()
+	clock := options.clockFormat()
+
+	day := tr("today")
+	hours := hoursWithin(weather, weather.Current.Time, span)
+	if len(hours) == 0 {
+		day = tr("tomorrow")
+		hours = hoursWithin(weather, weather.Current.Time.AddDate(0, 0, 1), span)
+	}
+	if len(hours) == 0 {
+		return fmt.Errorf("%s has no hourly forecast for that time range", result.Provider)
+	}
+
+	// Air quality only refines the score, so the weather alone will do
+	airQuality, err := fetchAirQuality(ctx, weather.Coord)
+	if err != nil {
+		logger.Debug("air quality lookup failed", "error", err)
+		status("[!] No air quality forecast, scoring on the weather alone")
+	}
+
+	scores := make([]float64, len(hours))
+	rows := [][]string{translated("Time", "Score", "Feels Like", "Wind", "Rain chance", "Humidity", "AQI")}
+	for index, hour := range hours {
+		aqi, known := airQuality[hour.Time.Truncate(time.Hour).Unix()]
+		if !known {
+			aqi = -1
+		}
+
+		scores[index] = sport.score(hour, aqi, options.Units)
+
+		shownAQI := "-"
+		if known {
+			shownAQI = fmt.Sprintf("%.0f", aqi)
+		}
+
+		rows = append(rows, []string{
+			hour.Time.Format(clock),
+			fmt.Sprintf("%.0f", scores[index]),
+			fmt.Sprintf("%.1f%s", hour.FeelsLike, temperature),
+			options.wind(hour.WindSpeed, 1),
+			fmt.Sprintf("%.0f%%", hour.Pop*100),
+			fmt.Sprintf("%d%%", hour.Humidity),
+			shownAQI,
+		})
+	}
+
+	fmt.Printf("\n"+tr("Conditions for %s %s")+"\n\n", tr(sport.Name), day)
+	printTable(os.Stdout, rows)
+	fmt.Println()
+
+	windows := activityWindows(hours, scores, GOOD_ACTIVITY_SCORE)
+	if len(windows) == 0 {
+		best := 0
+		for index := range scores {
+			if scores[index] > scores[best] {
+				best = index
+			}
+		}
+
+		fmt.Printf(tr("No good window, the best hour is %s (score %.0f)")+"\n", hours[best].Time.Format(clock), scores[best])
+		return nil
+	}
+
+	var listed []string
+	for _, window := range windows {
+		listed = append(listed, fmt.Sprintf("%s–%s (%.0f)", window.Start.Format(clock), window.End.Format(clock), window.Score))
+	}
+	fmt.Printf("%s: %s\n", tr("Best windows"), strings.Join(listed, ", "))
+
+	return nil
+}
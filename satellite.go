@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+)
+
+// Satellite imagery tiles from NASA GIBS, which serves them without a key.
+// The tile row comes before the column.
+const GIBS_TILE_URL = "https://gibs.earthdata.nasa.gov/wmts/epsg3857/best/%s/default/%s/GoogleMapsCompatible_Level%d/%d/%d/%d.%s"
+
+// Zoom the satellite picture is drawn at unless told otherwise
+const DEFAULT_SATELLITE_ZOOM = 5
+
+// Bands -band takes; auto is infrared where a geostationary satellite sees
+// the place and visible elsewhere
+const (
+	AUTO_BAND     = "auto"
+	INFRARED_BAND = "infrared"
+	VISIBLE_BAND  = "visible"
+)
+
+var satelliteBands = []string{AUTO_BAND, INFRARED_BAND, VISIBLE_BAND}
+
+// One imagery layer of NASA GIBS and the longitudes it is used for
+type satelliteLayer struct {
+	Satellite string
+	Layer     string
+	Format    string
+	MaxZoom   int
+	West      float64
+	East      float64
+}
+
+// Clean infrared of the geostationary satellites, refreshed every ten
+// minutes, day and night. Europe and Africa have none on GIBS.
+var infraredLayers = []satelliteLayer{
+	{"GOES-West", "GOES-West_ABI_Band13_Clean_Infrared", "png", 6, -180, -105},
+	{"GOES-East", "GOES-East_ABI_Band13_Clean_Infrared", "png", 6, -105, -20},
+	{"Himawari", "Himawari_AHI_Band13_Clean_Infrared", "png", 6, 80, 180},
+}
+
+// True color from the polar orbiting VIIRS, one picture of the whole world
+// a day
+var visibleLayer = satelliteLayer{"VIIRS", "VIIRS_SNPP_CorrectedReflectance_TrueColor", "jpg", 9, -180, 180}
+
+// The layer that shows a band over a place
+func satelliteLayerFor(band string, at coordinate) (satelliteLayer, error) {
+	if band != VISIBLE_BAND {
+		for _, layer := range infraredLayers {
+			if at.Lon >= layer.West && at.Lon < layer.East {
+				return layer, nil
+			}
+		}
+
+		if band == INFRARED_BAND {
+			return satelliteLayer{}, fmt.Errorf("no geostationary infrared covers longitude %.1f, try -band visible", at.Lon)
+		}
+	}
+
+	return visibleLayer, nil
+}
+
+// Implements `weather satellite`, the latest satellite picture around the
+// place drawn in the terminal or saved as a PNG
+func runSatellite(ctx context.Context, target location, band string, zoom int, protocol string, save string) error {
+	layer, err := satelliteLayerFor(band, target.Coord)
+	if err != nil {
+		return err
+	}
+	if zoom > layer.MaxZoom {
+		return fmt.Errorf("%s imagery goes up to -zoom %d", layer.Satellite, layer.MaxZoom)
+	}
+
+	protocol, err = chooseGraphics(protocol, save)
+	if err != nil {
+		return err
+	}
+
+	// The daily picture of today is still being put together from passes,
+	// yesterday's covers the whole world
+	day, caption := "default", fmt.Sprintf(tr("Latest %s infrared"), layer.Satellite)
+	if layer.Layer == visibleLayer.Layer {
+		yesterday := time.Now().UTC().AddDate(0, 0, -1)
+		day, caption = yesterday.Format("2006-01-02"), fmt.Sprintf(tr("%s true color of %s"), layer.Satellite, yesterday.Format("Jan 2"))
+	}
+
+	picture := image.NewRGBA(image.Rect(0, 0, RADAR_WIDTH, RADAR_HEIGHT))
+	draw.Draw(picture, picture.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	drawTiles(ctx, picture, target.Coord, zoom, func(x, y int) string {
+		return fmt.Sprintf(GIBS_TILE_URL, layer.Layer, day, layer.MaxZoom, zoom, y, x, layer.Format)
+	})
+	drawMarker(picture)
+
+	return showImage(picture, protocol, save, caption+", "+tr("imagery NASA GIBS"))
+}
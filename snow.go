@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Hourly values `weather snow` fetches from Open-Meteo, always in metric:
+// snowfall in cm, snow depth and the freezing level in m
+const OPEN_METEO_SNOW_HOURLY = "temperature_2m,snowfall,snow_depth,freezing_level_height,wind_speed_10m,wind_gusts_10m"
+
+// Days of the snow forecast
+const SNOW_FORECAST_DAYS = 7
+
+// Snow usually reaches this far below the freezing level before it melts
+const SNOW_LINE_BELOW_FREEZING_M = 300
+
+// Hourly mountain weather of one elevation from Open-Meteo
+type openMeteoSnow struct {
+	Elevation        float64 `json:"elevation"`
+	Timezone         string  `json:"timezone"`
+	UTCOffsetSeconds int     `json:"utc_offset_seconds"`
+	Hourly           struct {
+		Time          []int64   `json:"time"`
+		Temperature   []float64 `json:"temperature_2m"`
+		Snowfall      []float64 `json:"snowfall"`
+		SnowDepth     []float64 `json:"snow_depth"`
+		FreezingLevel []float64 `json:"freezing_level_height"`
+		WindSpeed     []float64 `json:"wind_speed_10m"`
+		WindGust      []float64 `json:"wind_gusts_10m"`
+	} `json:"hourly"`
+}
+
+// Fetches the snow forecast with the past day at an elevation in meters,
+// or at the elevation of the model's terrain when it is NaN
+func fetchSnow(ctx context.Context, at coordinate, elevation float64) (openMeteoSnow, error) {
+	status("[@] Fetching the snow forecast from Open-Meteo")
+
+	query := openMeteoQuery(at, METRIC)
+	query.Set("hourly", OPEN_METEO_SNOW_HOURLY)
+	query.Set("past_days", "1")
+	query.Set("forecast_days", fmt.Sprint(SNOW_FORECAST_DAYS))
+	if !math.IsNaN(elevation) {
+		query.Set("elevation", fmt.Sprintf("%.0f", elevation))
+	}
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return openMeteoSnow{}, fmt.Errorf("fetching the snow forecast from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoSnow
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return openMeteoSnow{}, fmt.Errorf("parsing the Open-Meteo snow forecast: %w", err)
+	}
+	if len(parsed.Hourly.Time) == 0 {
+		return openMeteoSnow{}, errors.New("Open-Meteo has no snow forecast for this place")
+	}
+
+	return parsed, nil
+}
+
+// Formats an amount of snow given in centimeters, in inches with imperial
+// units
+func formatSnow(cm float64, units unitSystem) string {
+	if units == IMPERIAL {
+		return fmt.Sprintf("%.1f in", cm/2.54)
+	}
+
+	return fmt.Sprintf("%.0f cm", cm)
+}
+
+// Formats a height given in meters, in feet with imperial units
+func formatHeight(meters float64, units unitSystem) string {
+	if units == IMPERIAL {
+		return fmt.Sprintf("%.0f ft", meters*3.28084)
+	}
+
+	return fmt.Sprintf("%.0f m", meters)
+}
+
+// Lowest and highest of the values at the hours, in order
+func valueRange(values []float64, hours []int) (float64, float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, hour := range hours {
+		low, high = min(low, valueAt(values, hour)), max(high, valueAt(values, hour))
+	}
+
+	return low, high
+}
+
+// Implements `weather snow`, the fresh snow, snow depth, freezing level and
+// snow line at the location, and the temperature up the mountain when the
+// summit elevation is given
+func runSnow(ctx context.Context, target location, summit float64, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	// The summit is given in feet with imperial units
+	if options.Units == IMPERIAL {
+		summit /= 3.28084
+	}
+
+	base, err := fetchSnow(ctx, target.Coord, math.NaN())
+	if err != nil {
+		return err
+	}
+
+	var top openMeteoSnow
+	if summit > 0 {
+		if top, err = fetchSnow(ctx, target.Coord, summit); err != nil {
+			return err
+		}
+	}
+
+	zone := time.FixedZone(base.Timezone, base.UTCOffsetSeconds)
+	now := time.Now().In(zone)
+	hourly := base.Hourly
+	temperature := func(celsius float64) string {
+		return fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+	}
+
+	// The hour under way, and the snow that fell in the day before it
+	current, fresh := 0, 0.0
+	for index, unix := range hourly.Time {
+		moment := time.Unix(unix, 0)
+		if moment.After(now) {
+			break
+		}
+		current = index
+		if moment.After(now.Add(-24 * time.Hour)) {
+			fresh += valueAt(hourly.Snowfall, index)
+		}
+	}
+
+	heading := fmt.Sprintf(tr("Base %s"), formatHeight(base.Elevation, options.Units))
+	if summit > 0 {
+		heading += ", " + fmt.Sprintf(tr("summit %s"), formatHeight(top.Elevation, options.Units))
+	}
+	fmt.Println(heading)
+
+	printField("Snow Depth", formatSnow(valueAt(hourly.SnowDepth, current)*100, options.Units))
+	printField("Fresh Snow", fmt.Sprintf(tr("%s in the last 24 hours"), formatSnow(fresh, options.Units)))
+	printField("Freezing Level", formatHeight(valueAt(hourly.FreezingLevel, current), options.Units))
+	printField("Snow Line", formatHeight(max(valueAt(hourly.FreezingLevel, current)-SNOW_LINE_BELOW_FREEZING_M, 0), options.Units))
+	printField("Temperature", temperature(valueAt(hourly.Temperature, current)))
+	if summit > 0 {
+		printField("At the Summit", fmt.Sprintf(tr("%s, wind %s, gusts %s"), temperature(valueAt(top.Hourly.Temperature, current)),
+			options.Wind.format(valueAt(top.Hourly.WindSpeed, current), 0), options.Wind.format(valueAt(top.Hourly.WindGust, current), 0)))
+	}
+
+	// The forecast hours of each local day from today on
+	var days [][]int
+	var dates []time.Time
+	for index, unix := range hourly.Time {
+		moment := unixIn(unix, zone)
+		if moment.Before(now.Truncate(time.Hour)) && !sameDate(moment, now) {
+			continue
+		}
+		if len(dates) == 0 || !sameDate(dates[len(dates)-1], moment) {
+			dates = append(dates, moment)
+			days = append(days, nil)
+		}
+		days[len(days)-1] = append(days[len(days)-1], index)
+	}
+
+	header := translated("Day", "Snowfall", "Depth", "Freezing level", "Snow line", "Temp")
+	if summit > 0 {
+		header = append(header, translated("Summit", "Summit wind")...)
+	}
+	rows := [][]string{header}
+	for position, hours := range days {
+		snowfall := 0.0
+		for _, hour := range hours {
+			snowfall += valueAt(hourly.Snowfall, hour)
+		}
+		lowFreezing, highFreezing := valueRange(hourly.FreezingLevel, hours)
+		low, high := valueRange(hourly.Temperature, hours)
+
+		row := []string{
+			dates[position].Format("Mon Jan 2"),
+			formatSnow(snowfall, options.Units),
+			formatSnow(valueAt(hourly.SnowDepth, hours[len(hours)-1])*100, options.Units),
+			formatHeight(lowFreezing, options.Units) + "–" + formatHeight(highFreezing, options.Units),
+			formatHeight(max(lowFreezing-SNOW_LINE_BELOW_FREEZING_M, 0), options.Units),
+			temperature(low) + "–" + temperature(high),
+		}
+		if summit > 0 {
+			low, high := valueRange(top.Hourly.Temperature, hours)
+			_, gust := valueRange(top.Hourly.WindGust, hours)
+			row = append(row, temperature(low)+"–"+temperature(high), options.Wind.format(gust, 0))
+		}
+		rows = append(rows, row)
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// Depths of the soil Open-Meteo models, each temperature with the moisture
+// of the layer it lies in
+var soilDepths = []struct {
+	Centimeters int
+	Temperature string
+	Moisture    string // Water in m³ per m³ of soil
+}{
+	{0, "soil_temperature_0cm", "soil_moisture_0_to_1cm"},
+	{6, "soil_temperature_6cm", "soil_moisture_3_to_9cm"},
+	{18, "soil_temperature_18cm", "soil_moisture_9_to_27cm"},
+	{54, "soil_temperature_54cm", "soil_moisture_27_to_81cm"},
+}
+
+// Depth seeds are sown at in cm, and its temperature among soilDepths
+const (
+	SEED_DEPTH_CM          = 6
+	SEED_DEPTH_TEMPERATURE = "soil_temperature_6cm"
+)
+
+// Hours ahead the soil's lows and highs and the sowing advice look at
+const SOIL_OUTLOOK_HOURS = 72
+
+// Soil temperatures in °C at seed depth that seeds need to germinate, warmest
+// first
+var sowingTemperatures = []struct {
+	Celsius float64
+	Crops   string
+}{
+	{16, "beans, squash and tomatoes"},
+	{10, "sweet corn, beets and carrots"},
+	{5, "peas, lettuce and spinach"},
+}
+
+// Hourly soil temperatures and moisture from Open-Meteo
+type openMeteoSoil struct {
+	Timezone         string                `json:"timezone"`
+	UTCOffsetSeconds int                   `json:"utc_offset_seconds"`
+	Hourly           map[string][]*float64 `json:"hourly"`
+}
+
+// Fetches the soil temperatures and moisture of the coming days
+func fetchSoil(ctx context.Context, at coordinate) (openMeteoSoil, error) {
+	status("[@] Fetching the soil from Open-Meteo")
+
+	var variables []string
+	for _, depth := range soilDepths {
+		variables = append(variables, depth.Temperature, depth.Moisture)
+	}
+
+	query := openMeteoQuery(at, METRIC)
+	query.Set("hourly", strings.Join(variables, ","))
+	query.Set("forecast_days", fmt.Sprint(SOIL_OUTLOOK_HOURS/24+1))
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return openMeteoSoil{}, fmt.Errorf("fetching the soil from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoSoil
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return openMeteoSoil{}, fmt.Errorf("parsing the Open-Meteo soil: %w", err)
+	}
+	if len(parsed.Hourly["time"]) == 0 {
+		return openMeteoSoil{}, errors.New("Open-Meteo has no soil data for this place")
+	}
+
+	return parsed, nil
+}
+
+// A variable at an hour, NaN when missing
+func (s openMeteoSoil) value(name string, index int) float64 {
+	if value := valueAt(s.Hourly[name], index); value != nil {
+		return *value
+	}
+
+	return math.NaN()
+}
+
+// The hour now falls in and the hours of the outlook after it, by index
+func (s openMeteoSoil) outlook(now time.Time) (int, []int) {
+	current := -1
+	var hours []int
+	until := now.Add(SOIL_OUTLOOK_HOURS * time.Hour)
+	for index, unix := range s.Hourly["time"] {
+		if unix == nil {
+			continue
+		}
+		moment := time.Unix(int64(*unix), 0)
+		if !moment.After(now) {
+			current = index
+		}
+		if !moment.Before(now.Truncate(time.Hour)) && moment.Before(until) {
+			hours = append(hours, index)
+		}
+	}
+
+	return current, hours
+}
+
+// Mean, low and high of a variable over the hours, NaN when it has none
+func (s openMeteoSoil) summarize(name string, hours []int) (float64, float64, float64) {
+	sum, count := 0.0, 0
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, index := range hours {
+		if value := s.value(name, index); !math.IsNaN(value) {
+			sum += value
+			count++
+			low, high = min(low, value), max(high, value)
+		}
+	}
+
+	if count == 0 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+
+	return sum / float64(count), low, high
+}
+
+// What can be sown in soil of a mean temperature at seed depth, in °C
+func sowingAdvice(celsius float64, options displayOptions) string {
+	for _, sowing := range sowingTemperatures {
+		if celsius >= sowing.Celsius {
+			return fmt.Sprintf(tr("warm enough to sow %s"), tr(sowing.Crops))
+		}
+	}
+
+	coolest := sowingTemperatures[len(sowingTemperatures)-1].Celsius
+	return fmt.Sprintf(tr("too cold to sow, most seeds wait for %.0f%s"), options.Units.fromCelsius(coolest), options.Units.temperature())
+}
+
+// Prints the soil temperature and moisture at each depth now and over the
+// coming days, and what the soil at seed depth is warm enough to sow
+func printSoil(soil openMeteoSoil, now time.Time, options displayOptions) {
+	current, hours := soil.outlook(now)
+	if current < 0 || len(hours) == 0 {
+		return
+	}
+
+	degrees := func(celsius float64) string {
+		if math.IsNaN(celsius) {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f%s", options.Units.fromCelsius(celsius), options.Units.temperature())
+	}
+	depth := func(centimeters int) string {
+		if options.Units == IMPERIAL {
+			return fmt.Sprintf("%.0f in", float64(centimeters)/2.54)
+		}
+		return fmt.Sprintf("%d cm", centimeters)
+	}
+
+	rows := [][]string{translated("Soil depth", "Now", "Low", "High", "Moisture")}
+	for _, layer := range soilDepths {
+		_, low, high := soil.summarize(layer.Temperature, hours)
+
+		moisture := "-"
+		if value := soil.value(layer.Moisture, current); !math.IsNaN(value) {
+			moisture = fmt.Sprintf("%.0f%%", value*100)
+		}
+
+		rows = append(rows, []string{depth(layer.Centimeters), degrees(soil.value(layer.Temperature, current)), degrees(low), degrees(high), moisture})
+	}
+
+	fmt.Println()
+	fmt.Println(tr("Soil"))
+	if mean, _, _ := soil.summarize(SEED_DEPTH_TEMPERATURE, hours); !math.IsNaN(mean) {
+		fmt.Printf(tr("At %s it averages %s over the next %d days, %s")+"\n", depth(SEED_DEPTH_CM), degrees(mean), SOIL_OUTLOOK_HOURS/24, sowingAdvice(mean, options))
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+}
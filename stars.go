@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// Hours scoring at least this are worth setting up a telescope for
+const GOOD_STARGAZING_SCORE = 60
+
+// Points a full moon takes off, as it washes out all but the brightest stars
+const FULL_MOON_PENALTY = 35
+
+// The hours of the coming night, leaving out the first and last one, which
+// are still twilight
+func nightHours(weather weatherData) []conditions {
+	until := weather.Current.Time.Add(24 * time.Hour)
+	now := weather.Current.Time.Truncate(time.Hour)
+
+	var night []conditions
+	for _, hour := range weather.Hourly {
+		if hour.Time.Before(now) || !hour.Time.Before(until) {
+			continue
+		}
+
+		if hour.Condition.Night {
+			night = append(night, hour)
+		} else if len(night) > 0 {
+			break
+		}
+	}
+
+	// Once the night has begun there's no dusk left to skip
+	if len(night) > 2 {
+		if !weather.Current.Condition.Night {
+			night = night[1:]
+		}
+		night = night[:len(night)-1]
+	}
+
+	return night
+}
+
+// How good an hour is for stargazing from 0 to 100
+func stargazingScore(hour conditions) float64 {
+	// Clouds decide it, a few percent already hide faint objects
+	score := 100 - float64(hour.Clouds)*1.2
+
+	score -= moonIllumination(hour.Time) * FULL_MOON_PENALTY
+
+	// Damp air blurs the sky and fogs up lenses
+	score -= max(0, float64(hour.Humidity)-70) * 0.8
+
+	if hour.Visibility > 0 && hour.Visibility < 10000 {
+		score -= float64(10000-hour.Visibility) / 400
+	}
+	if hour.Condition.Kind.precipitating() || hour.Condition.Kind == FOG {
+		score = 0
+	}
+
+	return math.Max(0, math.Min(100, score))
+}
+
+// Implements `weather stars`, scoring each hour of the coming night for
+// stargazing and marking the best ones
+func runStars(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	options := result.Options
+	clock := options.clockFormat()
+
+	night := nightHours(result.Weather)
+	if len(night) == 0 {
+		return fmt.Errorf("%s has no hourly forecast for the coming night", result.Provider)
+	}
+
+	scores := make([]float64, len(night))
+	best := 0.0
+	for index, hour := range night {
+		scores[index] = stargazingScore(hour)
+		best = max(best, scores[index])
+	}
+
+	middle := night[len(night)/2].Time
+	fmt.Printf("\n"+tr("Moon: %.0f%% lit (%s)")+"\n\n", moonIllumination(middle)*100, tr(moonPhaseName(middle)))
+
+	rows := [][]string{translated("Time", "Score", "Clouds", "Humidity", "Best")}
+	for index, hour := range night {
+		// Only hours worth going out for are starred
+		mark := ""
+		if scores[index] >= GOOD_STARGAZING_SCORE && scores[index] >= best-5 {
+			mark = "★"
+		}
+
+		rows = append(rows, []string{
+			hour.Time.Format(clock),
+			fmt.Sprintf("%.0f", scores[index]),
+			fmt.Sprintf("%d%%", hour.Clouds),
+			fmt.Sprintf("%d%%", hour.Humidity),
+			mark,
+		})
+	}
+
+	printTable(os.Stdout, rows)
+	fmt.Println()
+
+	windows := activityWindows(night, scores, GOOD_STARGAZING_SCORE)
+	if len(windows) == 0 {
+		fmt.Printf(tr("Poor night for stargazing, the best score is %.0f")+"\n", best)
+		return nil
+	}
+
+	var listed []string
+	for _, window := range windows {
+		listed = append(listed, fmt.Sprintf("%s–%s (%.0f)", window.Start.Format(clock), window.End.Format(clock), window.Score))
+	}
+	fmt.Printf("%s: %s\n", tr("Best hours"), strings.Join(listed, ", "))
+
+	return nil
+}
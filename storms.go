@@ -0,0 +1,270 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Active tropical cyclones of the Atlantic and the eastern and central
+// Pacific from the US National Hurricane Center
+const NHC_CURRENT_STORMS_URL = "https://www.nhc.noaa.gov/CurrentStorms.json"
+
+// Meters per second in a knot, the unit advisories give winds in
+const METERS_PER_SECOND_PER_KNOT = 1852.0 / 3600
+
+// One storm of the NHC feed. Intensity is the maximum sustained wind in
+// knots and the movement speed is in mph.
+type nhcStorm struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Classification string  `json:"classification"`
+	Intensity      string  `json:"intensity"`
+	Pressure       string  `json:"pressure"`
+	Latitude       string  `json:"latitude"`
+	Longitude      string  `json:"longitude"`
+	Lat            float64 `json:"latitudeNumeric"`
+	Lon            float64 `json:"longitudeNumeric"`
+	MovementDir    float64 `json:"movementDir"`
+	MovementSpeed  float64 `json:"movementSpeed"`
+	TrackCone      *struct {
+		KMZFile string `json:"kmzFile"`
+	} `json:"trackCone"`
+}
+
+type nhcStormsResponse struct {
+	ActiveStorms []nhcStorm `json:"activeStorms"`
+}
+
+// Names of the NHC classifications
+var stormClassifications = map[string]string{
+	"TD":  "tropical depression",
+	"STD": "subtropical depression",
+	"TS":  "tropical storm",
+	"STS": "subtropical storm",
+	"HU":  "hurricane",
+	"TY":  "typhoon",
+	"PTC": "post-tropical cyclone",
+	"PC":  "potential tropical cyclone",
+}
+
+// Saffir-Simpson category of a hurricane from its sustained wind in knots,
+// 0 below hurricane strength
+func saffirSimpson(knots float64) int {
+	for category, from := range []float64{137, 113, 96, 83, 64} {
+		if knots >= from {
+			return 5 - category
+		}
+	}
+
+	return 0
+}
+
+// What kind of storm it is, with the category of hurricanes
+func (s nhcStorm) kind() string {
+	name, known := stormClassifications[s.Classification]
+	if !known {
+		name = s.Classification
+	}
+
+	knots, _ := strconv.ParseFloat(s.Intensity, 64)
+	if category := saffirSimpson(knots); category > 0 && (s.Classification == "HU" || s.Classification == "TY") {
+		return fmt.Sprintf(tr("category %d %s"), category, tr(name))
+	}
+
+	return tr(name)
+}
+
+// Fetches the storms the NHC is issuing advisories for
+func fetchStorms(ctx context.Context) ([]nhcStorm, error) {
+	status("[@] Fetching active storms from the National Hurricane Center")
+
+	body, err := fetch(ctx, NHC_CURRENT_STORMS_URL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching active storms from the NHC: %w", err)
+	}
+
+	var parsed nhcStormsResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing NHC active storms: %w", err)
+	}
+
+	return parsed.ActiveStorms, nil
+}
+
+// Polygons of a storm's forecast cone from its KMZ, a zipped KML document
+func fetchCone(ctx context.Context, url string) ([]polygon, error) {
+	body, err := fetch(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching forecast cone: %w", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("opening forecast cone: %w", err)
+	}
+
+	for _, file := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".kml") {
+			continue
+		}
+
+		document, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening forecast cone: %w", err)
+		}
+		defer document.Close()
+
+		return kmlPolygons(document)
+	}
+
+	return nil, errors.New("forecast cone holds no KML document")
+}
+
+// Outer boundaries of every polygon in a KML document, whose coordinates
+// are "lon,lat[,alt]" triples separated by spaces
+func kmlPolygons(document io.Reader) ([]polygon, error) {
+	decoder := xml.NewDecoder(document)
+
+	var polygons []polygon
+	var inPolygon, inCoordinates bool
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return polygons, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing forecast cone: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "Polygon":
+				inPolygon = true
+			case "coordinates":
+				inCoordinates = inPolygon
+				text.Reset()
+			case "innerBoundaryIs":
+				// Holes don't matter at the scale of a cone
+				inPolygon = false
+			}
+		case xml.CharData:
+			if inCoordinates {
+				text.Write(element)
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "Polygon":
+				inPolygon = false
+			case "innerBoundaryIs":
+				inPolygon = true
+			case "coordinates":
+				if inCoordinates {
+					polygons = append(polygons, parseKMLCoordinates(text.String()))
+				}
+				inCoordinates = false
+			}
+		}
+	}
+}
+
+// Coordinates of a KML coordinates element, skipping what doesn't parse
+func parseKMLCoordinates(text string) polygon {
+	var points polygon
+	for _, tuple := range strings.Fields(text) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			continue
+		}
+
+		lon, lonErr := strconv.ParseFloat(parts[0], 64)
+		lat, latErr := strconv.ParseFloat(parts[1], 64)
+		if lonErr == nil && latErr == nil {
+			points = append(points, coordinate{Lat: lat, Lon: lon})
+		}
+	}
+
+	return points
+}
+
+// Implements `weather storms`, the active tropical cyclones by distance
+// from the place, warning when it lies within one's forecast cone
+func runStorms(ctx context.Context, target location, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	storms, err := fetchStorms(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(storms) == 0 {
+		fmt.Println(tr("No active tropical cyclones in the Atlantic or eastern and central Pacific."))
+		return nil
+	}
+
+	sort.Slice(storms, func(i, j int) bool {
+		return distanceKm(target.Coord, coordinate{storms[i].Lat, storms[i].Lon}) < distanceKm(target.Coord, coordinate{storms[j].Lat, storms[j].Lon})
+	})
+
+	var warnings []string
+	rows := [][]string{translated("Name", "Type", "Wind", "Pressure", "Position", "Moving", "Distance")}
+	for _, storm := range storms {
+		at := coordinate{Lat: storm.Lat, Lon: storm.Lon}
+		knots, _ := strconv.ParseFloat(storm.Intensity, 64)
+		pressure, _ := strconv.ParseFloat(storm.Pressure, 64)
+
+		moving := tr("stationary")
+		if storm.MovementSpeed > 0 {
+			moving = compassDirection(int64(storm.MovementDir)) + " " + options.Wind.format(storm.MovementSpeed*0.44704, 0)
+		}
+
+		rows = append(rows, []string{
+			storm.Name,
+			storm.kind(),
+			options.Wind.format(knots*METERS_PER_SECOND_PER_KNOT, 0),
+			options.Pressure.format(pressure),
+			storm.Latitude + " " + storm.Longitude,
+			moving,
+			formatDistance(distanceKm(target.Coord, at), options.Units) + " " + compassDirection(int64(bearingDegrees(target.Coord, at))),
+		})
+
+		// A cone that can't be fetched leaves the storm without a warning
+		if storm.TrackCone == nil || storm.TrackCone.KMZFile == "" {
+			continue
+		}
+		cone, err := fetchCone(ctx, storm.TrackCone.KMZFile)
+		if err != nil {
+			logger.Debug("forecast cone lookup failed", "storm", storm.Name, "error", err)
+			continue
+		}
+		for _, area := range cone {
+			if area.contains(target.Coord) {
+				warnings = append(warnings, fmt.Sprintf(tr("%s (%s): this location lies in the forecast cone"), storm.Name, storm.kind()))
+				break
+			}
+		}
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	for _, warning := range warnings {
+		fmt.Println("\n⚠️  " + warning)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Julian date of the J2000 epoch, 2000-01-01 12:00 UTC
+const J2000 = 2451545.0
+
+// Sun altitude in degrees at sunrise and sunset, the upper limb touching the
+// horizon with average refraction
+const SUNRISE_ALTITUDE = -0.833
+
+// Step used to look for the sun or moon crossing an altitude, refined by
+// bisection
+const SKY_SEARCH_STEP = 10 * time.Minute
+
+// Where the sun or moon stands in the sky, both in degrees
+type skyPosition struct {
+	Altitude float64 // Above the horizon, negative below it
+	Azimuth  float64 // Clockwise from north
+}
+
+// Position of the sun seen from a place, from the low precision formulas of
+// the Astronomical Almanac. Good to about a hundredth of a degree, which is
+// far finer than refraction or the horizon allow anyway.
+func sunAt(moment time.Time, at coordinate) skyPosition {
+	radians := math.Pi / 180
+	days := daysSinceJ2000(moment)
+
+	longitude := sunLongitude(days)
+	obliquity := (23.439 - 0.00000036*days) * radians
+
+	rightAscension := math.Atan2(math.Cos(obliquity)*math.Sin(longitude), math.Cos(longitude))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(longitude))
+
+	return horizontalPosition(days, at, rightAscension, declination)
+}
+
+// Ecliptic longitude of the sun in radians, the given days from J2000
+func sunLongitude(days float64) float64 {
+	radians := math.Pi / 180
+	anomaly := (357.529 + 0.98560028*days) * radians
+	meanLongitude := 280.459 + 0.98564736*days
+
+	return (meanLongitude + 1.915*math.Sin(anomaly) + 0.020*math.Sin(2*anomaly)) * radians
+}
+
+// Days from the J2000 epoch to the moment
+func daysSinceJ2000(moment time.Time) float64 {
+	return float64(moment.UTC().UnixNano())/float64(24*time.Hour) + 2440587.5 - J2000
+}
+
+// Altitude and azimuth seen from a place of a body at the right ascension
+// and declination, both in radians
+func horizontalPosition(days float64, at coordinate, rightAscension float64, declination float64) skyPosition {
+	radians := math.Pi / 180
+
+	siderealDegrees := math.Mod(280.46061837+360.98564736629*days, 360)
+	hourAngle := siderealDegrees*radians + at.Lon*radians - rightAscension
+	latitude := at.Lat * radians
+
+	altitude := math.Asin(math.Sin(latitude)*math.Sin(declination) + math.Cos(latitude)*math.Cos(declination)*math.Cos(hourAngle))
+	azimuth := math.Atan2(-math.Sin(hourAngle), math.Tan(declination)*math.Cos(latitude)-math.Sin(latitude)*math.Cos(hourAngle))
+
+	return skyPosition{
+		Altitude: altitude / radians,
+		Azimuth:  math.Mod(azimuth/radians+360, 360),
+	}
+}
+
+// A moment the sun or moon passes an altitude
+type skyCrossing struct {
+	Time   time.Time
+	Rising bool
+}
+
+// Every time the sun passes the altitude during the local calendar day of
+// the moment, in order. Near the poles there may be none.
+func sunCrossings(day time.Time, at coordinate, altitude float64) []skyCrossing {
+	return skyCrossings(day, func(moment time.Time) bool { return sunAt(moment, at).Altitude >= altitude })
+}
+
+// Every time a body goes above or below during the local calendar day of the
+// moment, in order
+func skyCrossings(day time.Time, above func(time.Time) bool) []skyCrossing {
+	year, month, date := day.Date()
+	start := time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	var crossings []skyCrossing
+	for from := start; from.Before(end); from = from.Add(SKY_SEARCH_STEP) {
+		to := from.Add(SKY_SEARCH_STEP)
+		rising := !above(from)
+		if above(to) != rising {
+			continue
+		}
+
+		// Halving the step down to seconds
+		low, high := from, to
+		for high.Sub(low) > time.Second {
+			middle := low.Add(high.Sub(low) / 2)
+			if above(middle) == rising {
+				high = middle
+			} else {
+				low = middle
+			}
+		}
+
+		crossings = append(crossings, skyCrossing{Time: high.Truncate(time.Second), Rising: rising})
+	}
+
+	return crossings
+}
+
+// When the sun passes the altitude rising or setting on the day, false when
+// it doesn't that day
+func sunPasses(day time.Time, at coordinate, altitude float64, rising bool) (time.Time, bool) {
+	for _, crossing := range sunCrossings(day, at, altitude) {
+		if crossing.Rising == rising {
+			return crossing.Time, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Sun altitudes in degrees where the twilights end, from the lightest
+var twilights = []struct {
+	Name     string
+	Altitude float64
+}{
+	{"civil", -6},
+	{"nautical", -12},
+	{"astronomical", -18},
+}
+
+// When the sun stands highest on the local calendar day of the moment, and
+// where it stands then
+func solarNoon(day time.Time, at coordinate) (time.Time, skyPosition) {
+	year, month, date := day.Date()
+	start := time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	highest := start
+	for moment := start; moment.Before(end); moment = moment.Add(SKY_SEARCH_STEP) {
+		if sunAt(moment, at).Altitude > sunAt(highest, at).Altitude {
+			highest = moment
+		}
+	}
+
+	noon := narrowPeak(highest, func(moment time.Time) float64 { return sunAt(moment, at).Altitude })
+
+	return noon, sunAt(noon, at)
+}
+
+// Narrows down on the peak of a value within a search step either side of
+// a moment, by thirds
+func narrowPeak(near time.Time, value func(time.Time) float64) time.Time {
+	low, high := near.Add(-SKY_SEARCH_STEP), near.Add(SKY_SEARCH_STEP)
+	for high.Sub(low) > time.Second {
+		third := high.Sub(low) / 3
+		if value(low.Add(third)) < value(high.Add(-third)) {
+			low = low.Add(third)
+		} else {
+			high = high.Add(-third)
+		}
+	}
+
+	return low.Truncate(time.Second)
+}
+
+// Every time a value peaks during the local calendar day of the moment, in
+// order, such as the moon crossing the meridian
+func skyPeaks(day time.Time, value func(time.Time) float64) []time.Time {
+	year, month, date := day.Date()
+	start := time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	var peaks []time.Time
+	for moment := start; moment.Before(end); moment = moment.Add(SKY_SEARCH_STEP) {
+		current := value(moment)
+		if current > value(moment.Add(-SKY_SEARCH_STEP)) && current >= value(moment.Add(SKY_SEARCH_STEP)) {
+			if peak := narrowPeak(moment, value); !peak.Before(start) && peak.Before(end) {
+				peaks = append(peaks, peak)
+			}
+		}
+	}
+
+	return peaks
+}
+
+// How long the sun is up on the local calendar day of the moment, from 0 in
+// polar night to 24 hours in polar summer
+func daylight(day time.Time, at coordinate) time.Duration {
+	year, month, date := day.Date()
+	start := time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	var total time.Duration
+	up := sunAt(start, at).Altitude >= SUNRISE_ALTITUDE
+	since := start
+	for _, crossing := range sunCrossings(day, at, SUNRISE_ALTITUDE) {
+		if up && !crossing.Rising {
+			total += crossing.Time.Sub(since)
+		}
+		up, since = crossing.Rising, crossing.Time
+	}
+	if up {
+		total += end.Sub(since)
+	}
+
+	return total
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Number of terminal columns a string occupies, counting emoji and East
+// Asian wide characters as two and combining marks as zero
+func displayWidth(text string) int {
+	width := 0
+
+	for _, char := range text {
+		switch {
+		case char == 0xFE0F || char == 0x200D || unicode.Is(unicode.Mn, char):
+			// Variation selectors, joiners and combining marks take no space
+		case char >= 0x1F000 || (char >= 0x2600 && char <= 0x27BF) || (char >= 0x1100 && char <= 0x115F) || (char >= 0x2E80 && char <= 0xA4CF) || (char >= 0xAC00 && char <= 0xD7A3) || (char >= 0xF900 && char <= 0xFAFF) || (char >= 0xFF00 && char <= 0xFF60):
+			width += 2
+		default:
+			width++
+		}
+	}
+
+	return width
+}
+
+// Pads text with spaces up to the given display width
+func padRight(text string, width int) string {
+	return text + strings.Repeat(" ", max(0, width-displayWidth(text)))
+}
+
+// Prints rows as aligned columns, with a rule under the first (header) row
+func printTable(out io.Writer, rows [][]string) {
+	var widths []int
+
+	for _, row := range rows {
+		for index, cell := range row {
+			if index >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[index] = max(widths[index], displayWidth(cell))
+		}
+	}
+
+	for rowIndex, row := range rows {
+		cells := make([]string, len(row))
+		for index, cell := range row {
+			cells[index] = padRight(cell, widths[index])
+		}
+		fmt.Fprintln(out, strings.TrimRight(strings.Join(cells, "  "), " "))
+
+		if rowIndex == 0 {
+			rules := make([]string, len(widths))
+			for index, width := range widths {
+				rules[index] = strings.Repeat("-", width)
+			}
+			fmt.Fprintln(out, strings.Join(rules, "  "))
+		}
+	}
+}
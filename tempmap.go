@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Default distance from the place to the edge of the map in kilometers, or
+// miles with imperial units. The map is half as high as it is wide.
+const DEFAULT_MAP_RADIUS = 250
+
+// Size of the map in terminal cells, which are about twice as tall as wide,
+// so it covers twice the distance across as down
+const (
+	MAP_COLUMNS = 72
+	MAP_ROWS    = 18
+)
+
+// Most populous cities of the region whose temperatures are fetched, of
+// which those whose labels fit are drawn
+const MAX_MAP_CITIES = 40
+
+// Cities closer to the place than this many kilometers would sit on it
+const MAP_NEAREST_CITY_KM = 10
+
+// Terminal colors of the temperatures, each up to its bound in °C
+var temperatureColors = []struct {
+	Below float64
+	Code  string
+}{
+	{-10, "35"}, // Magenta
+	{0, "34"},   // Blue
+	{10, "36"},  // Cyan
+	{20, "32"},  // Green
+	{28, "33"},  // Yellow
+	{math.Inf(1), "31"},
+}
+
+// Current temperature of one place in Open-Meteo's answer for several
+type openMeteoTemperature struct {
+	Current struct {
+		Temperature float64 `json:"temperature_2m"`
+	} `json:"current"`
+}
+
+// Fetches the current temperatures of several places in one request, in the
+// order given
+func fetchTemperatures(ctx context.Context, places []coordinate, units unitSystem) ([]float64, error) {
+	status("[@] Fetching temperatures from Open-Meteo")
+
+	latitudes := make([]string, len(places))
+	longitudes := make([]string, len(places))
+	for index, place := range places {
+		latitudes[index] = fmt.Sprintf("%.4f", place.Lat)
+		longitudes[index] = fmt.Sprintf("%.4f", place.Lon)
+	}
+
+	query := openMeteoQuery(places[0], units)
+	query.Set("latitude", strings.Join(latitudes, ","))
+	query.Set("longitude", strings.Join(longitudes, ","))
+	query.Set("current", "temperature_2m")
+
+	body, err := fetch(ctx, OPEN_METEO_URL+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("fetching temperatures from Open-Meteo: %w", err)
+	}
+
+	// Several places come back as a list, a single one on its own
+	var parsed []openMeteoTemperature
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] != '[' {
+		body = append(append([]byte("["), trimmed...), ']')
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("parsing Open-Meteo temperatures: %w", err)
+	}
+	if len(parsed) != len(places) {
+		return nil, fmt.Errorf("Open-Meteo returned %d temperatures for %d places", len(parsed), len(places))
+	}
+
+	temperatures := make([]float64, len(parsed))
+	for index, place := range parsed {
+		temperatures[index] = place.Current.Temperature
+	}
+
+	return temperatures, nil
+}
+
+// One character of the map and the color it is drawn in
+type mapCell struct {
+	Char  rune
+	Color string // Terminal color code, empty for the default
+}
+
+// A map of the region around a place in terminal cells, each column covering
+// the same distance and each row twice that
+type textMap struct {
+	Center    coordinate
+	KmPerCell float64
+	Cells     [][]mapCell
+	used      [][]bool
+}
+
+// An empty map reaching the radius east and west of the center
+func newTextMap(center coordinate, radiusKm float64) *textMap {
+	m := &textMap{Center: center, KmPerCell: 2 * radiusKm / MAP_COLUMNS}
+	m.Cells = make([][]mapCell, MAP_ROWS)
+	m.used = make([][]bool, MAP_ROWS)
+	for row := range m.Cells {
+		m.Cells[row] = make([]mapCell, MAP_COLUMNS)
+		m.used[row] = make([]bool, MAP_COLUMNS)
+		for column := range m.Cells[row] {
+			m.Cells[row][column] = mapCell{Char: ' '}
+		}
+	}
+
+	return m
+}
+
+// The cell a coordinate falls in, projecting the small region as flat
+func (m *textMap) cell(at coordinate) (int, int) {
+	east := (at.Lon - m.Center.Lon) * math.Cos(m.Center.Lat*math.Pi/180) * 111.32
+	north := (at.Lat - m.Center.Lat) * 110.57
+
+	column := MAP_COLUMNS/2 + int(math.Round(east/m.KmPerCell))
+	row := MAP_ROWS/2 - int(math.Round(north/(2*m.KmPerCell)))
+
+	return column, row
+}
+
+// Puts a marker at a coordinate with a label after it, keeping a blank cell
+// around labels so they stay apart. Reports false, drawing nothing, when it
+// doesn't fit or would cover another label.
+func (m *textMap) place(at coordinate, marker rune, label []mapCell) bool {
+	column, row := m.cell(at)
+	if row < 0 || row >= MAP_ROWS || column < 0 || column+len(label) >= MAP_COLUMNS {
+		return false
+	}
+
+	for offset := -1; offset <= len(label)+1; offset++ {
+		if spot := column + offset; spot >= 0 && spot < MAP_COLUMNS && m.used[row][spot] {
+			return false
+		}
+	}
+
+	m.Cells[row][column] = mapCell{Char: marker}
+	copy(m.Cells[row][column+1:], label)
+	for offset := 0; offset <= len(label); offset++ {
+		m.used[row][column+offset] = true
+	}
+
+	return true
+}
+
+// Writes the map in a frame, in color when asked
+func (m *textMap) print(colored bool) {
+	fmt.Println("┌" + strings.Repeat("─", MAP_COLUMNS) + "┐")
+	for _, row := range m.Cells {
+		var line strings.Builder
+		line.WriteString("│")
+		for _, cell := range row {
+			if colored && cell.Color != "" {
+				fmt.Fprintf(&line, "\x1b[%sm%c\x1b[0m", cell.Color, cell.Char)
+			} else {
+				line.WriteRune(cell.Char)
+			}
+		}
+		line.WriteString("│")
+		fmt.Println(line.String())
+	}
+	fmt.Println("└" + strings.Repeat("─", MAP_COLUMNS) + "┘")
+}
+
+// Color code of a temperature
+func temperatureColor(celsius float64) string {
+	for _, band := range temperatureColors {
+		if celsius < band.Below {
+			return band.Code
+		}
+	}
+
+	return ""
+}
+
+// A label of a name and a temperature in its color
+func temperatureLabel(name string, temperature float64, units unitSystem) []mapCell {
+	var label []mapCell
+	for _, char := range name + " " {
+		label = append(label, mapCell{Char: char})
+	}
+
+	color := temperatureColor(units.toCelsius(temperature))
+	for _, char := range fmt.Sprintf("%.0f°", temperature) {
+		label = append(label, mapCell{Char: char, Color: color})
+	}
+
+	return label
+}
+
+// Implements `weather map`, the current temperatures of the larger cities
+// around the place drawn where they lie, colored by how warm they are
+func runMap(ctx context.Context, target location, radius float64, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	// The radius is given in miles with imperial units
+	radiusKm := radius
+	if options.Units == IMPERIAL {
+		radiusKm *= 1.609344
+	}
+
+	// Only the cities that can land on the map, the most populous first
+	area := newTextMap(target.Coord, radiusKm)
+	var nearby []city
+	for _, entry := range cities().Cities {
+		column, row := area.cell(entry.Coord)
+		if column >= 0 && column < MAP_COLUMNS && row >= 0 && row < MAP_ROWS && distanceKm(target.Coord, entry.Coord) >= MAP_NEAREST_CITY_KM {
+			nearby = append(nearby, entry)
+		}
+	}
+	if len(nearby) == 0 {
+		return fmt.Errorf("no city of the offline list lies within %s, try a larger -radius or `weather cities update`", formatDistance(radiusKm, options.Units))
+	}
+
+	sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].Population > nearby[j].Population })
+	nearby = nearby[:min(len(nearby), MAX_MAP_CITIES)]
+
+	places := []coordinate{target.Coord}
+	for _, entry := range nearby {
+		places = append(places, entry.Coord)
+	}
+
+	temperatures, err := fetchTemperatures(ctx, places, options.Units)
+	if err != nil {
+		return err
+	}
+
+	// The place itself goes first so no city covers it
+	name := firstNonEmpty(target.Name, fmt.Sprintf("%.2f, %.2f", target.Coord.Lat, target.Coord.Lon))
+	if !area.place(target.Coord, '◉', temperatureLabel(name, temperatures[0], options.Units)) {
+		return errors.New("the place doesn't fit on its own map")
+	}
+
+	coldest, warmest := 0, 0
+	for index, entry := range nearby {
+		temperature := temperatures[index+1]
+		if !area.place(entry.Coord, '•', temperatureLabel(entry.Name, temperature, options.Units)) {
+			continue
+		}
+
+		if temperature < temperatures[coldest] {
+			coldest = index + 1
+		}
+		if temperature > temperatures[warmest] {
+			warmest = index + 1
+		}
+	}
+
+	colored := isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	area.print(colored)
+
+	nameOf := func(index int) string {
+		if index == 0 {
+			return name
+		}
+		return nearby[index-1].Name
+	}
+	temperature := options.Units.temperature()
+	fmt.Printf(tr("Coldest %s %.0f%s, warmest %s %.0f%s, within %s")+"\n",
+		nameOf(coldest), temperatures[coldest], temperature, nameOf(warmest), temperatures[warmest], temperature,
+		formatDistance(radiusKm, options.Units))
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Satellite tiles come as JPEG
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+// Ways of showing images inline in a terminal
+const (
+	KITTY_GRAPHICS = "kitty"
+	ITERM_GRAPHICS = "iterm"
+	SIXEL_GRAPHICS = "sixel"
+)
+
+// -protocol that picks the protocol from the terminal
+const AUTO_PROTOCOL = "auto"
+
+// Accepted values of -protocol
+var graphicsProtocols = []string{AUTO_PROTOCOL, KITTY_GRAPHICS, ITERM_GRAPHICS, SIXEL_GRAPHICS}
+
+// Largest piece of base64 the kitty protocol takes in one escape sequence
+const KITTY_CHUNK = 4096
+
+// The image protocol the terminal speaks, judged from the variables
+// terminals set. Sixel support can't be told from the environment except
+// for a few terminals, so it is only guessed for those.
+func detectGraphics() (string, bool) {
+	program := os.Getenv("TERM_PROGRAM")
+	term := os.Getenv("TERM")
+
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") || program == "ghostty":
+		return KITTY_GRAPHICS, true
+	case program == "iTerm.app" || program == "WezTerm" || os.Getenv("LC_TERMINAL") == "iTerm2":
+		return ITERM_GRAPHICS, true
+	case strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm") || strings.Contains(term, "sixel"):
+		return SIXEL_GRAPHICS, true
+	}
+
+	return "", false
+}
+
+// The protocol to draw images with, detected when left to auto. An image
+// that is saved needs none.
+func chooseGraphics(protocol string, save string) (string, error) {
+	if protocol != AUTO_PROTOCOL || save != "" {
+		return protocol, nil
+	}
+
+	detected, ok := detectGraphics()
+	if !ok {
+		return "", errors.New("can't tell whether this terminal shows images, choose -protocol kitty, iterm or sixel, or -save to a PNG file")
+	}
+
+	return detected, nil
+}
+
+// Draws an image in the terminal above its caption, or saves it as a PNG
+// when a file is given
+func showImage(picture image.Image, protocol string, save string, caption string) error {
+	if save == "" {
+		if err := writeImage(os.Stdout, picture, protocol); err != nil {
+			return err
+		}
+		fmt.Println(caption)
+		return nil
+	}
+
+	file, err := os.Create(save)
+	if err != nil {
+		return fmt.Errorf("saving image: %w", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, picture); err != nil {
+		return fmt.Errorf("saving image: %w", err)
+	}
+	status("[+] " + caption + ", saved to " + save)
+
+	return nil
+}
+
+// Writes an image inline in the terminal with the protocol
+func writeImage(out io.Writer, picture image.Image, protocol string) error {
+	if protocol == SIXEL_GRAPHICS {
+		return writeSixel(out, picture)
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, picture); err != nil {
+		return fmt.Errorf("encoding image: %w", err)
+	}
+	data := base64.StdEncoding.EncodeToString(encoded.Bytes())
+
+	if protocol == ITERM_GRAPHICS {
+		_, err := fmt.Fprintf(out, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a\n", encoded.Len(), data)
+		return err
+	}
+
+	// Kitty takes the PNG in chunks, all but the last flagged with m=1
+	for start := 0; start < len(data); start += KITTY_CHUNK {
+		end := min(start+KITTY_CHUNK, len(data))
+		more := 0
+		if end < len(data) {
+			more = 1
+		}
+
+		control := fmt.Sprintf("m=%d", more)
+		if start == 0 {
+			control = "a=T,f=100," + control
+		}
+		if _, err := fmt.Fprintf(out, "\x1b_G%s;%s\x1b\\", control, data[start:end]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(out)
+
+	return err
+}
+
+// Writes an image as sixels, six rows of pixels at a time, in a palette of
+// 216 colors with six levels of red, green and blue. Transparent pixels are
+// left as they are.
+func writeSixel(out io.Writer, picture image.Image) error {
+	bounds := picture.Bounds()
+
+	var sixel strings.Builder
+	sixel.WriteString("\x1bPq")
+	fmt.Fprintf(&sixel, "\"1;1;%d;%d", bounds.Dx(), bounds.Dy())
+	for index := 0; index < 216; index++ {
+		fmt.Fprintf(&sixel, "#%d;2;%d;%d;%d", index, index/36*20, index/6%6*20, index%6*20)
+	}
+
+	level := func(value uint32) int { return int((value>>8)*5+127) / 255 }
+	colors := make([]int, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			red, green, blue, alpha := picture.At(x, y).RGBA()
+			color := -1
+			if alpha >= 0x8000 {
+				color = level(red)*36 + level(green)*6 + level(blue)
+			}
+			colors[(y-bounds.Min.Y)*bounds.Dx()+x-bounds.Min.X] = color
+		}
+	}
+
+	for band := 0; band < bounds.Dy(); band += 6 {
+		used := map[int]bool{}
+		for row := band; row < min(band+6, bounds.Dy()); row++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				if color := colors[row*bounds.Dx()+x]; color >= 0 {
+					used[color] = true
+				}
+			}
+		}
+
+		// One pass over the band per color, each returning to its start
+		for color := range used {
+			fmt.Fprintf(&sixel, "#%d", color)
+
+			previous, run := byte(0), 0
+			flush := func() {
+				switch {
+				case run > 3:
+					fmt.Fprintf(&sixel, "!%d%c", run, previous)
+				case run > 0:
+					sixel.WriteString(strings.Repeat(string(previous), run))
+				}
+			}
+
+			for x := 0; x < bounds.Dx(); x++ {
+				bits := 0
+				for offset := 0; offset < 6 && band+offset < bounds.Dy(); offset++ {
+					if colors[(band+offset)*bounds.Dx()+x] == color {
+						bits |= 1 << offset
+					}
+				}
+
+				char := byte(63 + bits)
+				if char != previous {
+					flush()
+					previous, run = char, 0
+				}
+				run++
+			}
+			flush()
+			sixel.WriteString("$")
+		}
+		sixel.WriteString("-")
+	}
+	sixel.WriteString("\x1b\\\n")
+
+	_, err := io.WriteString(out, sixel.String())
+	return err
+}
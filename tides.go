@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tide prediction stations of NOAA, which covers the US without a key
+const NOAA_TIDE_STATIONS_URL = "https://api.tidesandcurrents.noaa.gov/mdapi/prod/webapi/stations.json?type=tidepredictions"
+
+// High and low tide predictions of a NOAA station
+const NOAA_TIDE_PREDICTIONS_URL = "https://api.tidesandcurrents.noaa.gov/api/prod/datagetter"
+
+// Stations farther away than this tell little about the tides of a place
+const MAX_TIDE_STATION_KM = 100
+
+// One high or low tide, its height in meters
+type tide struct {
+	Time   time.Time
+	Height float64
+	High   bool
+}
+
+// The tides of a day and where they come from
+type tideTable struct {
+	Source string
+	Tides  []tide
+}
+
+// Tide stations of NOAA
+type noaaStations struct {
+	Stations []struct {
+		ID   string  `json:"id"`
+		Name string  `json:"name"`
+		Lat  float64 `json:"lat"`
+		Lon  float64 `json:"lng"`
+	} `json:"stations"`
+}
+
+// High and low tides of a NOAA station, times in GMT
+type noaaPredictions struct {
+	Predictions []struct {
+		Time   string `json:"t"`
+		Height string `json:"v"`
+		Type   string `json:"type"`
+	} `json:"predictions"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Fetches the high and low tides of the NOAA station nearest to a US
+// coordinate over a span, with the station's name and distance
+func fetchNOAATides(ctx context.Context, at coordinate, from time.Time, to time.Time, units unitSystem) (tideTable, error) {
+	status("[@] Fetching tide stations from NOAA")
+
+	body, err := fetch(ctx, NOAA_TIDE_STATIONS_URL)
+	if err != nil {
+		return tideTable{}, fmt.Errorf("fetching tide stations from NOAA: %w", err)
+	}
+
+	var stations noaaStations
+	if err := json.Unmarshal(body, &stations); err != nil {
+		return tideTable{}, fmt.Errorf("parsing NOAA tide stations: %w", err)
+	}
+
+	nearest, best := -1, float64(MAX_TIDE_STATION_KM)
+	for index, station := range stations.Stations {
+		if km := distanceKm(at, coordinate{Lat: station.Lat, Lon: station.Lon}); km < best {
+			nearest, best = index, km
+		}
+	}
+	if nearest < 0 {
+		return tideTable{}, fmt.Errorf("no NOAA tide station within %d km", MAX_TIDE_STATION_KM)
+	}
+	station := stations.Stations[nearest]
+
+	status("[@] Fetching tides of " + station.Name + " from NOAA")
+
+	query := url.Values{}
+	query.Set("product", "predictions")
+	query.Set("datum", "MLLW")
+	query.Set("interval", "hilo")
+	query.Set("units", "metric")
+	query.Set("time_zone", "gmt")
+	query.Set("format", "json")
+	query.Set("application", "weather-cli")
+	query.Set("station", station.ID)
+	query.Set("begin_date", from.UTC().Format("20060102 15:04"))
+	query.Set("end_date", to.UTC().Format("20060102 15:04"))
+
+	body, err = fetch(ctx, NOAA_TIDE_PREDICTIONS_URL+"?"+query.Encode())
+	if err != nil {
+		return tideTable{}, fmt.Errorf("fetching tides from NOAA: %w", err)
+	}
+
+	var parsed noaaPredictions
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return tideTable{}, fmt.Errorf("parsing NOAA tides: %w", err)
+	}
+	if parsed.Error != nil {
+		return tideTable{}, fmt.Errorf("NOAA tides of %s: %s", station.Name, parsed.Error.Message)
+	}
+
+	table := tideTable{Source: fmt.Sprintf(tr("NOAA station %s, %s away"), station.Name, formatDistance(best, units))}
+	for _, prediction := range parsed.Predictions {
+		moment, timeErr := time.Parse("2006-01-02 15:04", prediction.Time)
+		height, heightErr := strconv.ParseFloat(prediction.Height, 64)
+		if timeErr != nil || heightErr != nil {
+			return tideTable{}, fmt.Errorf("bad NOAA tide at %q", prediction.Time)
+		}
+
+		table.Tides = append(table.Tides, tide{Time: moment, Height: height, High: strings.HasPrefix(prediction.Type, "H")})
+	}
+
+	return table, nil
+}
+
+// Hourly sea level off a coordinate, tides included, from the Open-Meteo
+// marine model
+type openMeteoSeaLevel struct {
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+	Hourly           struct {
+		Time     []int64    `json:"time"`
+		SeaLevel []*float64 `json:"sea_level_height_msl"`
+	} `json:"hourly"`
+}
+
+// Fetches the modeled sea level of today and tomorrow, with the time zone
+// of the place
+func fetchSeaLevel(ctx context.Context, at coordinate) (openMeteoSeaLevel, error) {
+	status("[@] Fetching the sea level from Open-Meteo")
+
+	query := url.Values{}
+	query.Set("latitude", fmt.Sprintf("%f", at.Lat))
+	query.Set("longitude", fmt.Sprintf("%f", at.Lon))
+	query.Set("timezone", "auto")
+	query.Set("timeformat", "unixtime")
+	query.Set("hourly", "sea_level_height_msl")
+	query.Set("forecast_days", "2")
+
+	body, err := fetch(ctx, OPEN_METEO_MARINE_URL+"?"+query.Encode())
+	if err != nil {
+		return openMeteoSeaLevel{}, fmt.Errorf("fetching the sea level from Open-Meteo: %w", err)
+	}
+
+	var parsed openMeteoSeaLevel
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return openMeteoSeaLevel{}, fmt.Errorf("parsing the Open-Meteo sea level: %w", err)
+	}
+
+	return parsed, nil
+}
+
+// Highs and lows of the hourly sea level within a span, each placed between
+// the hours by the parabola through it and its neighbours
+func (s openMeteoSeaLevel) tides(from time.Time, to time.Time) []tide {
+	var tides []tide
+	levels := s.Hourly.SeaLevel
+	for index := 1; index+1 < len(s.Hourly.Time) && index+1 < len(levels); index++ {
+		before, level, after := levels[index-1], levels[index], levels[index+1]
+		if before == nil || level == nil || after == nil {
+			continue
+		}
+
+		high := *level > *before && *level >= *after
+		low := *level < *before && *level <= *after
+		if !high && !low {
+			continue
+		}
+
+		// Vertex of the parabola, in hours from this one
+		curvature := *before - 2**level + *after
+		offset := 0.0
+		if curvature != 0 {
+			offset = (*before - *after) / (2 * curvature)
+		}
+		height := *level - (*before-*after)*offset/4
+
+		moment := time.Unix(s.Hourly.Time[index], 0).Add(time.Duration(offset * float64(time.Hour)))
+		if !moment.Before(from) && moment.Before(to) {
+			tides = append(tides, tide{Time: moment, Height: height, High: high})
+		}
+	}
+
+	return tides
+}
+
+// The high and low tides of the place's calendar day, from the nearest NOAA
+// station in the US and else from the modeled sea level, with the time zone
+// of the place
+func fetchTides(ctx context.Context, target location, units unitSystem) (tideTable, *time.Location, error) {
+	sea, err := fetchSeaLevel(ctx, target.Coord)
+	if err != nil {
+		return tideTable{}, nil, err
+	}
+
+	zone := time.FixedZone(sea.Timezone, sea.UTCOffsetSeconds)
+	now := time.Now().In(zone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, zone)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	if strings.EqualFold(target.Country, "US") {
+		table, err := fetchNOAATides(ctx, target.Coord, today, tomorrow, units)
+		if err == nil && len(table.Tides) > 0 {
+			return table, zone, nil
+		}
+		logger.Debug("NOAA tide lookup failed", "error", err)
+	}
+
+	if tides := sea.tides(today, tomorrow); len(tides) > 0 {
+		return tideTable{Source: tr("modeled sea level from Open-Meteo, not a tide station"), Tides: tides}, zone, nil
+	}
+
+	return tideTable{}, nil, errors.New("no tides known here, they are predicted at coasts only")
+}
+
+// The tides on one line, as in "high tide 4:12 AM 1.4 m, low tide 10:30 AM 0.2 m"
+func (t tideTable) summary(zone *time.Location, options displayOptions) string {
+	var parts []string
+	for _, entry := range t.Tides {
+		kind := tr("low tide")
+		if entry.High {
+			kind = tr("high tide")
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s", kind, entry.Time.In(zone).Format(options.clockFormat()), formatWaveHeight(entry.Height, options.Units)))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Implements `weather tides`, today's high and low tides at the location
+// and how long until the next one
+func runTides(ctx context.Context, target location, units string) error {
+	options, err := resolveDisplay(units, target.Country)
+	if err != nil {
+		return err
+	}
+
+	table, zone, err := fetchTides(ctx, target, options.Units)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %s\n", tr("Tides from"), table.Source)
+
+	now := time.Now()
+	rows := [][]string{translated("Tide", "Time", "Height")}
+	for _, entry := range table.Tides {
+		kind := tr("Low tide")
+		if entry.High {
+			kind = tr("High tide")
+		}
+		rows = append(rows, []string{kind, entry.Time.In(zone).Format(options.clockFormat()) + countdown(entry.Time, now), formatWaveHeight(entry.Height, options.Units)})
+	}
+
+	fmt.Println()
+	printTable(os.Stdout, rows)
+
+	return nil
+}
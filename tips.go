@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// How far ahead the tips look, frost covering the night to come
+const (
+	TIPS_HOURS       = 12
+	FROST_TIPS_HOURS = 24
+)
+
+// Thresholds of the tips in metric units
+const (
+	FROST_C          = 0.0
+	HOT_FEELS_LIKE_C = 32.0
+	STRONG_GUST_KMH  = 60.0
+	SUNSCREEN_UVI    = 3.0
+	FOG_VISIBILITY_M = 1000
+)
+
+// What the tips are judged from: the current conditions, the hourly forecast
+// after them and today's outlook, in the units they were fetched in
+type tipContext struct {
+	Current conditions
+	Hours   []conditions // Forecast from now on, may be empty
+	Today   *dailyForecast
+	Units   unitSystem
+}
+
+// The current conditions and the forecast hours within the given span
+func (t tipContext) within(hours int) []conditions {
+	until := t.Current.Time.Add(time.Duration(hours) * time.Hour)
+
+	moments := []conditions{t.Current}
+	for _, hour := range t.Hours {
+		if hour.Time.Before(until) {
+			moments = append(moments, hour)
+		}
+	}
+
+	return moments
+}
+
+// Whether any moment within the span matches
+func (t tipContext) any(hours int, matches func(conditions) bool) bool {
+	for _, moment := range t.within(hours) {
+		if matches(moment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// One piece of advice and when it applies
+type tipRule struct {
+	Advice  string
+	Applies func(t tipContext) bool
+}
+
+// Rules behind the tips, shown in this order. New advice only takes another
+// entry.
+var tipRules = []tipRule{
+	{"Take an umbrella", func(t tipContext) bool {
+		return t.any(TIPS_HOURS, func(c conditions) bool {
+			return c.Pop >= RAIN_LIKELY || (c.Condition.Kind.precipitating() && c.Condition.Kind != SNOW)
+		})
+	}},
+	{"Thunderstorms around, stay off open ground and away from water", func(t tipContext) bool {
+		return t.any(TIPS_HOURS, func(c conditions) bool { return c.Condition.Kind == THUNDERSTORM })
+	}},
+	{"Snow on the way, allow extra time for travel", func(t tipContext) bool {
+		return t.any(TIPS_HOURS, func(c conditions) bool { return c.Condition.Kind == SNOW })
+	}},
+	{"High UV, wear sunscreen", func(t tipContext) bool {
+		if t.Today != nil && t.Today.UVI >= SUNSCREEN_UVI {
+			return true
+		}
+		return t.any(TIPS_HOURS, func(c conditions) bool { return c.UVI >= SUNSCREEN_UVI })
+	}},
+	{"Hot, drink plenty of water and avoid effort at midday", func(t tipContext) bool {
+		return t.any(TIPS_HOURS, func(c conditions) bool { return t.Units.toCelsius(c.FeelsLike) >= HOT_FEELS_LIKE_C })
+	}},
+	{"Frost tonight, cover sensitive plants and keep an ice scraper handy", func(t tipContext) bool {
+		if len(t.Hours) == 0 && t.Today != nil {
+			return t.Units.toCelsius(t.Today.TempMin) <= FROST_C
+		}
+		return t.any(FROST_TIPS_HOURS, func(c conditions) bool { return t.Units.toCelsius(c.Temp) <= FROST_C })
+	}},
+	{"Strong gusts, secure loose items outdoors", func(t tipContext) bool {
+		return t.any(TIPS_HOURS, func(c conditions) bool {
+			return KILOMETERS_PER_HOUR.fromMetersPerSecond(t.Units.toMetersPerSecond(c.WindGust)) >= STRONG_GUST_KMH
+		})
+	}},
+	{"Critical fire weather, avoid anything that could spark a fire outdoors", func(t tipContext) bool {
+		return t.any(TIPS_HOURS, func(c conditions) bool { return c.redFlag(t.Units) })
+	}},
+	{"Fog, drive slowly with low beams", func(t tipContext) bool {
+		return t.Current.Condition.Kind == FOG || (t.Current.Visibility > 0 && t.Current.Visibility < FOG_VISIBILITY_M)
+	}},
+}
+
+// Advice that applies to the weather, translated, in the order of tipRules
+func (w weatherData) tips(units unitSystem) []string {
+	situation := tipContext{Current: w.Current, Units: units}
+	for _, hour := range w.Hourly {
+		if hour.Time.After(w.Current.Time) {
+			situation.Hours = append(situation.Hours, hour)
+		}
+	}
+	if len(w.Daily) > 0 {
+		situation.Today = &w.Daily[0]
+	}
+
+	var tips []string
+	for _, rule := range tipRules {
+		if rule.Applies(situation) {
+			tips = append(tips, tr(rule.Advice))
+		}
+	}
+
+	return tips
+}
+
+// Prints the tips section of the full report, nothing when no tip applies
+func printTips(tips []string) {
+	if len(tips) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s:\n", tr("Tips"))
+	for _, tip := range tips {
+		fmt.Printf("  - %s\n", tip)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Observations within this distance count as the same place
+const NEARBY_KM = 25.0
+
+// Window of past observations used for the pressure tendency
+const (
+	TREND_MIN_AGE = 3 * time.Hour
+	TREND_MAX_AGE = 6 * time.Hour
+)
+
+// Pressure change per three hours below which pressure counts as steady,
+// and above which it changes rapidly
+const (
+	STEADY_HPA = 1.0
+	RAPID_HPA  = 3.5
+)
+
+// Stored observations near a coordinate, oldest first
+func nearbyHistory(entries []observation, at coordinate) []observation {
+	var nearby []observation
+
+	for _, entry := range entries {
+		if distanceKm(at, coordinate{Lat: entry.Lat, Lon: entry.Lon}) <= NEARBY_KM {
+			nearby = append(nearby, entry)
+		}
+	}
+
+	return nearby
+}
+
+// Describes how pressure changed since the stored reading closest to three
+// hours ago, e.g. "↑ rising (+1.8 hPa/3h)", in the given unit. Empty when
+// history is too short.
+func pressureTrend(entries []observation, at coordinate, now int64, pressure int64, unit pressureUnit) string {
+	var past *observation
+
+	nearby := nearbyHistory(entries, at)
+
+	for index, entry := range nearby {
+		age := time.Duration(now-entry.Time) * time.Second
+		if age < TREND_MIN_AGE || age > TREND_MAX_AGE {
+			continue
+		}
+
+		// The youngest qualifying reading is the one nearest three hours ago
+		past = &nearby[index]
+	}
+
+	if past == nil {
+		return ""
+	}
+
+	// Normalize to the conventional three hour tendency
+	hours := float64(now-past.Time) / 3600
+	change := float64(pressure-past.Pressure) * 3 / hours
+
+	// Classified in hPa, shown in the chosen unit with one more decimal
+	shown := fmt.Sprintf("%+.*f %s/3h", unit.precision()+1, unit.fromHectopascals(change), unit)
+
+	switch {
+	case math.Abs(change) < STEADY_HPA:
+		return fmt.Sprintf("→ %s (%s)", tr("steady"), shown)
+	case change >= RAPID_HPA:
+		return fmt.Sprintf("⇈ %s (%s)", tr("rising rapidly"), shown)
+	case change > 0:
+		return fmt.Sprintf("↑ %s (%s)", tr("rising"), shown)
+	case change <= -RAPID_HPA:
+		return fmt.Sprintf("⇊ %s (%s)", tr("falling rapidly"), shown)
+	default:
+		return fmt.Sprintf("↓ %s (%s)", tr("falling"), shown)
+	}
+}
+
+// How far from exactly a day ago a stored reading may be to stand for the
+// same time yesterday
+const YESTERDAY_WINDOW = 90 * time.Minute
+
+// Differences below these count as the same when comparing with yesterday
+const (
+	SAME_TEMPERATURE_C = 1.0
+	SAME_WIND_MS       = 2.0
+	SAME_HUMIDITY      = 15
+)
+
+// Compares the current conditions with the stored reading nearest the same
+// time yesterday, e.g. "3°C warmer, less windy". Empty when there is none.
+func yesterdayChange(entries []observation, at coordinate, current conditions, units unitSystem) string {
+	target := current.Time.Add(-24 * time.Hour).Unix()
+
+	var past *observation
+	nearby := nearbyHistory(entries, at)
+	for index, entry := range nearby {
+		offset := time.Duration(entry.Time-target).Abs() * time.Second
+		if offset > YESTERDAY_WINDOW {
+			continue
+		}
+
+		if past == nil || offset < time.Duration(past.Time-target).Abs()*time.Second {
+			past = &nearby[index]
+		}
+	}
+
+	if past == nil {
+		return ""
+	}
+
+	var changes []string
+
+	// Stored readings are metric, the difference is shown in the chosen units
+	difference := units.toCelsius(current.Temp) - past.Temp
+	shown := math.Abs(current.Temp - units.fromCelsius(past.Temp))
+	switch {
+	case difference >= SAME_TEMPERATURE_C:
+		changes = append(changes, fmt.Sprintf(tr("%.0f%s warmer"), shown, units.temperature()))
+	case difference <= -SAME_TEMPERATURE_C:
+		changes = append(changes, fmt.Sprintf(tr("%.0f%s colder"), shown, units.temperature()))
+	default:
+		changes = append(changes, tr("about as warm"))
+	}
+
+	switch wind := units.toMetersPerSecond(current.WindSpeed) - past.WindSpeed; {
+	case wind >= SAME_WIND_MS:
+		changes = append(changes, tr("windier"))
+	case wind <= -SAME_WIND_MS:
+		changes = append(changes, tr("less windy"))
+	}
+
+	switch humidity := current.Humidity - past.Humidity; {
+	case humidity >= SAME_HUMIDITY:
+		changes = append(changes, tr("more humid"))
+	case humidity <= -SAME_HUMIDITY:
+		changes = append(changes, tr("drier"))
+	}
+
+	return strings.Join(changes, ", ")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// The change since yesterday is shown in the chosen units, with the stored
+// metric reading converted once
+func TestYesterdayChange(t *testing.T) {
+	now := time.Date(2025, time.October, 14, 12, 0, 0, 0, time.UTC)
+	at := coordinate{Lat: 41.88, Lon: -87.63}
+	entries := []observation{{Time: now.Add(-24 * time.Hour).Unix(), Lat: at.Lat, Lon: at.Lon, Temp: 10}}
+
+	cases := []struct {
+		units unitSystem
+		temp  float64
+		want  string
+	}{
+		{IMPERIAL, 60, "10°F warmer"},
+		{IMPERIAL, 41, "9°F colder"},
+		{METRIC, 16, "6°C warmer"},
+		{METRIC, 10, "about as warm"},
+	}
+
+	for _, test := range cases {
+		current := conditions{Time: now, Temp: test.temp}
+		if got := yesterdayChange(entries, at, current, test.units); got != test.want {
+			t.Errorf("yesterdayChange at %v in %s = %q, want %q", test.temp, test.units, got, test.want)
+		}
+	}
+}
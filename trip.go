@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Past years averaged into the normals for days beyond the forecast
+const TRIP_NORMAL_YEARS = 10
+
+// Longest trip in days, to keep the archive requests in reason
+const MAX_TRIP_DAYS = 31
+
+// Precipitation in millimeters that makes a day count as wet in the normals
+const WET_DAY_MM = 1.0
+
+// What a calendar day has been like over the past years
+type climateNormal struct {
+	Years         int
+	TempMin       float64 // Averages
+	TempMax       float64
+	Precipitation float64
+	WindSpeed     float64
+	WetYears      int
+}
+
+// Averages the same calendar days of past years by month and day, fetching
+// the years in parallel. Years that fail are left out of the averages.
+func climateNormals(ctx context.Context, at coordinate, units unitSystem, from time.Time, to time.Time) (map[string]climateNormal, error) {
+	years := make([]int, TRIP_NORMAL_YEARS)
+	for index := range years {
+		years[index] = index + 1
+	}
+
+	results := runPool("Fetching past years", years, func(back int) string { return fmt.Sprint(from.Year() - back) }, func(back int) (weatherData, error) {
+		return fetchOpenMeteoDays(ctx, at, units, from.AddDate(-back, 0, 0), to.AddDate(-back, 0, 0))
+	})
+
+	normals := map[string]climateNormal{}
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		for _, day := range result.Value.Daily {
+			key := day.Date.Format("01-02")
+			normal := normals[key]
+			normal.Years++
+			normal.TempMin += day.TempMin
+			normal.TempMax += day.TempMax
+			normal.Precipitation += day.Precipitation
+			normal.WindSpeed += day.WindSpeed
+			if day.Precipitation >= units.fromMillimeters(WET_DAY_MM) {
+				normal.WetYears++
+			}
+			normals[key] = normal
+		}
+	}
+
+	if len(normals) == 0 {
+		return nil, fmt.Errorf("no past years of weather for %s to %s", from.Format("Jan 2"), to.Format("Jan 2"))
+	}
+
+	for key, normal := range normals {
+		years := float64(normal.Years)
+		normal.TempMin /= years
+		normal.TempMax /= years
+		normal.Precipitation /= years
+		normal.WindSpeed /= years
+		normals[key] = normal
+	}
+
+	return normals, nil
+}
+
+// How often the day has been wet, in words
+func (n climateNormal) outlook() string {
+	switch share := float64(n.WetYears) / float64(n.Years); {
+	case share < 0.3:
+		return "usually dry"
+	case share < 0.6:
+		return "often wet"
+	default:
+		return "usually wet"
+	}
+}
+
+// Implements `weather trip`, a day by day outlook over a date range from
+// the forecast, and from past years' normals where the forecast runs out
+func runTrip(ctx context.Context, target location, fromText string, toText string, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+	now := weather.Current.Time
+
+	from, err := parseDay(fromText, now)
+	if err != nil {
+		return err
+	}
+	to := from
+	if toText != "" {
+		if to, err = parseDay(toText, now); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case to.Before(from):
+		return fmt.Errorf("the trip ends on %s before it starts on %s", to.Format("Jan 2"), from.Format("Jan 2"))
+	case from.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())):
+		return fmt.Errorf("the trip starts in the past, see `weather history -date %s`", from.Format("2006-01-02"))
+	case to.Sub(from) >= MAX_TRIP_DAYS*24*time.Hour:
+		return fmt.Errorf("trips of up to %d days can be planned", MAX_TRIP_DAYS)
+	}
+
+	forecasts := map[string]dailyForecast{}
+	for _, day := range weather.Daily {
+		forecasts[day.Date.Format("2006-01-02")] = day
+	}
+
+	var normals map[string]climateNormal
+	if _, covered := forecasts[to.Format("2006-01-02")]; !covered {
+		if normals, err = climateNormals(ctx, weather.Coord, options.Units, from, to); err != nil {
+			return err
+		}
+	}
+
+	temperature := options.Units.temperature()
+
+	place := firstNonEmpty(target.CompactName, target.Name, fmt.Sprintf("%.4f, %.4f", weather.Coord.Lat, weather.Coord.Lon))
+	fmt.Printf("\n%s, %s – %s\n\n", place, from.Format("Mon Jan 2"), to.Format("Mon Jan 2 2006"))
+
+	rows := [][]string{translated("Day", "Source", "Condition", "Temp", "Rain chance", "Precipitation", "Wind")}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if forecast, found := forecasts[day.Format("2006-01-02")]; found {
+			rows = append(rows, []string{
+				day.Format("Mon Jan 2"),
+				tr("forecast"),
+				forecast.Condition.emoji() + " " + forecast.Condition.Description,
+				fmt.Sprintf("%.0f–%.0f%s", forecast.TempMin, forecast.TempMax, temperature),
+				fmt.Sprintf("%.0f%%", forecast.Pop*100),
+				options.Units.formatPrecipitation(forecast.Precipitation),
+				options.wind(forecast.WindSpeed, 1),
+			})
+			continue
+		}
+
+		normal, found := normals[day.Format("01-02")]
+		if !found {
+			rows = append(rows, []string{day.Format("Mon Jan 2"), tr("normal"), "-", "-", "-", "-", "-"})
+			continue
+		}
+
+		rows = append(rows, []string{
+			day.Format("Mon Jan 2"),
+			tr("normal"),
+			tr(normal.outlook()),
+			fmt.Sprintf("%.0f–%.0f%s", normal.TempMin, normal.TempMax, temperature),
+			fmt.Sprintf("%.0f%%", float64(normal.WetYears)/float64(normal.Years)*100),
+			options.Units.formatPrecipitation(normal.Precipitation),
+			options.wind(normal.WindSpeed, 1),
+		})
+	}
+	printTable(os.Stdout, rows)
+
+	if normals != nil {
+		fmt.Printf("\n"+tr("Normal days average the past %d years; rain chance is how many of them were wet.")+"\n", TRIP_NORMAL_YEARS)
+	}
+
+	return nil
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Zone names for -tz on systems without a zoneinfo database, like Windows
+	_ "time/tzdata"
+)
+
+// Measurement system used when requesting and printing weather
+type unitSystem string
+
+const (
+	METRIC   unitSystem = "metric"
+	IMPERIAL unitSystem = "imperial"
+)
+
+// Value of -units that infers the system from the location
+const AUTO_UNITS = "auto"
+
+// Countries that still measure temperature in °F
+var imperialCountries = map[string]bool{
+	"US": true,
+	"LR": true,
+	"MM": true,
+}
+
+// Countries where the 12-hour clock is the everyday convention
+var twelveHourCountries = map[string]bool{
+	"US": true,
+	"CA": true,
+	"AU": true,
+	"NZ": true,
+	"IN": true,
+	"PH": true,
+	"PK": true,
+	"EG": true,
+	"SA": true,
+}
+
+// Value of -time-format that picks the clock from the location
+const AUTO_TIME_FORMAT = "auto"
+
+// Accepted values of -time-format
+var timeFormats = []string{AUTO_TIME_FORMAT, "12", "24"}
+
+// Clock chosen with -time-format, the environment or the profile
+var hourClock = AUTO_TIME_FORMAT
+
+// Unit wind speeds are shown in
+type windUnit string
+
+const (
+	METERS_PER_SECOND   windUnit = "m/s"
+	KILOMETERS_PER_HOUR windUnit = "km/h"
+	MILES_PER_HOUR      windUnit = "mph"
+	KNOTS               windUnit = "knots"
+	BEAUFORT            windUnit = "beaufort"
+)
+
+// Accepted values of -wind
+var windUnits = []string{AUTO_UNITS, string(METERS_PER_SECOND), string(KILOMETERS_PER_HOUR), string(MILES_PER_HOUR), string(KNOTS), string(BEAUFORT)}
+
+// Other spellings of the wind units
+var windUnitAliases = map[string]windUnit{
+	"ms":  METERS_PER_SECOND,
+	"kmh": KILOMETERS_PER_HOUR,
+	"kph": KILOMETERS_PER_HOUR,
+	"kn":  KNOTS,
+	"kt":  KNOTS,
+	"kts": KNOTS,
+	"bft": BEAUFORT,
+}
+
+// Wind unit chosen with -wind, the environment or the profile. Auto follows
+// the units system.
+var windChoice = AUTO_UNITS
+
+// Parses a -wind value, reporting false for unknown ones
+func parseWindUnit(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, found := windUnitAliases[name]; found {
+		return string(alias), true
+	}
+
+	for _, known := range windUnits {
+		if name == known {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// Upper limits in m/s of each Beaufort force below 12, hurricane force
+var beaufortLimits = []float64{0.5, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+// Names of the Beaufort forces from 0 to 12
+var beaufortNames = []string{
+	"calm", "light air", "light breeze", "gentle breeze", "moderate breeze", "fresh breeze", "strong breeze",
+	"near gale", "gale", "strong gale", "storm", "violent storm", "hurricane force",
+}
+
+// Beaufort force of a wind speed in m/s
+func beaufortForce(speed float64) int {
+	for force, limit := range beaufortLimits {
+		if speed < limit {
+			return force
+		}
+	}
+
+	return len(beaufortLimits)
+}
+
+// Converts a speed in m/s to this unit, a Beaufort force for BEAUFORT
+func (w windUnit) fromMetersPerSecond(speed float64) float64 {
+	switch w {
+	case KILOMETERS_PER_HOUR:
+		return speed * 3.6
+	case MILES_PER_HOUR:
+		return speed / 0.44704
+	case KNOTS:
+		return speed * 3600 / 1852
+	case BEAUFORT:
+		return float64(beaufortForce(speed))
+	}
+
+	return speed
+}
+
+// Symbol printed after speeds in this unit
+func (w windUnit) symbol() string {
+	switch w {
+	case KNOTS:
+		return "kn"
+	case BEAUFORT:
+		return "Bft"
+	}
+
+	return string(w)
+}
+
+// Formats a speed in m/s, with the description of the force for Beaufort
+// such as "5 Bft (fresh breeze)"
+func (w windUnit) format(speed float64, precision int) string {
+	if w == BEAUFORT {
+		force := beaufortForce(speed)
+		return fmt.Sprintf("%d Bft (%s)", force, tr(beaufortNames[force]))
+	}
+
+	return fmt.Sprintf("%.*f %s", precision, w.fromMetersPerSecond(speed), w.symbol())
+}
+
+// Unit pressures are shown in
+type pressureUnit string
+
+const (
+	HECTOPASCALS           pressureUnit = "hPa"
+	MILLIMETERS_OF_MERCURY pressureUnit = "mmHg"
+	INCHES_OF_MERCURY      pressureUnit = "inHg"
+)
+
+// Size of the mercury units in hPa
+const (
+	HECTOPASCALS_PER_MMHG = 1.333224
+	HECTOPASCALS_PER_INHG = 33.863886
+)
+
+// Accepted values of -pressure
+var pressureUnits = []string{AUTO_UNITS, string(HECTOPASCALS), string(MILLIMETERS_OF_MERCURY), string(INCHES_OF_MERCURY)}
+
+// Countries where barometers still read millimeters of mercury
+var mmHgCountries = map[string]bool{
+	"RU": true,
+	"BY": true,
+	"KZ": true,
+}
+
+// Pressure unit chosen with -pressure, the environment or the profile. Auto
+// follows the units system and the country.
+var pressureChoice = AUTO_UNITS
+
+// Parses a -pressure value in any case, reporting false for unknown ones
+func parsePressureUnit(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if strings.EqualFold(name, "mb") || strings.EqualFold(name, "mbar") {
+		return string(HECTOPASCALS), true
+	}
+
+	for _, known := range pressureUnits {
+		if strings.EqualFold(name, known) {
+			return known, true
+		}
+	}
+
+	return "", false
+}
+
+// Converts a pressure in hPa to this unit
+func (p pressureUnit) fromHectopascals(pressure float64) float64 {
+	switch p {
+	case MILLIMETERS_OF_MERCURY:
+		return pressure / HECTOPASCALS_PER_MMHG
+	case INCHES_OF_MERCURY:
+		return pressure / HECTOPASCALS_PER_INHG
+	}
+
+	return pressure
+}
+
+// Decimals that give pressures in this unit the precision of whole hPa
+func (p pressureUnit) precision() int {
+	if p == INCHES_OF_MERCURY {
+		return 2
+	}
+
+	return 0
+}
+
+// Formats a pressure in hPa, such as "29.92 inHg"
+func (p pressureUnit) format(pressure float64) string {
+	return fmt.Sprintf("%.*f %s", p.precision(), p.fromHectopascals(pressure), p)
+}
+
+// Zone every time is shown in, set with -tz or -local. Nil keeps each
+// place's own zone.
+var displayZone *time.Location
+
+// How weather values and times are presented to the user
+type displayOptions struct {
+	Units    unitSystem
+	Wind     windUnit
+	Pressure pressureUnit
+	Clock12  bool
+	Zone     *time.Location // Nil for the place's own zone
+}
+
+// Builds display options for a location's country. An explicit units value,
+// -wind, -pressure or -time-format wins over the inferred one.
+func resolveDisplay(units string, country string) (displayOptions, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+
+	options := displayOptions{Units: METRIC, Clock12: twelveHourCountries[country], Zone: displayZone}
+	if imperialCountries[country] {
+		options.Units = IMPERIAL
+	}
+
+	switch hourClock {
+	case "12":
+		options.Clock12 = true
+	case "24":
+		options.Clock12 = false
+	}
+
+	switch strings.ToLower(units) {
+	case AUTO_UNITS, "":
+	case string(METRIC):
+		options.Units = METRIC
+	case string(IMPERIAL):
+		options.Units = IMPERIAL
+	default:
+		return options, fmt.Errorf("unknown units %q, expected auto, metric or imperial", units)
+	}
+
+	options.Wind = windUnit(windChoice)
+	if windChoice == AUTO_UNITS {
+		options.Wind = METERS_PER_SECOND
+		if options.Units == IMPERIAL {
+			options.Wind = MILES_PER_HOUR
+		}
+	}
+
+	options.Pressure = pressureUnit(pressureChoice)
+	if pressureChoice == AUTO_UNITS {
+		switch {
+		case options.Units == IMPERIAL:
+			options.Pressure = INCHES_OF_MERCURY
+		case mmHgCountries[country]:
+			options.Pressure = MILLIMETERS_OF_MERCURY
+		default:
+			options.Pressure = HECTOPASCALS
+		}
+	}
+
+	return options, nil
+}
+
+// Symbol printed after temperatures
+func (u unitSystem) temperature() string {
+	if u == IMPERIAL {
+		return "°F"
+	}
+
+	return "°C"
+}
+
+// Symbol printed after wind speeds
+func (u unitSystem) speed() string {
+	if u == IMPERIAL {
+		return "mph"
+	}
+
+	return "m/s"
+}
+
+// Formats a wind speed given in the units system in the chosen wind unit
+func (d displayOptions) wind(speed float64, precision int) string {
+	return d.Wind.format(d.Units.toMetersPerSecond(speed), precision)
+}
+
+// Layout used for short times of day without seconds or zone
+func (d displayOptions) clockFormat() string {
+	if d.Clock12 {
+		return "3:04 PM"
+	}
+
+	return "15:04"
+}
+
+// Layout used for times of day
+func (d displayOptions) timeFormat() string {
+	if d.Clock12 {
+		return "3:04:05 PM MST" // H:MM:SS AM/PM Timezone
+	}
+
+	return "15:04:05 MST" // HH:MM:SS Timezone
+}
+
+// Parses a -tz value: an IANA zone such as Europe/Paris, UTC, or local for
+// this machine's zone
+func parseZone(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q, expected a name such as Europe/Paris, UTC or local", name)
+	}
+
+	return zone, nil
+}
+
+// The time in the chosen zone, or unchanged when none was chosen
+func (d displayOptions) in(t time.Time) time.Time {
+	if d.Zone == nil || t.IsZero() {
+		return t
+	}
+
+	return t.In(d.Zone)
+}
+
+// Sixteen-point compass name for a wind direction in degrees
+func compassDirection(degrees int64) string {
+	points := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+	index := int((float64(degrees%360)+11.25)/22.5) % len(points)
+
+	return points[index]
+}
+
+// Converts a temperature in this system to °C
+func (u unitSystem) toCelsius(value float64) float64 {
+	if u == IMPERIAL {
+		return (value - 32) * 5 / 9
+	}
+
+	return value
+}
+
+// Converts a temperature in °C to this system
+func (u unitSystem) fromCelsius(value float64) float64 {
+	if u == IMPERIAL {
+		return value*9/5 + 32
+	}
+
+	return value
+}
+
+// Converts a speed in this system to m/s
+func (u unitSystem) toMetersPerSecond(value float64) float64 {
+	if u == IMPERIAL {
+		return value * 0.44704
+	}
+
+	return value
+}
+
+// Converts a speed in m/s to this system
+func (u unitSystem) fromMetersPerSecond(value float64) float64 {
+	if u == IMPERIAL {
+		return value / 0.44704
+	}
+
+	return value
+}
+
+// Symbol printed after amounts of precipitation
+func (u unitSystem) precipitation() string {
+	if u == IMPERIAL {
+		return "in"
+	}
+
+	return "mm"
+}
+
+// Formats an amount of precipitation given in this system, with inches to
+// hundredths as rain gauges read them
+func (u unitSystem) formatPrecipitation(amount float64) string {
+	if u == IMPERIAL {
+		return fmt.Sprintf("%.2f in", amount)
+	}
+
+	return fmt.Sprintf("%.1f mm", amount)
+}
+
+// Converts an amount of precipitation in mm to this system
+func (u unitSystem) fromMillimeters(value float64) float64 {
+	if u == IMPERIAL {
+		return value / 25.4
+	}
+
+	return value
+}
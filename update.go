@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release of this build, set with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// GitHub API endpoint describing the newest release
+const LATEST_RELEASE_URL = "https://api.github.com/repos/rohitaryal/weather-cli/releases/latest"
+
+// Release asset listing the SHA-256 of every binary, in sha256sum format
+const CHECKSUMS_ASSET = "checksums.txt"
+
+// Time allowed for downloading a binary when -timeout is left at its default
+const DOWNLOAD_TIMEOUT = 2 * time.Minute
+
+// File in the data directory remembering the latest release the notice saw
+const UPDATE_CHECK_FILE = "update-check.json"
+
+// How often the passive notice asks GitHub for the latest release
+const UPDATE_CHECK_INTERVAL = 24 * time.Hour
+
+// Longest the passive notice may delay a command
+const UPDATE_CHECK_TIMEOUT = 2 * time.Second
+
+// Whether to mention newer releases after a command, set in the config
+var updateNotice bool
+
+// Result of the last passive check
+type updateCheck struct {
+	Checked time.Time `json:"checked"`
+	Latest  string    `json:"latest"`
+}
+
+// Parts of a GitHub release that updating needs
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	HTMLURL string        `json:"html_url"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+// File attached to a release
+type githubAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// Looks up the newest published release
+func fetchLatestRelease(ctx context.Context) (githubRelease, error) {
+	var release githubRelease
+
+	body, err := fetch(ctx, LATEST_RELEASE_URL)
+	if err != nil {
+		return release, fmt.Errorf("looking up the latest release: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &release); err != nil {
+		return release, fmt.Errorf("parsing the latest release: %w", err)
+	}
+
+	if release.TagName == "" {
+		return release, errors.New("the latest release has no version tag")
+	}
+
+	return release, nil
+}
+
+// Finds an asset of the release by file name
+func (r githubRelease) asset(name string) (githubAsset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+
+	return githubAsset{}, false
+}
+
+// Name of the release binary built for this platform
+func binaryAssetName() string {
+	name := fmt.Sprintf("weather-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+// Compares two versions such as v1.2.3 part by part, returning -1, 0 or 1.
+// Versions that aren't numeric, like dev builds, sort before every release.
+func compareVersions(a string, b string) int {
+	parse := func(text string) ([]int, bool) {
+		var parts []int
+		for _, field := range strings.Split(strings.TrimPrefix(text, "v"), ".") {
+			number, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, false
+			}
+			parts = append(parts, number)
+		}
+
+		return parts, true
+	}
+
+	left, leftOK := parse(a)
+	right, rightOK := parse(b)
+	switch {
+	case !leftOK && !rightOK:
+		return strings.Compare(a, b)
+	case !leftOK:
+		return -1
+	case !rightOK:
+		return 1
+	}
+
+	for index := 0; index < max(len(left), len(right)); index++ {
+		var l, r int
+		if index < len(left) {
+			l = left[index]
+		}
+		if index < len(right) {
+			r = right[index]
+		}
+
+		if l != r {
+			if l < r {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Finds the expected SHA-256 of a file in a sha256sum style listing
+func findChecksum(listing []byte, name string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(listing))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+
+	return "", false
+}
+
+// Swaps the running executable for a new binary. The binary is written next
+// to it and renamed over it, so an interrupted update leaves the old one.
+func replaceExecutable(binary []byte) (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("locating the running executable: %w", err)
+	}
+
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("locating the running executable: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".weather-update-*")
+	if err != nil {
+		return "", fmt.Errorf("no permission to update %s: %w", path, err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(binary); err != nil {
+		temp.Close()
+		return "", fmt.Errorf("writing the new binary: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return "", fmt.Errorf("writing the new binary: %w", err)
+	}
+
+	if err := os.Chmod(temp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return "", err
+	}
+
+	// Windows won't replace a running executable, but it allows renaming it
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+
+		if err := os.Rename(path, old); err != nil {
+			return "", fmt.Errorf("moving the old binary aside: %w", err)
+		}
+
+		if err := os.Rename(temp.Name(), path); err != nil {
+			os.Rename(old, path)
+			return "", fmt.Errorf("installing the new binary: %w", err)
+		}
+
+		return path, nil
+	}
+
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return "", fmt.Errorf("installing the new binary: %w", err)
+	}
+
+	return path, nil
+}
+
+// Implements `weather version`, optionally asking whether it is outdated
+func runVersion(ctx context.Context, check bool) error {
+	fmt.Println("weather " + version)
+
+	if !check {
+		return nil
+	}
+
+	release, err := fetchLatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	if compareVersions(version, release.TagName) < 0 {
+		fmt.Printf("%s is available, run weather self-update or see %s\n", release.TagName, release.HTMLURL)
+	} else {
+		fmt.Printf("Up to date, the latest release is %s\n", release.TagName)
+	}
+
+	return nil
+}
+
+// Mentions a newer release on stderr when the notice is turned on. GitHub is
+// asked at most once a day and only briefly, and any failure stays silent.
+// Development builds are never reported as outdated.
+func noticeUpdate(ctx context.Context) {
+	if !updateNotice || quiet || version == "dev" || !isTerminal(os.Stderr) {
+		return
+	}
+
+	path, err := dataPath(UPDATE_CHECK_FILE)
+	if err != nil {
+		return
+	}
+
+	var last updateCheck
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &last)
+	}
+
+	if time.Since(last.Checked) > UPDATE_CHECK_INTERVAL {
+		checkCtx, cancel := context.WithTimeout(ctx, UPDATE_CHECK_TIMEOUT)
+		release, err := fetchLatestRelease(checkCtx)
+		cancel()
+
+		// Record failed checks too, so an offline machine isn't slowed every run
+		last.Checked = time.Now()
+		if err == nil {
+			last.Latest = release.TagName
+		} else {
+			logger.Debug("update check failed", "error", err)
+		}
+
+		if data, err := json.Marshal(last); err == nil && os.MkdirAll(filepath.Dir(path), 0o755) == nil {
+			os.WriteFile(path, data, 0o644)
+		}
+	}
+
+	if last.Latest != "" && compareVersions(version, last.Latest) < 0 {
+		status(fmt.Sprintf("[!] weather %s is available (running %s), run weather self-update", last.Latest, version))
+	}
+}
+
+// Implements `weather self-update`
+func runSelfUpdate(ctx context.Context, check bool, force bool) error {
+	status("[@] Checking for a newer release")
+
+	release, err := fetchLatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	if compareVersions(version, release.TagName) >= 0 && !force {
+		fmt.Printf("weather %s is up to date (latest release %s)\n", version, release.TagName)
+		return nil
+	}
+
+	if check {
+		fmt.Printf("weather %s is available (running %s): %s\n", release.TagName, version, release.HTMLURL)
+		return nil
+	}
+
+	name := binaryAssetName()
+	binaryAsset, found := release.asset(name)
+	if !found {
+		return fmt.Errorf("release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	checksumAsset, found := release.asset(CHECKSUMS_ASSET)
+	if !found {
+		return fmt.Errorf("release %s has no %s, refusing to install an unverified binary", release.TagName, CHECKSUMS_ASSET)
+	}
+
+	listing, err := fetch(ctx, checksumAsset.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("downloading checksums: %w", err)
+	}
+
+	expected, found := findChecksum(listing, name)
+	if !found {
+		return fmt.Errorf("%s of release %s doesn't list %s", CHECKSUMS_ASSET, release.TagName, name)
+	}
+
+	// Binaries are much larger than API answers
+	if requestTimeout == DEFAULT_TIMEOUT {
+		requestTimeout = DOWNLOAD_TIMEOUT
+	}
+
+	status("[@] Downloading " + name + " " + release.TagName)
+
+	binary, err := fetch(ctx, binaryAsset.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", name, err)
+	}
+
+	sum := sha256.Sum256(binary)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, expected, actual)
+	}
+
+	path, err := replaceExecutable(binary)
+	if err != nil {
+		return err
+	}
+
+	status(fmt.Sprintf("[+] Updated %s from %s to %s", path, version, release.TagName))
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Erythemal dose in J/m² that reddens fair skin (Fitzpatrick type II), and
+// the UV irradiance in W/m² one index point stands for
+const (
+	FAIR_SKIN_MED     = 250.0
+	WATTS_PER_UV_UNIT = 0.025
+)
+
+// WHO categories of the UV index with their lower bounds
+var uvCategories = []struct {
+	From float64
+	Name string
+}{
+	{11, "extreme"},
+	{8, "very high"},
+	{6, "high"},
+	{3, "moderate"},
+	{0, "low"},
+}
+
+// WHO category of a UV index
+func uvCategory(index float64) string {
+	rounded := math.Round(index)
+	for _, category := range uvCategories {
+		if rounded >= category.From {
+			return category.Name
+		}
+	}
+
+	return "low"
+}
+
+// Minutes until unprotected fair skin starts to burn at a UV index
+func burnMinutes(index float64) float64 {
+	return FAIR_SKIN_MED / (index * WATTS_PER_UV_UNIT * 60)
+}
+
+// Explains a UV index: its category, how long fair skin can go unprotected
+// and what protection to use when it is high
+func uvAdvice(index float64) string {
+	advice := tr(uvCategory(index))
+
+	// Below 1 there is no meaningful burn time
+	if index < 1 {
+		return advice
+	}
+
+	advice += ", " + fmt.Sprintf(tr("fair skin burns in about %.0f min"), math.Round(burnMinutes(index)/5)*5)
+
+	switch rounded := math.Round(index); {
+	case rounded >= 8:
+		advice += "; " + tr("avoid the midday sun, use SPF 50+ sunscreen, a hat and sunglasses")
+	case rounded >= 3:
+		advice += "; " + tr("use SPF 30+ sunscreen and seek shade around midday")
+	}
+
+	return advice
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Name of the forecast log inside the data directory
+const FORECASTS_FILE = "forecasts.jsonl"
+
+// Observations needed on a day before its high and low are trusted
+const MIN_DAILY_OBSERVATIONS = 3
+
+// One stored daily forecast, in metric units
+type forecastRecord struct {
+	Issued   int64   `json:"issued"`
+	Provider string  `json:"provider"`
+	Location string  `json:"location"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Date     string  `json:"date"` // Local date the forecast is for
+	Lead     int     `json:"lead"` // Days between issue and target date
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Pop      float64 `json:"pop"`
+}
+
+// What was actually observed at a place on one local date
+type observedDay struct {
+	Count  int
+	High   float64
+	Low    float64
+	Rained bool
+}
+
+// Accumulated forecast errors for one provider and lead time
+type accuracy struct {
+	Days      int
+	HighError float64
+	LowError  float64
+	HighBias  float64
+	LowBias   float64
+	Brier     float64
+}
+
+// Converts a report's daily forecasts into records for later verification
+func newForecastRecords(result report) []forecastRecord {
+	weather := result.Weather
+	issued := weather.Current.Time
+	units := result.Options.Units
+
+	name := newObservation(result).Location
+
+	var records []forecastRecord
+	for _, day := range weather.Daily {
+		target := day.Date
+
+		records = append(records, forecastRecord{
+			Issued:   issued.Unix(),
+			Provider: result.Provider,
+			Location: name,
+			Lat:      weather.Coord.Lat,
+			Lon:      weather.Coord.Lon,
+			Date:     target.Format(time.DateOnly),
+			Lead:     daysBetween(issued, target),
+			High:     units.toCelsius(day.TempMax),
+			Low:      units.toCelsius(day.TempMin),
+			Pop:      day.Pop,
+		})
+	}
+
+	return records
+}
+
+// Whole calendar days from one local date to another
+func daysBetween(from, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(math.Round(toDate.Sub(fromDate).Hours() / 24))
+}
+
+// Whether precipitation was falling during an observation. Entries written
+// before conditions were normalized only carry an OpenWeatherMap code.
+func (o observation) precipitating() bool {
+	if o.Kind != "" {
+		return o.Kind.precipitating()
+	}
+
+	return o.ConditionID >= 200 && o.ConditionID < 700
+}
+
+// Summarizes stored observations near a place for one local date
+func observeDay(entries []observation, at coordinate, date string) observedDay {
+	day := observedDay{High: math.Inf(-1), Low: math.Inf(1)}
+
+	for _, entry := range nearbyHistory(entries, at) {
+		local := time.Unix(entry.Time, 0).In(time.FixedZone("", entry.TzOffset))
+		if local.Format(time.DateOnly) != date {
+			continue
+		}
+
+		day.Count++
+		day.High = math.Max(day.High, entry.Temp)
+		day.Low = math.Min(day.Low, entry.Temp)
+		day.Rained = day.Rained || entry.precipitating()
+	}
+
+	return day
+}
+
+// Implements `weather verify`, scoring stored forecasts against what was observed
+func runVerify(days int, place string, units string) error {
+	options, err := resolveDisplay(units, "")
+	if err != nil {
+		return err
+	}
+
+	forecasts, err := readRecords[forecastRecord](FORECASTS_FILE)
+	if err != nil {
+		return err
+	}
+
+	entries, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().Format(time.DateOnly)
+	oldest := time.Now().AddDate(0, 0, -days).Format(time.DateOnly)
+
+	// Keep only the latest forecast per location, provider, date and lead time
+	latest := map[string]forecastRecord{}
+	for _, record := range forecasts {
+		if record.Date >= today || record.Date < oldest {
+			continue
+		}
+		if place != "" && !strings.Contains(strings.ToLower(record.Location), strings.ToLower(place)) {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%s|%s|%d", record.Provider, record.Location, record.Date, record.Lead)
+		if existing, found := latest[key]; !found || record.Issued > existing.Issued {
+			latest[key] = record
+		}
+	}
+
+	scores := map[string]*accuracy{}
+	for _, record := range latest {
+		observed := observeDay(entries, coordinate{Lat: record.Lat, Lon: record.Lon}, record.Date)
+		if observed.Count < MIN_DAILY_OBSERVATIONS {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%d", record.Provider, record.Lead)
+		if scores[key] == nil {
+			scores[key] = &accuracy{}
+		}
+
+		outcome := 0.0
+		if observed.Rained {
+			outcome = 1
+		}
+
+		score := scores[key]
+		score.Days++
+		score.HighError += math.Abs(record.High - observed.High)
+		score.LowError += math.Abs(record.Low - observed.Low)
+		score.HighBias += record.High - observed.High
+		score.LowBias += record.Low - observed.Low
+		score.Brier += (record.Pop - outcome) * (record.Pop - outcome)
+	}
+
+	if len(scores) == 0 {
+		fmt.Println(tr("Not enough data to verify forecasts yet."))
+		fmt.Printf(tr("Forecasts are checked once at least %d observations exist for a past day.")+"\n", MIN_DAILY_OBSERVATIONS)
+		return nil
+	}
+
+	keys := make([]string, 0, len(scores))
+	for key := range scores {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Temperature differences scale without the offset
+	scale := 1.0
+	if options.Units == IMPERIAL {
+		scale = 9.0 / 5.0
+	}
+	symbol := options.Units.temperature()
+
+	fmt.Printf(tr("Forecast accuracy over the last %d days")+"\n\n", days)
+
+	rows := [][]string{translated("Provider", "Lead", "Days", "High error", "Low error", "High bias", "Low bias", "Rain score")}
+	for _, key := range keys {
+		provider, lead, _ := strings.Cut(key, "|")
+		score := scores[key]
+		count := float64(score.Days)
+
+		rows = append(rows, []string{
+			provider,
+			lead + "d",
+			fmt.Sprint(score.Days),
+			fmt.Sprintf("%.1f%s", score.HighError/count*scale, symbol),
+			fmt.Sprintf("%.1f%s", score.LowError/count*scale, symbol),
+			fmt.Sprintf("%+.1f%s", score.HighBias/count*scale, symbol),
+			fmt.Sprintf("%+.1f%s", score.LowBias/count*scale, symbol),
+			fmt.Sprintf("%.2f", score.Brier/count),
+		})
+	}
+
+	printTable(os.Stdout, rows)
+	fmt.Println("\n" + tr("Errors are mean absolute differences; rain score is the Brier score (0 is perfect, lower is better)."))
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Hours ahead `weather wear` dresses for, roughly a day out
+const WEAR_HOURS = 8
+
+// Wind in km/h from which a windproof outer layer is worth it
+const WINDY_KMH = 30.0
+
+// Degrees the wearer runs warm (positive) or cold (negative), in the
+// temperature unit shown, from -offset, WEATHER_WEAR_OFFSET or wear_offset
+var wearOffset float64
+
+// Clothes for a felt temperature in °C from its lower bound, warmest first.
+// Items are English labels that get translated.
+var clothingLayers = []struct {
+	From  float64
+	Items []string
+}{
+	{25, []string{"T-shirt", "Shorts or a skirt"}},
+	{20, []string{"T-shirt", "Light trousers"}},
+	{15, []string{"Long sleeves", "Light sweater or hoodie", "Trousers"}},
+	{10, []string{"Sweater", "Light jacket", "Trousers"}},
+	{5, []string{"Sweater", "Warm jacket", "Trousers"}},
+	{0, []string{"Thermal top", "Sweater", "Winter coat", "Hat and gloves"}},
+	{-10, []string{"Thermal base layer", "Fleece or wool sweater", "Winter coat", "Hat, scarf and gloves"}},
+	{-273.15, []string{"Thermal base layer", "Fleece or wool sweater", "Insulated winter coat", "Hat, scarf and warm gloves", "Insulated boots"}},
+}
+
+// The current conditions followed by the forecast hours within the span
+func (w weatherData) upcoming(hours int) []conditions {
+	until := w.Current.Time.Add(time.Duration(hours) * time.Hour)
+
+	moments := []conditions{w.Current}
+	for _, hour := range w.Hourly {
+		if hour.Time.After(w.Current.Time) && hour.Time.Before(until) {
+			moments = append(moments, hour)
+		}
+	}
+
+	return moments
+}
+
+// What to wear for a felt temperature in °C, plus extras for the wind and
+// what falls from the sky over the moments to come
+func clothingFor(feltC float64, moments []conditions, units unitSystem) []string {
+	var items []string
+	for _, band := range clothingLayers {
+		if feltC >= band.From {
+			items = append(items, band.Items...)
+			break
+		}
+	}
+
+	var windy, wet, snowy bool
+	for _, moment := range moments {
+		wind := max(moment.WindSpeed, moment.WindGust)
+		windy = windy || KILOMETERS_PER_HOUR.fromMetersPerSecond(units.toMetersPerSecond(wind)) >= WINDY_KMH
+
+		switch moment.Condition.Kind {
+		case SNOW, SLEET:
+			snowy = true
+		default:
+			wet = wet || moment.Pop >= RAIN_LIKELY || moment.Condition.Kind.precipitating()
+		}
+	}
+
+	// A t-shirt day doesn't call for a shell against the wind
+	if windy && feltC < 20 {
+		items = append(items, "Windproof outer layer")
+	}
+	if wet {
+		items = append(items, "Waterproof jacket or umbrella")
+	}
+	if snowy {
+		items = append(items, "Waterproof boots")
+	}
+
+	return items
+}
+
+// Implements `weather wear`, dressing for the coldest it feels over the
+// next WEAR_HOURS, shifted by the personal offset
+func runWear(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	options := result.Options
+	temperature := options.Units.temperature()
+	moments := result.Weather.upcoming(WEAR_HOURS)
+
+	coldest := moments[0]
+	for _, moment := range moments {
+		if moment.FeelsLike < coldest.FeelsLike {
+			coldest = moment
+		}
+	}
+
+	// Running cold means dressing as if it were colder than it feels
+	felt := coldest.FeelsLike + wearOffset
+
+	fmt.Printf(tr("Dressing for %.1f%s: feels like %.1f%s now, %.1f%s at the coldest (%s)")+"\n",
+		felt, temperature, moments[0].FeelsLike, temperature, coldest.FeelsLike, temperature, coldest.Time.Format(options.clockFormat()))
+	if wearOffset != 0 {
+		fmt.Printf(tr("Shifted by %+.1f%s for how warm you run")+"\n", wearOffset, temperature)
+	}
+
+	for _, item := range clothingFor(options.Units.toCelsius(felt), moments, options.Units) {
+		fmt.Printf("  - %s\n", tr(item))
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import "time"
+
+// Provider independent weather that every renderer works from. Each provider
+// adapter fills it in the units that were asked for: temperatures in °C or
+// °F, speeds in m/s or mph and precipitation in mm or inches. Pressure is
+// always hPa, visibility meters and humidity and clouds percentages. Times
+// are in the location's own zone.
+type weatherData struct {
+	Coord    coordinate
+	Timezone string
+	Offset   int // Seconds east of UTC
+	Current  conditions
+	Minutely []precipitationStep
+	Hourly   []conditions
+	Daily    []dailyForecast
+	Alerts   []weatherAlert
+}
+
+// The same weather with every moment moved to another zone. Daily dates stay
+// the place's own calendar days.
+func (w weatherData) in(options displayOptions) weatherData {
+	if options.Zone == nil {
+		return w
+	}
+
+	moved := func(c conditions) conditions {
+		c.Time = options.in(c.Time)
+		c.Sunrise = options.in(c.Sunrise)
+		c.Sunset = options.in(c.Sunset)
+		return c
+	}
+
+	w.Current = moved(w.Current)
+
+	w.Minutely = append([]precipitationStep(nil), w.Minutely...)
+	for index := range w.Minutely {
+		w.Minutely[index].Time = options.in(w.Minutely[index].Time)
+	}
+
+	hourly := make([]conditions, len(w.Hourly))
+	for index, hour := range w.Hourly {
+		hourly[index] = moved(hour)
+	}
+	w.Hourly = hourly
+
+	w.Daily = append([]dailyForecast(nil), w.Daily...)
+	for index := range w.Daily {
+		w.Daily[index].Sunrise = options.in(w.Daily[index].Sunrise)
+		w.Daily[index].Sunset = options.in(w.Daily[index].Sunset)
+	}
+
+	w.Alerts = append([]weatherAlert(nil), w.Alerts...)
+	for index := range w.Alerts {
+		w.Alerts[index].Start = options.in(w.Alerts[index].Start)
+		w.Alerts[index].End = options.in(w.Alerts[index].End)
+	}
+
+	return w
+}
+
+// Conditions at one moment, either observed now or forecast for an hour
+type conditions struct {
+	Time       time.Time
+	Temp       float64
+	FeelsLike  float64
+	DewPoint   float64
+	Pressure   int64
+	Humidity   int64
+	Clouds     int64
+	UVI        float64
+	Visibility int64 // Zero when the provider doesn't report it
+	WindSpeed  float64
+	WindDeg    int64
+	WindGust   float64
+	Condition  condition
+
+	// Chance (0-1) and amount of precipitation over the following hour,
+	// only filled in for hourly forecasts
+	Pop           float64
+	Precipitation float64
+
+	// Only filled in for the current conditions, zero when unknown
+	Sunrise time.Time
+	Sunset  time.Time
+}
+
+// Precipitation expected during one minute of the next hour
+type precipitationStep struct {
+	Time          time.Time
+	Precipitation float64 // Intensity per hour, like the hourly amounts
+}
+
+// Total precipitation expected over the minutely forecast and whether there
+// is one
+func (w weatherData) nextHourPrecipitation() (float64, bool) {
+	total := 0.0
+	for _, step := range w.Minutely {
+		total += step.Precipitation / 60
+	}
+
+	return total, len(w.Minutely) > 0
+}
+
+// The forecast hour a moment falls in, or the current conditions when it is
+// within the hour they were observed in
+func (w weatherData) hourAt(moment time.Time) (conditions, bool) {
+	for _, hour := range w.Hourly {
+		if !moment.Before(hour.Time) && moment.Before(hour.Time.Add(time.Hour)) {
+			return hour, true
+		}
+	}
+
+	if !moment.Before(w.Current.Time) && moment.Before(w.Current.Time.Add(time.Hour)) {
+		return w.Current, true
+	}
+
+	return conditions{}, false
+}
+
+// Outlook for one local calendar day
+type dailyForecast struct {
+	Date          time.Time // Local noon of the day
+	Sunrise       time.Time
+	Sunset        time.Time
+	TempMax       float64
+	TempMin       float64
+	Pressure      int64
+	Humidity      int64
+	Clouds        int64
+	UVI           float64
+	WindSpeed     float64
+	WindDeg       int64
+	WindGust      float64
+	Precipitation float64
+	Pop           float64
+	Condition     condition
+}
+
+// Official warning issued for the area
+type weatherAlert struct {
+	Event       string
+	Sender      string
+	Start       time.Time
+	End         time.Time
+	Description string
+	AreaName    string    // Counties or zones the alert names, when given
+	Area        []polygon // Drawn warning area, empty when whole zones are warned
+}
+
+// Broad kind of weather shared by every provider's condition codes
+type conditionKind string
+
+const (
+	CLEAR         conditionKind = "clear"
+	PARTLY_CLOUDY conditionKind = "partly-cloudy"
+	CLOUDY        conditionKind = "cloudy"
+	FOG           conditionKind = "fog"
+	DRIZZLE       conditionKind = "drizzle"
+	RAIN          conditionKind = "rain"
+	SHOWERS       conditionKind = "showers"
+	SLEET         conditionKind = "sleet"
+	SNOW          conditionKind = "snow"
+	THUNDERSTORM  conditionKind = "thunderstorm"
+)
+
+// Weather described by a kind plus the provider's own wording
+type condition struct {
+	Kind        conditionKind
+	Description string
+	Night       bool
+}
+
+// Emoji for each kind of weather by day and by night
+var conditionEmojis = map[conditionKind][2]string{
+	CLEAR:         {"☀️", "🌙"},
+	PARTLY_CLOUDY: {"🌤️", "🌥️"},
+	CLOUDY:        {"☁️", "☁️"},
+	FOG:           {"🌫️", "🌫️"},
+	DRIZZLE:       {"🌦️", "🌧️"},
+	RAIN:          {"🌦️", "🌧️"},
+	SHOWERS:       {"🌦️", "🌧️"},
+	SLEET:         {"🌨️", "🌨️"},
+	SNOW:          {"🌨️", "🌨️"},
+	THUNDERSTORM:  {"⛈️", "⛈️"},
+}
+
+// Emoji shown next to the condition
+func (c condition) emoji() string {
+	emojis, found := conditionEmojis[c.Kind]
+	if !found {
+		return ""
+	}
+
+	if c.Night {
+		return emojis[1]
+	}
+
+	return emojis[0]
+}
+
+// Whether the condition means something is falling from the sky
+func (k conditionKind) precipitating() bool {
+	switch k {
+	case DRIZZLE, RAIN, SHOWERS, SLEET, SNOW, THUNDERSTORM:
+		return true
+	}
+
+	return false
+}
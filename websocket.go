@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Appended to the key of a WebSocket handshake before hashing, from RFC 6455
+const WEBSOCKET_GUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	WEBSOCKET_CONTINUATION = 0x0
+	WEBSOCKET_TEXT         = 0x1
+	WEBSOCKET_CLOSE        = 0x8
+	WEBSOCKET_PING         = 0x9
+	WEBSOCKET_PONG         = 0xA
+)
+
+// Longest message accepted from a server
+const MAX_WEBSOCKET_MESSAGE = 1 << 20
+
+// The client end of a WebSocket connection, just enough of RFC 6455 for
+// live feeds: text messages, pings and closing
+type webSocket struct {
+	conn   io.ReadWriteCloser
+	reader *bufio.Reader
+}
+
+// Opens a WebSocket through the shared client, so proxies and TLS settings
+// apply as for every other request. The connection closes with the context.
+func dialWebSocket(ctx context.Context, address string) (*webSocket, error) {
+	target := strings.Replace(strings.Replace(address, "wss://", "https://", 1), "ws://", "http://", 1)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", redactError(err))
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("creating WebSocket key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req.Header.Set("User-Agent", USER_AGENT)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", req.URL.Host, redactError(err))
+	}
+
+	conn, upgraded := res.Body.(io.ReadWriteCloser)
+	if res.StatusCode != http.StatusSwitchingProtocols || !upgraded {
+		res.Body.Close()
+		return nil, fmt.Errorf("%s refused the WebSocket: %s", req.URL.Host, res.Status)
+	}
+
+	digest := sha1.Sum([]byte(key + WEBSOCKET_GUID))
+	if res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(digest[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("%s answered the WebSocket handshake wrongly", req.URL.Host)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	return &webSocket{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Sends one frame. Frames from clients are masked, as servers insist on it.
+func (w *webSocket) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		header = append(header, 0x80|byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 0x80|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	header = append(header, mask...)
+
+	masked := make([]byte, len(payload))
+	for index, value := range payload {
+		masked[index] = value ^ mask[index%4]
+	}
+
+	_, err := w.conn.Write(append(header, masked...))
+	return err
+}
+
+// Sends a text message
+func (w *webSocket) send(text string) error {
+	return w.writeFrame(WEBSOCKET_TEXT, []byte(text))
+}
+
+// Reads the next text message, answering pings on the way. io.EOF means
+// the server closed the connection.
+func (w *webSocket) read() (string, error) {
+	var message []byte
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(w.reader, header[:]); err != nil {
+			return "", err
+		}
+
+		final, opcode := header[0]&0x80 != 0, header[0]&0x0F
+		length := uint64(header[1] & 0x7F)
+		switch length {
+		case 126:
+			var extended [2]byte
+			if _, err := io.ReadFull(w.reader, extended[:]); err != nil {
+				return "", err
+			}
+			length = uint64(binary.BigEndian.Uint16(extended[:]))
+		case 127:
+			var extended [8]byte
+			if _, err := io.ReadFull(w.reader, extended[:]); err != nil {
+				return "", err
+			}
+			length = binary.BigEndian.Uint64(extended[:])
+		}
+
+		if length+uint64(len(message)) > MAX_WEBSOCKET_MESSAGE {
+			return "", errors.New("WebSocket message too long")
+		}
+
+		// Servers don't mask, but a mask would be harmless to honor
+		var mask [4]byte
+		masked := header[1]&0x80 != 0
+		if masked {
+			if _, err := io.ReadFull(w.reader, mask[:]); err != nil {
+				return "", err
+			}
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(w.reader, payload); err != nil {
+			return "", err
+		}
+		if masked {
+			for index := range payload {
+				payload[index] ^= mask[index%4]
+			}
+		}
+
+		switch opcode {
+		case WEBSOCKET_PING:
+			if err := w.writeFrame(WEBSOCKET_PONG, payload); err != nil {
+				return "", err
+			}
+			continue
+		case WEBSOCKET_PONG:
+			continue
+		case WEBSOCKET_CLOSE:
+			w.writeFrame(WEBSOCKET_CLOSE, nil)
+			return "", io.EOF
+		}
+
+		// Text and binary messages alike, possibly split over continuations
+		message = append(message, payload...)
+		if final {
+			return string(message), nil
+		}
+	}
+}
+
+// Closes the connection, telling the server first
+func (w *webSocket) close() error {
+	w.writeFrame(WEBSOCKET_CLOSE, nil)
+	return w.conn.Close()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Parts of the day the hourly forecast is summed up in, by local hour
+var dayParts = []struct {
+	Name string
+	From int
+	To   int
+}{
+	{"Morning", 6, 12},
+	{"Afternoon", 12, 18},
+	{"Evening", 18, 24},
+}
+
+// One line about a day of the daily forecast
+func (d dailyForecast) summary(options displayOptions) string {
+	temperature := options.Units.temperature()
+
+	return fmt.Sprintf("%s %s, %.0f–%.0f%s, %s %.0f%%, %s %s",
+		d.Condition.emoji(), d.Condition.Description, d.TempMin, d.TempMax, temperature,
+		tr("rain chance"), d.Pop*100, tr("wind"), options.wind(d.WindSpeed, 1))
+}
+
+// Hourly forecast of a local calendar day grouped into its parts, most
+// common condition first. Parts without forecast hours are left out.
+func (w weatherData) printDayParts(day time.Time, options displayOptions) {
+	temperature := options.Units.temperature()
+
+	rows := [][]string{translated("Part", "Condition", "Temp", "Rain chance", "Wind")}
+	for _, part := range dayParts {
+		var hours []conditions
+		for _, hour := range w.Hourly {
+			if sameDate(hour.Time, day) && hour.Time.Hour() >= part.From && hour.Time.Hour() < part.To {
+				hours = append(hours, hour)
+			}
+		}
+		if len(hours) == 0 {
+			continue
+		}
+
+		// The condition most of the hours share stands for the part
+		counts := map[conditionKind]int{}
+		typical := hours[0]
+		low, high, pop, wind := hours[0].Temp, hours[0].Temp, 0.0, 0.0
+		for _, hour := range hours {
+			counts[hour.Condition.Kind]++
+			if counts[hour.Condition.Kind] > counts[typical.Condition.Kind] {
+				typical = hour
+			}
+
+			low, high = min(low, hour.Temp), max(high, hour.Temp)
+			pop, wind = max(pop, hour.Pop), max(wind, hour.WindSpeed)
+		}
+
+		rows = append(rows, []string{
+			tr(part.Name),
+			typical.Condition.emoji() + " " + typical.Condition.Description,
+			fmt.Sprintf("%.0f–%.0f%s", low, high, temperature),
+			fmt.Sprintf("%.0f%%", pop*100),
+			options.wind(wind, 1),
+		})
+	}
+
+	if len(rows) > 1 {
+		printTable(os.Stdout, rows)
+	}
+}
+
+// Local midnight of the day the coming weekend starts: the next Saturday, or
+// today when the weekend has begun
+func weekendStart(now time.Time) time.Time {
+	year, month, date := now.Date()
+	today := time.Date(year, month, date, 0, 0, 0, 0, now.Location())
+	if today.Weekday() == time.Sunday {
+		return today
+	}
+
+	return today.AddDate(0, 0, (int(time.Saturday)-int(today.Weekday())+7)%7)
+}
+
+// Implements `weather weekend`, summing up the coming Saturday and Sunday,
+// or what is left of the weekend when it has begun
+func runWeekend(ctx context.Context, target location, units string) error {
+	result, err := fetchReport(ctx, target, units)
+	if err != nil {
+		return err
+	}
+
+	weather := result.Weather
+	options := result.Options
+
+	start := weekendStart(weather.Current.Time)
+	found := false
+	for _, day := range weather.Daily {
+		if !found && !sameDate(day.Date, start) {
+			continue
+		}
+
+		weekday := day.Date.Weekday()
+		if weekday != time.Saturday && weekday != time.Sunday {
+			break
+		}
+
+		fmt.Printf("\n%s: %s\n", day.Date.Format("Monday Jan 2"), day.summary(options))
+		weather.printDayParts(day.Date, options)
+		found = true
+
+		if weekday == time.Sunday {
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%s's forecast doesn't reach the weekend yet", result.Provider)
+	}
+
+	return nil
+}
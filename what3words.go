@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// what3words' address lookup, which needs a personal API key
+const WHAT3WORDS_URL = "https://api.what3words.com/v3/convert-to-coordinates"
+
+// Personal what3words API key, from the config file or the system keyring
+var what3wordsAPIKey string
+
+// Three words joined by dots, as in ///filled.count.soap. Words may be in
+// any script.
+var threeWordsPattern = regexp.MustCompile(`^\p{L}+\.\p{L}+\.\p{L}+$`)
+
+// what3words' answer for an address
+type what3wordsResponse struct {
+	Country     string `json:"country"`
+	NearestName string `json:"nearestPlace"`
+	Words       string `json:"words"`
+	Coordinates struct {
+		Lat float64 `json:"lat"`
+		Lng float64 `json:"lng"`
+	} `json:"coordinates"`
+}
+
+// Reports whether a query is a what3words address, returning its words.
+// Without the leading "///" the words must be in lower case, so names like
+// "Washington.D.C" stay place names.
+func parseThreeWords(query string) (string, bool) {
+	query = strings.TrimSpace(query)
+
+	words, prefixed := strings.CutPrefix(query, "///")
+	if !threeWordsPattern.MatchString(words) || !prefixed && words != strings.ToLower(words) {
+		return "", false
+	}
+
+	return strings.ToLower(words), true
+}
+
+// Resolves a what3words address to the middle of its three metre square
+func findThreeWords(ctx context.Context, words string) (locationSearchResult, error) {
+	// The keyring is only asked when an address is looked up, as every
+	// lookup starts a helper program
+	if what3wordsAPIKey == "" {
+		key, err := keyringGet("what3words")
+		if err != nil && !errors.Is(err, errNoKey) {
+			logger.Debug("keyring lookup failed", "error", err)
+		}
+		what3wordsAPIKey = key
+	}
+
+	if what3wordsAPIKey == "" {
+		return locationSearchResult{}, errors.New("what3words addresses need a personal API key, add one with weather auth set what3words or under [api_keys] in the config")
+	}
+
+	status("[@] Looking up ///" + words)
+
+	TARGET_URL := fmt.Sprintf("%s?words=%s&key=%s", WHAT3WORDS_URL, url.QueryEscape(words), url.QueryEscape(what3wordsAPIKey))
+
+	body, err := fetch(ctx, TARGET_URL)
+	if err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.Code == http.StatusBadRequest {
+			return locationSearchResult{}, fmt.Errorf("///%s is not a what3words address", words)
+		}
+		if errors.As(err, &statusErr) && statusErr.Code == http.StatusUnauthorized {
+			return locationSearchResult{}, errors.New("what3words rejected the API key")
+		}
+
+		return locationSearchResult{}, fmt.Errorf("looking up ///%s: %w", words, err)
+	}
+
+	var parsed what3wordsResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return locationSearchResult{}, fmt.Errorf("parsing what3words response: %w", err)
+	}
+
+	name := "///" + parsed.Words
+	fullName := name
+	if parsed.NearestName != "" {
+		fullName += " near " + parsed.NearestName
+	}
+
+	return locationSearchResult{Count: 1, Lists: []location{{
+		Coord:    coordinate{Lat: parsed.Coordinates.Lat, Lon: parsed.Coordinates.Lng},
+		Name:     name,
+		FullName: fullName,
+		Country:  strings.ToUpper(parsed.Country),
+	}}}, nil
+}